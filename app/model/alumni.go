@@ -7,18 +7,23 @@ import (
 )
 
 type Alumni struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	UserID     primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"` // Relasi ke User
-	NIM        string             `bson:"nim" json:"nim"`
-	Nama       string             `bson:"nama" json:"nama"`
-	Jurusan    string             `bson:"jurusan" json:"jurusan"`
-	Angkatan   int                `bson:"angkatan" json:"angkatan"`
-	TahunLulus int                `bson:"tahun_lulus" json:"tahun_lulus"`
-	Email      string             `bson:"email" json:"email"`
-	NoTelepon  *string            `bson:"no_telepon,omitempty" json:"no_telepon,omitempty"`
-	Alamat     *string            `bson:"alamat,omitempty" json:"alamat,omitempty"`
-	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID     primitive.ObjectID  `bson:"user_id,omitempty" json:"user_id,omitempty"` // Relasi ke User
+	NIM        string              `bson:"nim" json:"nim"`
+	Nama       string              `bson:"nama" json:"nama"`
+	Jurusan    string              `bson:"jurusan" json:"jurusan"`
+	Angkatan   int                 `bson:"angkatan" json:"angkatan"`
+	TahunLulus int                 `bson:"tahun_lulus" json:"tahun_lulus"`
+	Email      string              `bson:"email" json:"email"`
+	NoTelepon  *string             `bson:"no_telepon,omitempty" json:"no_telepon,omitempty"`
+	Alamat     *string             `bson:"alamat,omitempty" json:"alamat,omitempty"`
+	IsDeleted  bool                `bson:"is_deleted,omitempty" json:"is_deleted"` // Data lama tanpa field ini dianggap aktif
+	DeletedAt  *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedBy  *primitive.ObjectID `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time           `bson:"updated_at" json:"updated_at"`
+	Version    int                 `bson:"version" json:"version"`                         // Naik di setiap perubahan, dikirim sebagai ETag
+	LegacyID   *int                `bson:"legacy_id,omitempty" json:"legacy_id,omitempty"` // id di PostgreSQL sistem lama (sinkronisasi legacy)
 }
 
 type CreateAlumniRequest struct {
@@ -42,3 +47,60 @@ type UpdateAlumniRequest struct {
 	NoTelepon  *string `json:"no_telepon"`
 	Alamat     *string `json:"alamat"`
 }
+
+// AlumniCascade merangkum data terkait yang ikut dipindahkan ke trash,
+// dipulihkan, atau dihapus permanen bersama alumni
+type AlumniCascade struct {
+	Pekerjaan []PekerjaanAlumni `json:"-"` // Kondisi sebelum perubahan, untuk audit
+	Files     []File            `json:"-"`
+}
+
+// AlumniCascadeSummary adalah ringkasan AlumniCascade untuk response API
+type AlumniCascadeSummary struct {
+	Pekerjaan int `json:"pekerjaan"`
+	Files     int `json:"files"`
+}
+
+func (c *AlumniCascade) Summary() AlumniCascadeSummary {
+	return AlumniCascadeSummary{Pekerjaan: len(c.Pekerjaan), Files: len(c.Files)}
+}
+
+// UpdateMyAlumniRequest adalah field yang boleh diubah alumni pada datanya sendiri
+type UpdateMyAlumniRequest struct {
+	Email     string  `json:"email" validate:"required,email"`
+	NoTelepon *string `json:"no_telepon"`
+	Alamat    *string `json:"alamat"`
+}
+
+// LinkAlumniUserRequest dipakai admin untuk menghubungkan akun user ke data alumni
+type LinkAlumniUserRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+}
+
+type ClaimAlumniRequest struct {
+	NIM string `json:"nim" validate:"required"`
+}
+
+type VerifyClaimAlumniRequest struct {
+	NIM  string `json:"nim" validate:"required"`
+	Code string `json:"code" validate:"required"`
+}
+
+// ClaimAlumniResponse memberi tahu ke mana kode verifikasi dikirim
+type ClaimAlumniResponse struct {
+	EmailMasked string    `json:"email_masked"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// AlumniClaim disimpan di koleksi "alumni_claims". Kode verifikasi dikirim ke
+// email yang tercatat di data alumni; yang disimpan hanya hash-nya.
+type AlumniClaim struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	AlumniID  primitive.ObjectID `bson:"alumni_id" json:"alumni_id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	CodeHash  string             `bson:"code_hash" json:"-"`
+	Attempts  int                `bson:"attempts" json:"attempts"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty" json:"used_at,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKeyPrefix menandai string API key agar mudah dikenali (misalnya oleh secret scanner)
+const APIKeyPrefix = "ak_"
+
+// APIKeyForbiddenScopes tidak boleh diberikan ke API key karena memungkinkan
+// key menaikkan hak aksesnya sendiri (membuat user, role atau key baru)
+var APIKeyForbiddenScopes = []string{PermUsersManage, PermRolesManage, PermAPIKeysManage}
+
+// APIKey disimpan di koleksi "api_keys" untuk integrasi antar sistem.
+// Key utuh hanya ditampilkan sekali saat dibuat; yang disimpan hanya hash SHA-256-nya.
+type APIKey struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name       string             `bson:"name" json:"name"`
+	Prefix     string             `bson:"prefix" json:"prefix"` // Bagian awal key, untuk identifikasi di daftar/log
+	KeyHash    string             `bson:"key_hash" json:"-"`
+	Scopes     []string           `bson:"scopes" json:"scopes"` // Permission yang diberikan ke key ini
+	CreatedBy  primitive.ObjectID `bson:"created_by" json:"created_by"`
+	ExpiresAt  *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	LastUsedIP string             `bson:"last_used_ip,omitempty" json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" validate:"required"`
+	Scopes        []string `json:"scopes" validate:"required"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 = tidak kedaluwarsa
+}
+
+// CreateAPIKeyResponse berisi key utuh yang tidak bisa ditampilkan lagi
+type CreateAPIKeyResponse struct {
+	Key    string  `json:"key"`
+	APIKey *APIKey `json:"api_key"`
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Jenis data yang dicatat di audit log
+const (
+	AuditEntityAlumni        = "alumni"
+	AuditEntityPekerjaan     = "pekerjaan"
+	AuditEntityFile          = "file"
+	AuditEntityUser          = "user"
+	AuditEntityPendingChange = "pending_change"
+	AuditEntityRole          = "role"
+	AuditEntityAPIKey        = "api_key"
+	AuditEntityIP            = "ip" // Kunci login per IP
+)
+
+// Aksi yang dicatat di audit log
+const (
+	AuditActionCreate         = "create"
+	AuditActionUpdate         = "update"
+	AuditActionDelete         = "delete"
+	AuditActionSoftDelete     = "soft_delete"
+	AuditActionRestore        = "restore"
+	AuditActionHardDelete     = "hard_delete"
+	AuditActionLink           = "link"
+	AuditActionUnlink         = "unlink"
+	AuditActionClaim          = "claim"
+	AuditActionSubmit         = "submit" // Perubahan diajukan ke antrian moderasi
+	AuditActionApprove        = "approve"
+	AuditActionReject         = "reject"
+	AuditActionRegister       = "register"
+	AuditActionPasswordChange = "password_change"
+	AuditActionPasswordReset  = "password_reset"
+	AuditActionMFAEnable      = "mfa_enable"
+	AuditActionMFADisable     = "mfa_disable"
+	AuditActionLogoutAll      = "logout_all"
+	AuditActionRevert         = "revert" // Data dikembalikan ke versi lama
+	AuditActionPurge          = "purge"  // Dihapus permanen oleh auto-purge trash
+	AuditActionLegalHold      = "legal_hold"
+	AuditActionLegalRelease   = "legal_hold_release"
+	AuditActionDisable        = "disable"
+	AuditActionEnable         = "enable"
+	AuditActionUnlock         = "unlock" // Kunci login sementara dibuka admin
+	AuditActionRevoke         = "revoke"
+)
+
+// AuditLog adalah satu catatan perubahan data. Koleksi audit_log hanya
+// ditambah, tidak pernah diubah atau dihapus lewat aplikasi.
+type AuditLog struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	ActorID       *primitive.ObjectID `bson:"actor_id,omitempty" json:"actor_id,omitempty"` // Kosong untuk request anonim (register, reset password)
+	ActorUsername string              `bson:"actor_username,omitempty" json:"actor_username,omitempty"`
+	ActorRole     string              `bson:"actor_role,omitempty" json:"actor_role,omitempty"`
+	Action        string              `bson:"action" json:"action"`
+	EntityType    string              `bson:"entity_type" json:"entity_type"`
+	EntityID      string              `bson:"entity_id,omitempty" json:"entity_id,omitempty"`
+	Before        bson.M              `bson:"before,omitempty" json:"before,omitempty"`
+	After         bson.M              `bson:"after,omitempty" json:"after,omitempty"`
+	IP            string              `bson:"ip" json:"ip"`
+	UserAgent     string              `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	RequestID     string              `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	Method        string              `bson:"method" json:"method"`
+	Path          string              `bson:"path" json:"path"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// AuditLogFilter dipakai endpoint query dan export; field kosong berarti tanpa filter
+type AuditLogFilter struct {
+	ActorID       *primitive.ObjectID
+	ActorUsername string
+	EntityType    string
+	EntityID      string
+	Action        string
+	From          *time.Time
+	To            *time.Time
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -8,13 +9,31 @@ import (
 )
 
 type User struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Username     string             `bson:"username" json:"username"`
-	Email        string             `bson:"email" json:"email"`
-	PasswordHash string             `bson:"password_hash" json:"-"` // Jangan kirim hash ke client
-	Role         string             `bson:"role" json:"role"`
-	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Username          string             `bson:"username" json:"username"`
+	Email             string             `bson:"email" json:"email"`
+	PasswordHash      string             `bson:"password_hash" json:"-"` // Jangan kirim hash ke client
+	Role              string             `bson:"role" json:"role"`
+	Scope             *UserScope         `bson:"scope,omitempty" json:"scope,omitempty"`
+	IsDisabled        bool               `bson:"is_disabled" json:"is_disabled"`
+	DisabledAt        *time.Time         `bson:"disabled_at,omitempty" json:"disabled_at,omitempty"`
+	PasswordChangedAt *time.Time         `bson:"password_changed_at,omitempty" json:"password_changed_at,omitempty"`
+	TOTPEnabled       bool               `bson:"totp_enabled" json:"totp_enabled"`
+	TOTPSecret        string             `bson:"totp_secret,omitempty" json:"-"`
+	TOTPPendingSecret string             `bson:"totp_pending_secret,omitempty" json:"-"`               // Menunggu verifikasi kode pertama
+	TOTPLastStep      int64              `bson:"totp_last_step,omitempty" json:"-"`                    // Mencegah kode yang sama dipakai ulang
+	RecoveryCodes     []string           `bson:"recovery_codes,omitempty" json:"-"`                    // Hash SHA-256 kode pemulihan
+	OIDCSubject       string             `bson:"oidc_subject,omitempty" json:"oidc_subject,omitempty"` // "sub" dari IdP kampus jika akun terhubung SSO
+	VerifiedEmail     string             `bson:"verified_email,omitempty" json:"-"`                    // Email yang kepemilikannya sudah dibuktikan (link reset password, IdP)
+	LegacyID          *int               `bson:"legacy_id,omitempty" json:"legacy_id,omitempty"`       // id di PostgreSQL sistem lama (sinkronisasi legacy)
+	CreatedAt         time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt         time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// HasVerifiedEmail bernilai true jika email saat ini sudah dibuktikan milik
+// user. Mengganti email otomatis membatalkan status ini.
+func (u *User) HasVerifiedEmail() bool {
+	return u.Email != "" && strings.EqualFold(u.VerifiedEmail, u.Email)
 }
 
 type LoginRequest struct {
@@ -22,14 +41,83 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// RegisterRequest dipakai untuk pendaftaran mandiri (role selalu "user")
+type RegisterRequest struct {
+	Username string `json:"username" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// CreateUserRequest dipakai admin untuk membuat user dengan role tertentu
+type CreateUserRequest struct {
+	Username string     `json:"username" validate:"required"`
+	Email    string     `json:"email" validate:"required,email"`
+	Password string     `json:"password" validate:"required"`
+	Role     string     `json:"role" validate:"required"`
+	Scope    *UserScope `json:"scope"` // Opsional, batasi ke jurusan/fakultas tertentu
+}
+
+type UpdateUserRequest struct {
+	Username string     `json:"username" validate:"required"`
+	Email    string     `json:"email" validate:"required,email"`
+	Role     string     `json:"role" validate:"required"`
+	Scope    *UserScope `json:"scope"`    // nil menghapus scope
+	Password *string    `json:"password"` // Opsional, hanya diubah jika diisi
+}
+
 type LoginResponse struct {
-	User  User   `json:"user"`
-	Token string `json:"token"`
+	User             User      `json:"user"`
+	Token            string    `json:"token"` // Access token (berumur pendek)
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
 }
 
 type JWTClaims struct {
-	UserID   string `json:"user_id"` // Kita gunakan string untuk ObjectID
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	UserID   string     `json:"user_id"` // Kita gunakan string untuk ObjectID
+	Username string     `json:"username"`
+	Role     string     `json:"role"`
+	Purpose  string     `json:"purpose,omitempty"` // Kosong untuk access token, "mfa"/"mfa_setup" untuk token challenge
+	Scope    *UserScope `json:"scope,omitempty"`
+	// RegisteredClaims.ID (jti) dipakai untuk pencabutan token
 	jwt.RegisteredClaims
 }
+
+// Purpose token challenge 2FA
+const (
+	TokenPurposeMFA      = "mfa"       // Password benar, menunggu kode TOTP
+	TokenPurposeMFASetup = "mfa_setup" // Admin wajib 2FA tapi belum mendaftar
+)
+
+// MFAChallenge dikembalikan oleh login sebagai pengganti token penuh jika 2FA diperlukan
+type MFAChallenge struct {
+	MFARequired      bool      `json:"mfa_required"`
+	MFASetupRequired bool      `json:"mfa_setup_required"`
+	MFAToken         string    `json:"mfa_token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code"`          // Kode TOTP 6 digit
+	RecoveryCode string `json:"recovery_code"` // Alternatif jika perangkat hilang
+}
+
+type TOTPSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+type TOTPVerifyRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TOTPVerifyResponse struct {
+	RecoveryCodes []string       `json:"recovery_codes"`
+	Login         *LoginResponse `json:"login,omitempty"` // Terisi jika verifikasi dilakukan saat login wajib-2FA
+}
+
+type TOTPDisableRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
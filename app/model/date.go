@@ -0,0 +1,151 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// dateLayout sama dengan helper.DateLayout (model tidak boleh mengimpor helper)
+const dateLayout = "2006-01-02"
+
+// dateLayouts adalah format tanggal yang diterima dari input/data lama.
+// Format tanpa hari dianggap tanggal 1 pada bulan tersebut.
+var dateLayouts = []string{
+	dateLayout,
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006/01/02",
+	"02-01-2006",
+	"02/01/2006",
+	"2-1-2006",
+	"2/1/2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"January 2006",
+	"Jan 2006",
+	"2006-01",
+	"01/2006",
+	"01-2006",
+}
+
+// bulanIndonesia memetakan nama bulan berbahasa Indonesia ke bahasa Inggris
+// agar bisa diparse dengan layout standar
+var bulanIndonesia = strings.NewReplacer(
+	"januari", "January", "februari", "February", "maret", "March",
+	"april", "April", "mei", "May", "juni", "June", "juli", "July",
+	"agustus", "August", "september", "September", "oktober", "October",
+	"november", "November", "desember", "December",
+	"agu", "Aug", "okt", "Oct", "des", "Dec",
+)
+
+// Date adalah tanggal tanpa jam (UTC). Disimpan sebagai BSON date agar bisa
+// diurutkan dan dibandingkan di query, dan dikirim di JSON sebagai YYYY-MM-DD.
+type Date struct {
+	time.Time
+}
+
+// NewDate membuang jam dari t
+func NewDate(t time.Time) Date {
+	return Date{time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(dateLayout)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = Date{}
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	parsed, ok := parseStoredDate(value)
+	if !ok {
+		return fmt.Errorf("format tanggal %q tidak dikenali (gunakan YYYY-MM-DD)", value)
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Date) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if d.IsZero() {
+		return bson.MarshalValue(nil)
+	}
+	return bson.MarshalValue(d.Time)
+}
+
+// UnmarshalBSONValue juga menerima tanggal string dari data yang belum
+// dimigrasi (pekerjaan_dates_to_date) dan dari snapshot riwayat lama, dalam
+// semua format yang diterima ParseDate. String kosong dibaca sebagai tanggal
+// kosong; string yang tetap tidak dikenali (dilewati migrasi) menjadi error
+// agar tidak diam-diam tertimpa tanggal kosong saat data disimpan ulang.
+func (d *Date) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bsontype.DateTime:
+		*d = NewDate(raw.Time().UTC())
+	case bsontype.String:
+		value := raw.StringValue()
+		if strings.TrimSpace(value) == "" {
+			*d = Date{}
+			return nil
+		}
+		parsed, err := ParseDate(value)
+		if err != nil {
+			return fmt.Errorf("tanggal tersimpan tidak valid: %v", err)
+		}
+		*d = parsed
+	case bsontype.Null, bsontype.Undefined:
+		*d = Date{}
+	default:
+		return fmt.Errorf("tipe BSON %s tidak bisa dibaca sebagai tanggal", t)
+	}
+	return nil
+}
+
+// ParseDate membaca tanggal dalam salah satu format yang diterima
+// (mis. 2021-03-15, 15/03/2021, 15 Maret 2021, Maret 2021) sebagai UTC.
+func ParseDate(value string) (Date, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Date{}, fmt.Errorf("tanggal kosong")
+	}
+	normalized := bulanIndonesia.Replace(strings.ToLower(value))
+	for _, layout := range dateLayouts {
+		for _, candidate := range []string{value, normalized} {
+			if t, err := time.Parse(layout, candidate); err == nil {
+				return NewDate(t), nil
+			}
+		}
+	}
+	return Date{}, fmt.Errorf("format tanggal %q tidak dikenali (gunakan YYYY-MM-DD)", value)
+}
+
+// parseStoredDate membaca tanggal yang ditulis sistem ini (YYYY-MM-DD atau
+// RFC3339). Format input bebas dibaca dengan ParseDate.
+func parseStoredDate(value string) (Date, bool) {
+	for _, layout := range []string{dateLayout, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return NewDate(t), true
+		}
+	}
+	return Date{}, false
+}
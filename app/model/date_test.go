@@ -0,0 +1,118 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestDateJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		date Date
+		want string
+	}{
+		{"tanggal", NewDate(time.Date(2021, 3, 15, 23, 59, 0, 0, time.UTC)), `"2021-03-15"`},
+		{"kosong", Date{}, `null`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Fatalf("Marshal = %s, want %s", data, tt.want)
+			}
+			var back Date
+			if err := json.Unmarshal(data, &back); err != nil {
+				t.Fatal(err)
+			}
+			if !back.Equal(tt.date.Time) {
+				t.Errorf("Unmarshal = %v, want %v", back, tt.date)
+			}
+		})
+	}
+
+	var d Date
+	if err := json.Unmarshal([]byte(`"15 Maret 2021"`), &d); err == nil {
+		t.Errorf("Unmarshal format bebas = %v, want error", d)
+	}
+}
+
+func TestDateBSON(t *testing.T) {
+	type doc struct {
+		Tanggal Date `bson:"tanggal"`
+	}
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"datetime", time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC), "2021-03-15"},
+		{"string iso", "2021-03-15", "2021-03-15"},
+		{"string rfc3339", "2021-03-15T00:00:00Z", "2021-03-15"},
+		{"string format bebas", "15/03/2021", "2021-03-15"},
+		{"string bulan indonesia", "Maret 2021", "2021-03-01"},
+		{"string kosong", "  ", ""},
+		{"null", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := bson.Marshal(bson.M{"tanggal": tt.value})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got doc
+			if err := bson.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal error: %v", err)
+			}
+			if got.Tanggal.String() != tt.want {
+				t.Errorf("Tanggal = %q, want %q", got.Tanggal.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("string tidak dikenali", func(t *testing.T) {
+		data, err := bson.Marshal(bson.M{"tanggal": "entah kapan"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got doc
+		if err := bson.Unmarshal(data, &got); err == nil {
+			t.Errorf("Unmarshal = %q, want error", got.Tanggal.String())
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		in := doc{Tanggal: NewDate(time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC))}
+		data, err := bson.Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw := bson.Raw(data).Lookup("tanggal")
+		if raw.Type != bson.TypeDateTime {
+			t.Fatalf("tipe BSON = %s, want datetime", raw.Type)
+		}
+		var out doc
+		if err := bson.Unmarshal(data, &out); err != nil {
+			t.Fatal(err)
+		}
+		if !out.Tanggal.Equal(in.Tanggal.Time) {
+			t.Errorf("round trip = %v, want %v", out.Tanggal, in.Tanggal)
+		}
+	})
+
+	t.Run("tipe lain ditolak", func(t *testing.T) {
+		data, err := bson.Marshal(bson.M{"tanggal": 20210315})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out doc
+		if err := bson.Unmarshal(data, &out); err == nil {
+			t.Errorf("Unmarshal int = %v, want error", out.Tanggal)
+		}
+	})
+}
@@ -0,0 +1,13 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// DuplicateGroup adalah sekelompok dokumen dengan nilai sama pada field yang
+// akan diberi unique index. Selama masih ada, index tersebut gagal dibuat.
+type DuplicateGroup struct {
+	Collection string               `json:"collection"`
+	Field      string               `json:"field"`
+	Value      interface{}          `json:"value"`
+	Count      int                  `json:"count"`
+	IDs        []primitive.ObjectID `json:"ids"`
+}
@@ -17,4 +17,5 @@ type File struct {
 	FileSize     int64              `bson:"file_size" json:"file_size"`
 	FileType     string             `bson:"file_type" json:"file_type"` // MIME Type, misal: "image/png"
 	UploadedAt   time.Time          `bson:"uploaded_at" json:"uploaded_at"`
+	IsHidden     bool               `bson:"is_hidden,omitempty" json:"-"` // Disembunyikan selama alumni pemiliknya di trash
 }
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Hasil percobaan login yang dicatat di koleksi "login_attempts"
+const (
+	LoginResultSuccess            = "success"
+	LoginResultInvalidCredentials = "invalid_credentials"
+	LoginResultUnknownUser        = "unknown_user"
+	LoginResultLocked             = "locked"
+	LoginResultDisabled           = "disabled"
+	LoginResultMFAChallenge       = "mfa_challenge" // Password benar, menunggu kode 2FA
+	LoginResultInvalidMFA         = "invalid_mfa"
+)
+
+// LoginAttempt adalah catatan audit setiap percobaan login (berhasil maupun gagal)
+type LoginAttempt struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	Identifier string              `bson:"identifier" json:"identifier"` // Username/email yang dikirim
+	UserID     *primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	IP         string              `bson:"ip" json:"ip"`
+	UserAgent  string              `bson:"user_agent" json:"user_agent"`
+	Result     string              `bson:"result" json:"result"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// LoginThrottle menyimpan penghitung kegagalan per key ("user:<id>" atau "ip:<ip>")
+type LoginThrottle struct {
+	Key           string     `bson:"_id" json:"key"`
+	Failures      int        `bson:"failures" json:"failures"`
+	LastFailureAt time.Time  `bson:"last_failure_at" json:"last_failure_at"`
+	LockedUntil   *time.Time `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
+}
+
+// LoginAttemptFilter dipakai untuk query audit login oleh admin
+type LoginAttemptFilter struct {
+	Identifier string
+	UserID     *primitive.ObjectID
+	IP         string
+	Result     string
+	From       *time.Time
+	To         *time.Time
+}
+
+type UnlockIPRequest struct {
+	IP string `json:"ip" validate:"required"`
+}
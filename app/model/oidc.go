@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OIDCLoginState disimpan di koleksi "oidc_states" selama user berada di halaman
+// login IdP. Hanya bisa dipakai sekali; yang disimpan hanya hash dari state.
+type OIDCLoginState struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty"`
+	StateHash    string              `bson:"state_hash"`
+	Nonce        string              `bson:"nonce"`
+	CodeVerifier string              `bson:"code_verifier"` // PKCE, dikirim saat menukar code
+	IP           string              `bson:"ip,omitempty"`
+	LinkUserID   *primitive.ObjectID `bson:"link_user_id,omitempty"` // Diisi jika alur ini menghubungkan akun yang sedang login
+	ExpiresAt    time.Time           `bson:"expires_at"`
+	CreatedAt    time.Time           `bson:"created_at"`
+}
+
+// OIDCIdentity adalah claim ID token yang sudah diverifikasi
+type OIDCIdentity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+	NIM               string
+	Nonce             string
+}
+
+type OIDCAuthURLResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
@@ -6,6 +6,14 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Status persetujuan pekerjaan yang diajukan alumni sendiri
+// (hanya dipakai jika PEKERJAAN_REQUIRE_APPROVAL aktif)
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
 type PekerjaanAlumni struct {
 	ID                  primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
 	AlumniID            primitive.ObjectID  `bson:"alumni_id" json:"alumni_id"`
@@ -14,19 +22,78 @@ type PekerjaanAlumni struct {
 	BidangIndustri      string              `bson:"bidang_industri" json:"bidang_industri"`
 	LokasiKerja         string              `bson:"lokasi_kerja" json:"lokasi_kerja"`
 	GajiRange           *string             `bson:"gaji_range,omitempty" json:"gaji_range,omitempty"`
-	TanggalMulaiKerja   string              `bson:"tanggal_mulai_kerja" json:"tanggal_mulai_kerja"`
-	TanggalSelesaiKerja *string             `bson:"tanggal_selesai_kerja,omitempty" json:"tanggal_selesai_kerja,omitempty"`
+	TanggalMulaiKerja   Date                `bson:"tanggal_mulai_kerja" json:"tanggal_mulai_kerja"`
+	TanggalSelesaiKerja *Date               `bson:"tanggal_selesai_kerja,omitempty" json:"tanggal_selesai_kerja,omitempty"`
 	StatusPekerjaan     string              `bson:"status_pekerjaan" json:"status_pekerjaan"`
+	JenisPekerjaan      string              `bson:"jenis_pekerjaan,omitempty" json:"jenis_pekerjaan,omitempty"` // Kosong = data lama, dianggap full time
 	DeskripsiPekerjaan  *string             `bson:"deskripsi_pekerjaan,omitempty" json:"deskripsi_pekerjaan,omitempty"`
 	IsDeleted           bool                `bson:"is_deleted" json:"is_deleted"`
 	DeletedAt           *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 	DeletedBy           *primitive.ObjectID `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+	DeleteReason        string              `bson:"delete_reason,omitempty" json:"delete_reason,omitempty"`
+	RestoreHistory      []RestoreEvent      `bson:"restore_history,omitempty" json:"restore_history,omitempty"`         // Urut dari yang paling lama
+	DeletedWithAlumni   bool                `bson:"deleted_with_alumni,omitempty" json:"deleted_with_alumni,omitempty"` // Masuk trash karena alumninya dihapus, ikut dipulihkan bersamanya
+	LegalHold           bool                `bson:"legal_hold,omitempty" json:"legal_hold,omitempty"`                   // Tidak boleh dihapus permanen, termasuk oleh auto-purge
+	LegalHoldReason     string              `bson:"legal_hold_reason,omitempty" json:"legal_hold_reason,omitempty"`
+	LegalHoldBy         *primitive.ObjectID `bson:"legal_hold_by,omitempty" json:"legal_hold_by,omitempty"`
+	LegalHoldAt         *time.Time          `bson:"legal_hold_at,omitempty" json:"legal_hold_at,omitempty"`
+	ApprovalStatus      string              `bson:"approval_status,omitempty" json:"approval_status,omitempty"` // Kosong = data lama, dianggap approved
+	ReviewedBy          *primitive.ObjectID `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	ReviewedAt          *time.Time          `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+	RejectReason        string              `bson:"reject_reason,omitempty" json:"reject_reason,omitempty"`
 	CreatedAt           time.Time           `bson:"created_at" json:"created_at"`
 	UpdatedAt           time.Time           `bson:"updated_at" json:"updated_at"`
+	Version             int                 `bson:"version" json:"version"`                         // Naik di setiap perubahan, dikirim sebagai ETag
+	LegacyID            *int                `bson:"legacy_id,omitempty" json:"legacy_id,omitempty"` // id di PostgreSQL sistem lama (sinkronisasi legacy)
+}
+
+// Status pekerjaan. Pekerjaan aktif belum punya tanggal selesai, pekerjaan
+// yang selesai/resigned wajib punya.
+const (
+	StatusPekerjaanAktif    = "aktif"
+	StatusPekerjaanSelesai  = "selesai"
+	StatusPekerjaanResigned = "resigned"
+)
+
+// Jenis pekerjaan. Hanya pekerjaan full time yang tidak boleh tumpang tindih
+// dengan pekerjaan full time lain milik alumni yang sama.
+const (
+	JenisFullTime  = "full_time"
+	JenisPartTime  = "part_time"
+	JenisKontrak   = "kontrak"
+	JenisFreelance = "freelance"
+	JenisMagang    = "magang"
+)
+
+var JenisPekerjaanList = []string{JenisFullTime, JenisPartTime, JenisKontrak, JenisFreelance, JenisMagang}
+
+// IsFullTime menganggap pekerjaan tanpa jenis (data lama) sebagai full time
+func (p *PekerjaanAlumni) IsFullTime() bool {
+	return p.JenisPekerjaan == "" || p.JenisPekerjaan == JenisFullTime
+}
+
+// PeriodePekerjaan adalah tanggal mulai dan selesai pekerjaan yang sudah
+// dibaca dan divalidasi dari request. Selesai nil berarti masih berjalan.
+type PeriodePekerjaan struct {
+	Mulai   Date
+	Selesai *Date
+}
+
+// Overlaps memakai aturan yang sama dengan query FindOverlappingFullTime:
+// periode tanpa tanggal selesai berjalan sampai sekarang, dan pindah kerja di
+// hari yang sama (selesai = mulai) tidak dianggap tumpang tindih.
+func (p PeriodePekerjaan) Overlaps(other PeriodePekerjaan) bool {
+	if other.Selesai != nil && !other.Selesai.After(p.Mulai.Time) {
+		return false
+	}
+	if p.Selesai != nil && !other.Mulai.Before(p.Selesai.Time) {
+		return false
+	}
+	return true
 }
 
 type CreatePekerjaanRequest struct {
-	AlumniID            string  `json:"alumni_id" validate:"required"` // Terima sebagai string
+	AlumniID            string  `json:"alumni_id"` // Terima sebagai string; untuk alumni diisi otomatis dari token
 	NamaPerusahaan      string  `json:"nama_perusahaan" validate:"required"`
 	PosisiJabatan       string  `json:"posisi_jabatan" validate:"required"`
 	BidangIndustri      string  `json:"bidang_industri" validate:"required"`
@@ -35,6 +102,7 @@ type CreatePekerjaanRequest struct {
 	TanggalMulaiKerja   string  `json:"tanggal_mulai_kerja" validate:"required"`
 	TanggalSelesaiKerja *string `json:"tanggal_selesai_kerja"`
 	StatusPekerjaan     string  `json:"status_pekerjaan" validate:"required"`
+	JenisPekerjaan      string  `json:"jenis_pekerjaan"` // Kosong = full_time
 	DeskripsiPekerjaan  *string `json:"deskripsi_pekerjaan"`
 }
 
@@ -47,9 +115,77 @@ type UpdatePekerjaanRequest struct {
 	TanggalMulaiKerja   string  `json:"tanggal_mulai_kerja" validate:"required"`
 	TanggalSelesaiKerja *string `json:"tanggal_selesai_kerja"`
 	StatusPekerjaan     string  `json:"status_pekerjaan" validate:"required"`
+	JenisPekerjaan      string  `json:"jenis_pekerjaan"` // Kosong = full_time
 	DeskripsiPekerjaan  *string `json:"deskripsi_pekerjaan"`
 }
 
 type SoftDeletePekerjaanRequest struct {
 	Reason string `json:"reason,omitempty"` // Optional reason for deletion
 }
+
+// MaxDeleteReasonLength membatasi panjang alasan penghapusan
+const MaxDeleteReasonLength = 500
+
+// RestoreEvent mencatat satu kali pekerjaan dikembalikan dari trash beserta
+// informasi penghapusan sebelumnya
+type RestoreEvent struct {
+	RestoredAt   time.Time           `bson:"restored_at" json:"restored_at"`
+	RestoredBy   *primitive.ObjectID `bson:"restored_by,omitempty" json:"restored_by,omitempty"`
+	DeletedAt    *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedBy    *primitive.ObjectID `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+	DeleteReason string              `bson:"delete_reason,omitempty" json:"delete_reason,omitempty"`
+	WithAlumni   bool                `bson:"with_alumni,omitempty" json:"with_alumni,omitempty"` // Direstore bersama alumninya
+}
+
+// PekerjaanTrashItem adalah pekerjaan di trash beserta nama penghapus dan
+// identitas alumni pemiliknya
+type PekerjaanTrashItem struct {
+	PekerjaanAlumni   `bson:",inline"`
+	DeletedByUsername string `bson:"deleted_by_username,omitempty" json:"deleted_by_username,omitempty"`
+	AlumniNama        string `bson:"alumni_nama,omitempty" json:"alumni_nama,omitempty"`
+	AlumniNIM         string `bson:"alumni_nim,omitempty" json:"alumni_nim,omitempty"`
+}
+
+// SetLegalHoldRequest memasang (hold=true) atau melepas legal hold pekerjaan
+type SetLegalHoldRequest struct {
+	Hold   bool   `json:"hold"`
+	Reason string `json:"reason"` // Wajib saat memasang hold
+}
+
+// TrashPurgePreview adalah daftar pekerjaan di trash yang akan dihapus
+// permanen pada auto-purge berikutnya
+type TrashPurgePreview struct {
+	Enabled   bool              `json:"enabled"`
+	Retention string            `json:"retention"`             // Masa simpan di trash, misal "720h0m0s"
+	Cutoff    time.Time         `json:"cutoff"`                // Item dengan deleted_at sebelum waktu ini akan dihapus
+	NextRunAt *time.Time        `json:"next_run_at,omitempty"` // Kosong jika scheduler tidak berjalan
+	Total     int               `json:"total"`
+	OnHold    int               `json:"on_hold"` // Sudah melewati masa simpan tetapi dikecualikan legal hold
+	Data      []PekerjaanAlumni `json:"data"`
+}
+
+// BulkTrashRequest memilih pekerjaan di trash untuk operasi massal: daftar
+// IDs, atau jika kosong, semua item trash yang cocok dengan Search (filter
+// yang sama dengan GET /pekerjaan/trash)
+type BulkTrashRequest struct {
+	IDs      []string       `json:"ids"`
+	Search   *string        `json:"search"`
+	Versions map[string]int `json:"versions,omitempty"` // id -> versi yang diharapkan (pengganti If-Match); wajib per id jika REQUIRE_IF_MATCH aktif
+}
+
+// BulkTrashResult adalah hasil operasi massal untuk satu ID
+type BulkTrashResult struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Status  int    `json:"status"` // Status HTTP yang setara dengan operasi per item
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkTrashReport merangkum operasi massal trash
+type BulkTrashReport struct {
+	Requested int               `json:"requested"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	HasMore   bool              `json:"has_more"` // Masih ada item yang cocok dengan search di luar batas satu request
+	Results   []BulkTrashResult `json:"results"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodePekerjaanOverlaps(t *testing.T) {
+	date := func(s string) *Date {
+		parsed, err := time.Parse(dateLayout, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := NewDate(parsed)
+		return &d
+	}
+	periode := func(mulai, selesai string) PeriodePekerjaan {
+		p := PeriodePekerjaan{Mulai: *date(mulai)}
+		if selesai != "" {
+			p.Selesai = date(selesai)
+		}
+		return p
+	}
+	tests := []struct {
+		name        string
+		a, b        PeriodePekerjaan
+		wantOverlap bool
+	}{
+		{"berurutan", periode("2020-01-01", "2020-12-31"), periode("2021-01-01", "2021-12-31"), false},
+		{"pindah di hari yang sama", periode("2020-01-01", "2021-01-01"), periode("2021-01-01", ""), false},
+		{"bertumpuk sebagian", periode("2020-01-01", "2021-06-30"), periode("2021-01-01", "2021-12-31"), true},
+		{"di dalam periode lain", periode("2020-01-01", "2022-12-31"), periode("2021-01-01", "2021-12-31"), true},
+		{"keduanya masih berjalan", periode("2020-01-01", ""), periode("2021-01-01", ""), true},
+		{"masih berjalan setelah yang lain selesai", periode("2022-01-01", ""), periode("2020-01-01", "2021-12-31"), false},
+		{"masih berjalan sebelum yang lain selesai", periode("2021-01-01", ""), periode("2020-01-01", "2021-12-31"), true},
+		{"tanggal yang sama", periode("2021-01-01", "2021-01-01"), periode("2021-01-01", "2021-01-01"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Overlaps(tt.b); got != tt.wantOverlap {
+				t.Errorf("a.Overlaps(b) = %v, want %v", got, tt.wantOverlap)
+			}
+			if got := tt.b.Overlaps(tt.a); got != tt.wantOverlap {
+				t.Errorf("b.Overlaps(a) = %v, want %v", got, tt.wantOverlap)
+			}
+		})
+	}
+}
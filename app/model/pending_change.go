@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Jenis data yang perubahannya dimoderasi
+const (
+	ChangeEntityAlumni    = "alumni"
+	ChangeEntityPekerjaan = "pekerjaan"
+)
+
+const (
+	ChangeActionCreate = "create" // Pekerjaan baru yang belum tampil sebelum disetujui
+	ChangeActionUpdate = "update" // Diff yang baru diterapkan setelah disetujui
+)
+
+// ChangeStatusApproving menandai perubahan yang sedang diterapkan admin.
+// Status ini hanya sementara; bila penerapan gagal perubahan kembali pending.
+const ChangeStatusApproving = "approving"
+
+// FieldChange adalah satu field yang diubah. Nama field mengikuti JSON request
+// update; Old adalah nilai saat perubahan diajukan.
+type FieldChange struct {
+	Field string      `bson:"field" json:"field"`
+	Old   interface{} `bson:"old" json:"old"`
+	New   interface{} `bson:"new" json:"new"`
+}
+
+// PendingChange adalah perubahan data yang diajukan alumni dan menunggu
+// verifikasi admin. Status memakai konstanta Approval*.
+type PendingChange struct {
+	ID                  primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	EntityType          string              `bson:"entity_type" json:"entity_type"`
+	EntityID            primitive.ObjectID  `bson:"entity_id" json:"entity_id"`
+	Action              string              `bson:"action" json:"action"`
+	AlumniID            primitive.ObjectID  `bson:"alumni_id" json:"alumni_id"`
+	Jurusan             string              `bson:"jurusan" json:"jurusan"` // Untuk membatasi antrian sesuai scope admin
+	Changes             []FieldChange       `bson:"changes" json:"changes"`
+	Status              string              `bson:"status" json:"status"`
+	SubmittedBy         primitive.ObjectID  `bson:"submitted_by" json:"submitted_by"`
+	SubmittedByUsername string              `bson:"submitted_by_username" json:"submitted_by_username"`
+	ReviewedBy          *primitive.ObjectID `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	ReviewedAt          *time.Time          `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+	RejectReason        string              `bson:"reject_reason,omitempty" json:"reject_reason,omitempty"`
+	CreatedAt           time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt           time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// PendingChangeFilter dipakai untuk daftar antrian; field kosong berarti tanpa filter
+type PendingChangeFilter struct {
+	Status      string
+	EntityType  string
+	SubmittedBy *primitive.ObjectID
+	Scope       *DataScope
+}
+
+// FieldDiff membandingkan nilai saat diajukan, nilai usulan dan nilai saat ini.
+// Conflict berarti data sudah berubah lagi sejak perubahan diajukan.
+type FieldDiff struct {
+	Field    string      `json:"field"`
+	Old      interface{} `json:"old"`
+	New      interface{} `json:"new"`
+	Current  interface{} `json:"current"`
+	Conflict bool        `json:"conflict"`
+}
+
+type PendingChangeDiff struct {
+	Change  *PendingChange `json:"change"`
+	Current interface{}    `json:"current"` // Data alumni/pekerjaan saat ini
+	Fields  []FieldDiff    `json:"fields"`
+}
+
+type RejectChangeRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
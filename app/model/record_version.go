@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// VersionActionBaseline menandai snapshot data lama yang belum punya riwayat,
+// dibuat otomatis saat data tersebut pertama kali diubah
+const VersionActionBaseline = "baseline"
+
+// RecordVersion adalah snapshot lengkap alumni/pekerjaan setelah satu perubahan.
+// Version sama dengan field version dokumen pada snapshot tersebut, yaitu
+// nilai yang dipakai ETag/If-Match.
+type RecordVersion struct {
+	ID                primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	EntityType        string              `bson:"entity_type" json:"entity_type"`
+	EntityID          string              `bson:"entity_id" json:"entity_id"`
+	Version           int                 `bson:"version" json:"version"`
+	Action            string              `bson:"action" json:"action"` // Konstanta AuditAction*
+	Snapshot          bson.M              `bson:"snapshot" json:"snapshot"`
+	ChangedBy         *primitive.ObjectID `bson:"changed_by,omitempty" json:"changed_by,omitempty"`
+	ChangedByUsername string              `bson:"changed_by_username,omitempty" json:"changed_by_username,omitempty"`
+	RequestID         string              `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	CreatedAt         time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// VersionDiff adalah perbedaan field antara dua versi (Old = versi From)
+type VersionDiff struct {
+	EntityType string        `json:"entity_type"`
+	EntityID   string        `json:"entity_id"`
+	From       int           `json:"from"`
+	To         int           `json:"to"`
+	Changes    []FieldChange `json:"changes"`
+}
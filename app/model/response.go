@@ -22,3 +22,21 @@ type PekerjaanResponse struct {
 	Data []PekerjaanAlumni `json:"data"`
 	Meta MetaInfo          `json:"meta"`
 }
+
+// PekerjaanTrashResponse represents the response for the pekerjaan trash listing with pagination
+type PekerjaanTrashResponse struct {
+	Data []PekerjaanTrashItem `json:"data"`
+	Meta MetaInfo             `json:"meta"`
+}
+
+// UserResponse represents the response for user endpoints with pagination
+type UserResponse struct {
+	Data []User   `json:"data"`
+	Meta MetaInfo `json:"meta"`
+}
+
+// LoginAttemptResponse represents the response for login audit endpoints with pagination
+type LoginAttemptResponse struct {
+	Data []LoginAttempt `json:"data"`
+	Meta MetaInfo       `json:"meta"`
+}
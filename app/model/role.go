@@ -0,0 +1,104 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Role bawaan yang dibuat otomatis saat startup
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// Daftar permission. Format "<resource>:<aksi>"; akhiran "_any" berarti boleh
+// bertindak atas data milik alumni lain, bukan hanya data sendiri.
+const (
+	PermAlumniWrite         = "alumni:write"
+	PermAlumniDelete        = "alumni:delete"      // Memindahkan alumni ke trash dan memulihkannya
+	PermAlumniHardDelete    = "alumni:hard_delete" // Menghapus permanen alumni beserta pekerjaan dan file-nya
+	PermPekerjaanReadAny    = "pekerjaan:read_any"
+	PermPekerjaanWrite      = "pekerjaan:write"       // Membuat/mengubah pekerjaan milik alumni mana pun
+	PermPekerjaanManageAny  = "pekerjaan:manage_any"  // Soft delete, restore dan trash milik siapa saja
+	PermPekerjaanHardDelete = "pekerjaan:hard_delete" // Juga melihat preview auto-purge trash
+	PermPekerjaanLegalHold  = "pekerjaan:legal_hold"  // Mengecualikan pekerjaan dari penghapusan permanen
+	PermFilesReadAny        = "files:read_any"
+	PermFilesWriteAny       = "files:write_any"
+	PermFilesDeleteAny      = "files:delete_any"
+	PermUsersManage         = "users:manage"
+	PermRolesManage         = "roles:manage"
+	PermAPIKeysManage       = "api_keys:manage"
+	PermModerationReview    = "moderation:review" // Menyetujui/menolak perubahan data dari alumni
+	PermAuditRead           = "audit:read"
+)
+
+// AllPermissions dipakai untuk validasi input dan selalu dimiliki role admin
+var AllPermissions = []string{
+	PermAlumniWrite,
+	PermAlumniDelete,
+	PermAlumniHardDelete,
+	PermPekerjaanReadAny,
+	PermPekerjaanWrite,
+	PermPekerjaanManageAny,
+	PermPekerjaanHardDelete,
+	PermPekerjaanLegalHold,
+	PermFilesReadAny,
+	PermFilesWriteAny,
+	PermFilesDeleteAny,
+	PermUsersManage,
+	PermRolesManage,
+	PermAPIKeysManage,
+	PermModerationReview,
+	PermAuditRead,
+}
+
+type Role struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+	Permissions []string           `bson:"permissions" json:"permissions"`
+	IsSystem    bool               `bson:"is_system" json:"is_system"` // Role bawaan tidak bisa dihapus
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// PermissionSet adalah permission milik user yang sedang login
+type PermissionSet map[string]bool
+
+func NewPermissionSet(permissions []string) PermissionSet {
+	set := PermissionSet{}
+	for _, p := range permissions {
+		set[p] = true
+	}
+	return set
+}
+
+func (p PermissionSet) Has(permission string) bool {
+	return p[permission]
+}
+
+// Actor adalah identitas, permission dan scope user yang sedang melakukan request.
+// Disiapkan oleh middleware.LoadActor.
+type Actor struct {
+	UserID      string
+	Username    string
+	Role        string
+	Permissions PermissionSet
+	Scope       *DataScope
+}
+
+func (a *Actor) Can(permission string) bool {
+	return a.Permissions.Has(permission)
+}
@@ -0,0 +1,46 @@
+package model
+
+import "strings"
+
+// UserScope membatasi alumni yang boleh dikelola user (misalnya admin fakultas).
+// nil atau kosong berarti tanpa batasan.
+type UserScope struct {
+	Jurusan  []string `bson:"jurusan,omitempty" json:"jurusan,omitempty"`
+	Fakultas []string `bson:"fakultas,omitempty" json:"fakultas,omitempty"` // Dipetakan ke jurusan lewat config FACULTIES
+}
+
+func (s *UserScope) IsEmpty() bool {
+	return s == nil || (len(s.Jurusan) == 0 && len(s.Fakultas) == 0)
+}
+
+// Resolve menerjemahkan scope menjadi daftar jurusan. Fakultas yang tidak
+// dikenal tidak menambah jurusan apa pun (gagal tertutup).
+func (s *UserScope) Resolve(faculties map[string][]string) *DataScope {
+	if s.IsEmpty() {
+		return nil
+	}
+	scope := &DataScope{Jurusan: append([]string{}, s.Jurusan...)}
+	for _, f := range s.Fakultas {
+		scope.Jurusan = append(scope.Jurusan, faculties[strings.ToLower(f)]...)
+	}
+	return scope
+}
+
+// DataScope adalah daftar jurusan yang boleh diakses pada satu request.
+// nil berarti tanpa batasan; Jurusan kosong berarti tidak ada data yang boleh diakses.
+type DataScope struct {
+	Jurusan []string
+}
+
+// Allows membandingkan jurusan tanpa memperhatikan huruf besar/kecil
+func (s *DataScope) Allows(jurusan string) bool {
+	if s == nil {
+		return true
+	}
+	for _, j := range s.Jurusan {
+		if strings.EqualFold(j, jurusan) {
+			return true
+		}
+	}
+	return false
+}
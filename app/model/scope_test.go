@@ -0,0 +1,45 @@
+package model
+
+import "testing"
+
+func TestUserScopeResolve(t *testing.T) {
+	faculties := map[string][]string{
+		"teknik": {"Teknik Informatika", "Teknik Sipil"},
+	}
+
+	if scope := (*UserScope)(nil).Resolve(faculties); scope != nil {
+		t.Fatalf("scope nil harus tanpa batasan, dapat %+v", scope)
+	}
+	if scope := (&UserScope{}).Resolve(faculties); scope != nil {
+		t.Fatalf("scope kosong harus tanpa batasan, dapat %+v", scope)
+	}
+
+	scope := (&UserScope{Jurusan: []string{"Akuntansi"}, Fakultas: []string{"TEKNIK"}}).Resolve(faculties)
+	for _, jurusan := range []string{"Akuntansi", "Teknik Informatika", "teknik sipil"} {
+		if !scope.Allows(jurusan) {
+			t.Errorf("jurusan %q harus diizinkan oleh scope %v", jurusan, scope.Jurusan)
+		}
+	}
+	if scope.Allows("Kedokteran") {
+		t.Errorf("jurusan di luar scope tidak boleh diizinkan")
+	}
+}
+
+func TestUserScopeResolveUnknownFacultyFailsClosed(t *testing.T) {
+	scope := (&UserScope{Fakultas: []string{"Fakultas Hilang"}}).Resolve(map[string][]string{})
+	if scope == nil {
+		t.Fatal("fakultas tidak dikenal tidak boleh menghasilkan akses tanpa batasan")
+	}
+	if len(scope.Jurusan) != 0 || scope.Allows("Teknik Informatika") || scope.Allows("") {
+		t.Fatalf("fakultas tidak dikenal harus menolak semua jurusan, dapat %v", scope.Jurusan)
+	}
+}
+
+func TestUserScopeResolveDoesNotShareJurusanSlice(t *testing.T) {
+	user := &UserScope{Jurusan: make([]string, 1, 4), Fakultas: []string{"Ekonomi"}}
+	user.Jurusan[0] = "Akuntansi"
+	scope := user.Resolve(map[string][]string{"ekonomi": {"Manajemen"}})
+	if len(user.Jurusan) != 1 || user.Jurusan[:2][1] != "" || !scope.Allows("Manajemen") {
+		t.Fatalf("resolve tidak boleh mengubah scope user: user=%v scope=%v", user.Jurusan, scope.Jurusan)
+	}
+}
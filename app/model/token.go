@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshToken disimpan di koleksi "refresh_tokens". Token mentah tidak pernah
+// disimpan, hanya hash SHA-256-nya. Semua token hasil rotasi dari satu login
+// berbagi FamilyID yang sama.
+type RefreshToken struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID          primitive.ObjectID `bson:"user_id" json:"user_id"`
+	FamilyID        string             `bson:"family_id" json:"family_id"`
+	TokenHash       string             `bson:"token_hash" json:"-"`
+	AccessJTI       string             `bson:"access_jti" json:"-"`        // jti access token yang diterbitkan bersamaan
+	AccessExpiresAt time.Time          `bson:"access_expires_at" json:"-"` // Dipakai saat mencabut access token
+	ExpiresAt       time.Time          `bson:"expires_at" json:"expires_at"`
+	UsedAt          *time.Time         `bson:"used_at,omitempty" json:"used_at,omitempty"` // Terisi setelah dirotasi
+	RevokedAt       *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	RevokeReason    string             `bson:"revoke_reason,omitempty" json:"revoke_reason,omitempty"`
+	IP              string             `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent       string             `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// RevokedToken adalah daftar jti access token yang sudah dicabut sebelum expired.
+// Dokumen dihapus otomatis oleh TTL index setelah ExpiresAt.
+type RevokedToken struct {
+	JTI       string             `bson:"jti" json:"jti"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Reason    string             `bson:"reason" json:"reason"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	RevokedAt time.Time          `bson:"revoked_at" json:"revoked_at"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"` // Opsional, untuk mengakhiri sesi refresh juga
+}
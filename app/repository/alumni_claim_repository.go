@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"alumni-crud-api/app/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Batas klaim dihitung dalam satu masa berlaku kode (ALUMNI_CLAIM_TTL).
+// Klaim baru mewarisi jumlah salah kode klaim sebelumnya, sehingga meminta
+// kode baru tidak mengembalikan jatah percobaan.
+const (
+	MaxClaimAttempts          = 5  // Salah kode per user untuk satu alumni
+	MaxClaimRequestsPerUser   = 3  // Permintaan kode per user untuk satu alumni
+	MaxClaimRequestsPerAlumni = 10 // Permintaan kode untuk satu alumni dari semua user
+)
+
+type AlumniClaimRepository interface {
+	Create(claim *model.AlumniClaim) error
+	Consume(alumniID, userID primitive.ObjectID, codeHash string) (*model.AlumniClaim, error)
+	RegisterFailure(alumniID, userID primitive.ObjectID) error
+	InvalidateForAlumni(alumniID primitive.ObjectID) error
+	InvalidateForUser(alumniID, userID primitive.ObjectID) error
+	ListSince(alumniID primitive.ObjectID, since time.Time) ([]model.AlumniClaim, error)
+	EnsureIndexes() error
+}
+
+type alumniClaimRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAlumniClaimRepository(db *mongo.Database) AlumniClaimRepository {
+	return &alumniClaimRepository{
+		collection: db.Collection("alumni_claims"),
+	}
+}
+
+func (r *alumniClaimRepository) Create(claim *model.AlumniClaim) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	claim.CreatedAt = time.Now()
+	result, err := r.collection.InsertOne(ctx, claim)
+	if err != nil {
+		return err
+	}
+	claim.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// activeFilter mencocokkan klaim yang belum dipakai, belum kedaluwarsa dan
+// belum melewati batas percobaan
+func activeClaimFilter(alumniID, userID primitive.ObjectID) bson.M {
+	return bson.M{
+		"alumni_id":  alumniID,
+		"user_id":    userID,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+		"attempts":   bson.M{"$lt": MaxClaimAttempts},
+	}
+}
+
+// Consume menandai klaim sebagai terpakai secara atomik jika kodenya cocok
+func (r *alumniClaimRepository) Consume(alumniID, userID primitive.ObjectID, codeHash string) (*model.AlumniClaim, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := activeClaimFilter(alumniID, userID)
+	filter["code_hash"] = codeHash
+	update := bson.M{"$set": bson.M{"used_at": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var claim model.AlumniClaim
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&claim); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("kode verifikasi salah atau sudah kedaluwarsa")
+		}
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// RegisterFailure menambah penghitung salah kode pada klaim yang masih aktif
+func (r *alumniClaimRepository) RegisterFailure(alumniID, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateMany(ctx, activeClaimFilter(alumniID, userID), bson.M{"$inc": bson.M{"attempts": 1}})
+	return err
+}
+
+// InvalidateForAlumni membatalkan semua klaim yang belum terpakai untuk alumni ini
+func (r *alumniClaimRepository) InvalidateForAlumni(alumniID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"alumni_id": alumniID, "used_at": bson.M{"$exists": false}}
+	_, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"used_at": time.Now()}})
+	return err
+}
+
+// InvalidateForUser membatalkan klaim yang belum terpakai milik satu user saja,
+// agar permintaan ulang tidak membatalkan kode user lain
+func (r *alumniClaimRepository) InvalidateForUser(alumniID, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"alumni_id": alumniID, "user_id": userID, "used_at": bson.M{"$exists": false}}
+	_, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"used_at": time.Now()}})
+	return err
+}
+
+// ListSince mengembalikan semua klaim untuk alumni ini (termasuk yang sudah
+// dibatalkan) yang dibuat sejak waktu tertentu, untuk pembatasan permintaan
+func (r *alumniClaimRepository) ListSince(alumniID primitive.ObjectID, since time.Time) ([]model.AlumniClaim, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"alumni_id": alumniID, "created_at": bson.M{"$gte": since}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var claims []model.AlumniClaim
+	if err := cursor.All(ctx, &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (r *alumniClaimRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "alumni_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("idx_alumni_user"),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("ttl_expires_at"),
+		},
+	})
+	return err
+}
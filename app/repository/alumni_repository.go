@@ -4,6 +4,7 @@ import (
 	"alumni-crud-api/app/model"
 	"context"
 	"fmt"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,16 +14,38 @@ import (
 )
 
 type AlumniRepository interface {
-	GetAll() ([]model.Alumni, error)
+	GetAll(scope *model.DataScope) ([]model.Alumni, error)
 	GetByID(id string) (*model.Alumni, error)
 	GetByUserID(userID string) (*model.Alumni, error) // Penting untuk otorisasi
+	LinkUserByEmailOrNIM(userID primitive.ObjectID, email, nim string) (*model.Alumni, error)
+	GetByNIM(nim string) (*model.Alumni, error)
+	SetUserID(id string, userID, expected primitive.ObjectID, version int) (*model.Alumni, error) // NilObjectID melepas link
 	Create(alumni *model.CreateAlumniRequest, userID primitive.ObjectID) (*model.Alumni, error)
-	Update(id string, alumni *model.UpdateAlumniRequest) (*model.Alumni, error)
-	Delete(id string) error
-	GetAllWithPagination(search, sortBy, order string, limit, offset int) ([]model.Alumni, error)
-	CountWithSearch(search string) (int, error)
+	Update(id string, alumni *model.UpdateAlumniRequest, version int) (*model.Alumni, error)
+	Patch(id string, fields bson.M, version int) (*model.Alumni, error)
+	GetAllWithPagination(search, sortBy, order string, limit, offset int, scope *model.DataScope) ([]model.Alumni, error)
+	CountWithSearch(search string, scope *model.DataScope) (int, error)
+	GetByIDWithDeleted(id string) (*model.Alumni, error) // Untuk trash, restore dan hard delete
+	SoftDelete(id string, deleterID primitive.ObjectID, version int) (*model.Alumni, error)
+	Restore(id string, version int) (*model.Alumni, error)
+	HardDelete(id string) error // Hanya alumni yang sudah di trash
+	ListTrash(search string, limit, offset int, scope *model.DataScope) ([]model.Alumni, error)
+	CountTrash(search string, scope *model.DataScope) (int, error)
+	EnsureIndexes() error
+	FindDuplicates() ([]model.DuplicateGroup, error)
 }
 
+// alumniDuplicateMessages memetakan unique index alumni ke pesan error
+var alumniDuplicateMessages = map[string]string{
+	"uniq_nim":     "NIM sudah digunakan alumni lain",
+	"uniq_email":   "email sudah digunakan alumni lain",
+	"uniq_user_id": "user sudah terhubung ke data alumni lain",
+}
+
+// emailCollation membuat perbandingan email (dan username user) tidak peka
+// huruf besar/kecil
+var emailCollation = &options.Collation{Locale: "en", Strength: 2}
+
 type alumniRepository struct {
 	collection *mongo.Collection
 }
@@ -33,13 +56,13 @@ func NewAlumniRepository(db *mongo.Database) AlumniRepository {
 	}
 }
 
-func (r *alumniRepository) GetAll() ([]model.Alumni, error) {
+func (r *alumniRepository) GetAll(scope *model.DataScope) ([]model.Alumni, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	var alumni []model.Alumni
-	opts := options.Find().SetSort(bson.D{{"created_at", -1}})
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, activeOnly(jurusanScopeFilter(scope)), opts)
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +84,7 @@ func (r *alumniRepository) GetByID(id string) (*model.Alumni, error) {
 	}
 
 	var a model.Alumni
-	if err := r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&a); err != nil {
+	if err := r.collection.FindOne(ctx, activeOnly(bson.M{"_id": objID})).Decode(&a); err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, fmt.Errorf("alumni tidak ditemukan")
 		}
@@ -80,7 +103,55 @@ func (r *alumniRepository) GetByUserID(userID string) (*model.Alumni, error) {
 	}
 
 	var a model.Alumni
-	if err := r.collection.FindOne(ctx, bson.M{"user_id": userObjID}).Decode(&a); err != nil {
+	if err := r.collection.FindOne(ctx, activeOnly(bson.M{"user_id": userObjID})).Decode(&a); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("alumni tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+// LinkUserByEmailOrNIM menghubungkan user ke alumni dengan email atau NIM yang
+// cocok, hanya jika alumni tersebut belum terhubung ke user mana pun
+func (r *alumniRepository) LinkUserByEmailOrNIM(userID primitive.ObjectID, email, nim string) (*model.Alumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var match []bson.M
+	if nim != "" {
+		match = append(match, bson.M{"nim": nim})
+	}
+	if email != "" {
+		match = append(match, bson.M{"email": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(email) + "$", Options: "i"}})
+	}
+	if len(match) == 0 {
+		return nil, fmt.Errorf("alumni tidak ditemukan")
+	}
+
+	filter := activeOnly(bson.M{"$or": match, "user_id": bson.M{"$exists": false}})
+	update := bson.M{"$set": bson.M{"user_id": userID, "updated_at": time.Now()}, "$inc": bson.M{"version": 1}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var a model.Alumni
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&a); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("alumni tidak ditemukan")
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("%s", alumniDuplicateMessages["uniq_user_id"])
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *alumniRepository) GetByNIM(nim string) (*model.Alumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var a model.Alumni
+	if err := r.collection.FindOne(ctx, activeOnly(bson.M{"nim": nim})).Decode(&a); err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, fmt.Errorf("alumni tidak ditemukan")
 		}
@@ -89,13 +160,50 @@ func (r *alumniRepository) GetByUserID(userID string) (*model.Alumni, error) {
 	return &a, nil
 }
 
+// SetUserID menghubungkan alumni ke user, atau melepasnya jika userID kosong.
+// Hanya berhasil jika user_id masih sama dengan expected (kosong = belum
+// terhubung) dan versi dokumen masih version, sehingga dua admin yang
+// menghubungkan akun berbeda secara bersamaan tidak saling menimpa.
+func (r *alumniRepository) SetUserID(id string, userID, expected primitive.ObjectID, version int) (*model.Alumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	update := bson.M{"$set": bson.M{"user_id": userID, "updated_at": time.Now()}, "$inc": bson.M{"version": 1}}
+	if userID.IsZero() {
+		update = bson.M{"$set": bson.M{"updated_at": time.Now()}, "$unset": bson.M{"user_id": ""}, "$inc": bson.M{"version": 1}}
+	}
+
+	filter := activeOnly(bson.M{"_id": objID, "version": versionFilter(version), "user_id": expected})
+	if expected.IsZero() {
+		filter["user_id"] = bson.M{"$exists": false}
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var a model.Alumni
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&a); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, versionConflictOr(ctx, r.collection, activeOnly(bson.M{"_id": objID}), "alumni tidak ditemukan")
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("%s", alumniDuplicateMessages["uniq_user_id"])
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
 func (r *alumniRepository) Create(req *model.CreateAlumniRequest, userID primitive.ObjectID) (*model.Alumni, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	now := time.Now()
 	newAlumni := model.Alumni{
-		UserID:     userID, // Diambil dari service
+		UserID:     userID, // Kosong kecuali admin langsung menghubungkan akun alumni
 		NIM:        req.NIM,
 		Nama:       req.Nama,
 		Jurusan:    req.Jurusan,
@@ -106,10 +214,14 @@ func (r *alumniRepository) Create(req *model.CreateAlumniRequest, userID primiti
 		Alamat:     req.Alamat,
 		CreatedAt:  now,
 		UpdatedAt:  now,
+		Version:    1,
 	}
 
 	result, err := r.collection.InsertOne(ctx, newAlumni)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, duplicateKeyError(err, alumniDuplicateMessages, "NIM atau email sudah digunakan alumni lain")
+		}
 		return nil, err
 	}
 
@@ -117,7 +229,9 @@ func (r *alumniRepository) Create(req *model.CreateAlumniRequest, userID primiti
 	return &newAlumni, nil
 }
 
-func (r *alumniRepository) Update(id string, req *model.UpdateAlumniRequest) (*model.Alumni, error) {
+// Update hanya berhasil jika versi dokumen masih sama dengan version
+// (optimistic concurrency), lalu menaikkan versinya
+func (r *alumniRepository) Update(id string, req *model.UpdateAlumniRequest, version int) (*model.Alumni, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -137,14 +251,19 @@ func (r *alumniRepository) Update(id string, req *model.UpdateAlumniRequest) (*m
 			"alamat":      req.Alamat,
 			"updated_at":  time.Now(),
 		},
+		"$inc": bson.M{"version": 1},
 	}
 
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	filter := activeOnly(bson.M{"_id": objID, "version": versionFilter(version)})
 	var updatedAlumni model.Alumni
-	err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": objID}, update, opts).Decode(&updatedAlumni)
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updatedAlumni)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("alumni tidak ditemukan")
+			return nil, versionConflictOr(ctx, r.collection, activeOnly(bson.M{"_id": objID}), "alumni tidak ditemukan")
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, duplicateKeyError(err, alumniDuplicateMessages, "NIM atau email sudah digunakan alumni lain")
 		}
 		return nil, err
 	}
@@ -152,7 +271,113 @@ func (r *alumniRepository) Update(id string, req *model.UpdateAlumniRequest) (*m
 	return &updatedAlumni, nil
 }
 
-func (r *alumniRepository) Delete(id string) error {
+// Patch hanya menulis field yang diberikan (nil = $unset), dengan syarat versi
+// yang sama seperti Update. Nama field harus sudah divalidasi oleh service.
+func (r *alumniRepository) Patch(id string, fields bson.M, version int) (*model.Alumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	filter := activeOnly(bson.M{"_id": objID, "version": versionFilter(version)})
+	var a model.Alumni
+	if err := r.collection.FindOneAndUpdate(ctx, filter, patchUpdate(fields), opts).Decode(&a); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, versionConflictOr(ctx, r.collection, activeOnly(bson.M{"_id": objID}), "alumni tidak ditemukan")
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, duplicateKeyError(err, alumniDuplicateMessages, "NIM atau email sudah digunakan alumni lain")
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *alumniRepository) GetByIDWithDeleted(id string) (*model.Alumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	var a model.Alumni
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&a); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("alumni tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+// SoftDelete memindahkan alumni ke trash dengan syarat versi yang sama seperti
+// Update. NIM dan email tetap tercatat di unique index selama di trash.
+func (r *alumniRepository) SoftDelete(id string, deleterID primitive.ObjectID, version int) (*model.Alumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"is_deleted": true,
+			"deleted_at": now,
+			"deleted_by": deleterID,
+			"updated_at": now,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	filter := activeOnly(bson.M{"_id": objID, "version": versionFilter(version)})
+
+	var a model.Alumni
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&a); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, versionConflictOr(ctx, r.collection, activeOnly(bson.M{"_id": objID}), "alumni tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *alumniRepository) Restore(id string, version int) (*model.Alumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$unset": bson.M{"is_deleted": "", "deleted_at": "", "deleted_by": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	filter := bson.M{"_id": objID, "is_deleted": true, "version": versionFilter(version)}
+
+	var a model.Alumni
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&a); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, versionConflictOr(ctx, r.collection, bson.M{"_id": objID, "is_deleted": true}, "alumni tidak ditemukan di trash")
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *alumniRepository) HardDelete(id string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -161,38 +386,148 @@ func (r *alumniRepository) Delete(id string) error {
 		return fmt.Errorf("ID tidak valid: %v", err)
 	}
 
-	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objID})
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objID, "is_deleted": true})
 	if err != nil {
 		return err
 	}
-
 	if result.DeletedCount == 0 {
-		return fmt.Errorf("alumni tidak ditemukan")
+		return fmt.Errorf("alumni tidak ditemukan di trash")
 	}
-
 	return nil
 }
 
-func (r *alumniRepository) buildSearchFilter(search string) bson.M {
+func (r *alumniRepository) trashFilter(search string, scope *model.DataScope) bson.M {
+	filter := r.buildSearchFilter(search, scope)
+	filter["is_deleted"] = true
+	return filter
+}
+
+func (r *alumniRepository) ListTrash(search string, limit, offset int, scope *model.DataScope) ([]model.Alumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "deleted_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	var alumni []model.Alumni
+	cursor, err := r.collection.Find(ctx, r.trashFilter(search, scope), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &alumni); err != nil {
+		return nil, err
+	}
+	return alumni, nil
+}
+
+func (r *alumniRepository) CountTrash(search string, scope *model.DataScope) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, r.trashFilter(search, scope))
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// buildSearchFilter tidak memfilter status trash; pemanggil menambahkan
+// activeOnly atau is_deleted sesuai kebutuhan
+func (r *alumniRepository) buildSearchFilter(search string, scope *model.DataScope) bson.M {
+	filter := jurusanScopeFilter(scope)
 	if search == "" {
-		return bson.M{}
+		return filter
 	}
 	searchRegex := bson.M{"$regex": search, "$options": "i"}
-	return bson.M{
-		"$or": []bson.M{
-			{"nama": searchRegex},
-			{"nim": searchRegex},
-			{"jurusan": searchRegex},
-			{"email": searchRegex},
-		},
+	filter["$or"] = []bson.M{
+		{"nama": searchRegex},
+		{"nim": searchRegex},
+		{"jurusan": searchRegex},
+		{"email": searchRegex},
+	}
+	return filter
+}
+
+// activeOnly mengecualikan alumni di trash. Data lama tanpa field is_deleted
+// dianggap aktif.
+func activeOnly(filter bson.M) bson.M {
+	filter["is_deleted"] = bson.M{"$ne": true}
+	return filter
+}
+
+// versionFilter mencocokkan field version. Dokumen lama yang belum punya
+// field version dianggap versi 0.
+func versionFilter(version int) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return version
+}
+
+// withVersion menyalin filter dan menambahkan syarat versi, sehingga filter
+// aslinya tetap bisa dipakai versionConflictOr
+func withVersion(filter bson.M, version int) bson.M {
+	versioned := bson.M{"version": versionFilter(version)}
+	for k, v := range filter {
+		versioned[k] = v
+	}
+	return versioned
+}
+
+// patchUpdate menyusun $set/$unset untuk field yang berubah dan menaikkan versi
+func patchUpdate(fields bson.M) bson.M {
+	set := bson.M{"updated_at": time.Now()}
+	unset := bson.M{}
+	for field, value := range fields {
+		if value == nil {
+			unset[field] = ""
+		} else {
+			set[field] = value
+		}
+	}
+
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update
+}
+
+// versionConflictOr membedakan update bersyarat versi yang gagal karena data
+// sudah diubah orang lain dari data yang memang tidak ada
+func versionConflictOr(ctx context.Context, collection *mongo.Collection, filter bson.M, notFound string) error {
+	count, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("versi data tidak cocok")
+	}
+	return fmt.Errorf("%s", notFound)
+}
+
+// jurusanScopeFilter membatasi query alumni ke jurusan dalam scope
+// (tanpa memperhatikan huruf besar/kecil). Scope nil berarti tanpa filter.
+func jurusanScopeFilter(scope *model.DataScope) bson.M {
+	if scope == nil {
+		return bson.M{}
+	}
+	patterns := bson.A{}
+	for _, j := range scope.Jurusan {
+		patterns = append(patterns, primitive.Regex{Pattern: "^" + regexp.QuoteMeta(j) + "$", Options: "i"})
 	}
+	return bson.M{"jurusan": bson.M{"$in": patterns}}
 }
 
-func (r *alumniRepository) GetAllWithPagination(search, sortBy, order string, limit, offset int) ([]model.Alumni, error) {
+func (r *alumniRepository) GetAllWithPagination(search, sortBy, order string, limit, offset int, scope *model.DataScope) ([]model.Alumni, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	filter := r.buildSearchFilter(search)
+	filter := activeOnly(r.buildSearchFilter(search, scope))
 
 	validSortColumns := map[string]bool{"id": true, "nim": true, "nama": true, "jurusan": true, "angkatan": true, "tahun_lulus": true, "email": true, "created_at": true}
 	if !validSortColumns[sortBy] {
@@ -208,7 +543,7 @@ func (r *alumniRepository) GetAllWithPagination(search, sortBy, order string, li
 	}
 
 	opts := options.Find().
-		SetSort(bson.D{{sortBy, sortOrder}}).
+		SetSort(bson.D{{Key: sortBy, Value: sortOrder}}).
 		SetLimit(int64(limit)).
 		SetSkip(int64(offset))
 
@@ -225,14 +560,60 @@ func (r *alumniRepository) GetAllWithPagination(search, sortBy, order string, li
 	return alumni, nil
 }
 
-func (r *alumniRepository) CountWithSearch(search string) (int, error) {
+func (r *alumniRepository) CountWithSearch(search string, scope *model.DataScope) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	filter := r.buildSearchFilter(search)
+	filter := activeOnly(r.buildSearchFilter(search, scope))
 	count, err := r.collection.CountDocuments(ctx, filter)
 	if err != nil {
 		return 0, err
 	}
 	return int(count), nil
 }
+
+// EnsureIndexes membuat unique index NIM dan email (tanpa membedakan huruf
+// besar/kecil) serta unique index user_id untuk /alumni/me, agar satu user
+// hanya terhubung ke satu alumni. Index user_id bersifat partial karena
+// sebagian besar alumni belum terhubung. Data duplikat harus dibereskan lebih
+// dulu, lihat FindDuplicates.
+func (r *alumniRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "nim", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_nim"),
+		},
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_email").SetCollation(emailCollation),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"user_id": bson.M{"$type": "objectId"}}).
+				SetName("uniq_user_id"),
+		},
+	})
+	return err
+}
+
+// FindDuplicates melaporkan NIM dan email yang dipakai lebih dari satu alumni
+func (r *alumniRepository) FindDuplicates() ([]model.DuplicateGroup, error) {
+	groups, err := findDuplicates(r.collection, "nim", false)
+	if err != nil {
+		return nil, err
+	}
+	emails, err := findDuplicates(r.collection, "email", true)
+	if err != nil {
+		return nil, err
+	}
+	users, err := findDuplicates(r.collection, "user_id", false)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(groups, emails...), users...), nil
+}
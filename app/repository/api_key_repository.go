@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"alumni-crud-api/app/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// lastUsedResolution membatasi frekuensi penulisan last_used_at
+// agar sync yang memanggil ribuan request tidak menulis setiap kali
+const lastUsedResolution = time.Minute
+
+type APIKeyRepository interface {
+	Create(key *model.APIKey) error
+	GetAll() ([]model.APIKey, error)
+	GetActiveByHash(keyHash string) (*model.APIKey, error)
+	Revoke(id string) (*model.APIKey, error) // Mengembalikan key setelah dicabut
+	TouchLastUsed(id primitive.ObjectID, ip string) error
+	EnsureIndexes() error
+}
+
+type apiKeyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAPIKeyRepository(db *mongo.Database) APIKeyRepository {
+	return &apiKeyRepository{
+		collection: db.Collection("api_keys"),
+	}
+}
+
+func (r *apiKeyRepository) Create(key *model.APIKey) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key.CreatedAt = time.Now()
+	result, err := r.collection.InsertOne(ctx, key)
+	if err != nil {
+		return err
+	}
+	key.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *apiKeyRepository) GetAll() ([]model.APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []model.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// GetActiveByHash hanya mengembalikan key yang belum dicabut dan belum kedaluwarsa
+func (r *apiKeyRepository) GetActiveByHash(keyHash string) (*model.APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"key_hash":   keyHash,
+		"revoked_at": bson.M{"$exists": false},
+		"$or": []bson.M{
+			{"expires_at": bson.M{"$exists": false}},
+			{"expires_at": bson.M{"$gt": time.Now()}},
+		},
+	}
+
+	var key model.APIKey
+	if err := r.collection.FindOne(ctx, filter).Decode(&key); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("API key tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) Revoke(id string) (*model.APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	filter := bson.M{"_id": objID, "revoked_at": bson.M{"$exists": false}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var key model.APIKey
+	err = r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}}, opts).Decode(&key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("API key tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// TouchLastUsed mencatat waktu dan IP pemakaian terakhir (paling sering sekali per menit)
+func (r *apiKeyRepository) TouchLastUsed(id primitive.ObjectID, ip string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"_id": id,
+		"$or": []bson.M{
+			{"last_used_at": bson.M{"$exists": false}},
+			{"last_used_at": bson.M{"$lt": now.Add(-lastUsedResolution)}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"last_used_at": now, "last_used_ip": ip}}
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *apiKeyRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key_hash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_key_hash"),
+		},
+		{
+			Keys:    bson.D{{Key: "prefix", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_prefix"),
+		},
+	})
+	return err
+}
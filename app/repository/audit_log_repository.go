@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"alumni-crud-api/app/model"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditLogRepository sengaja tidak menyediakan update/delete (append-only)
+type AuditLogRepository interface {
+	Insert(entry *model.AuditLog) error
+	List(filter model.AuditLogFilter, limit, offset int) ([]model.AuditLog, error)
+	Count(filter model.AuditLogFilter) (int, error)
+	Each(filter model.AuditLogFilter, limit int, fn func(*model.AuditLog) error) error
+	EnsureIndexes() error
+}
+
+type auditLogRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAuditLogRepository(db *mongo.Database) AuditLogRepository {
+	return &auditLogRepository{
+		collection: db.Collection("audit_log"),
+	}
+}
+
+func (r *auditLogRepository) Insert(entry *model.AuditLog) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+func (r *auditLogRepository) buildFilter(f model.AuditLogFilter) bson.M {
+	filter := bson.M{}
+	if f.ActorID != nil {
+		filter["actor_id"] = *f.ActorID
+	}
+	if f.ActorUsername != "" {
+		filter["actor_username"] = f.ActorUsername
+	}
+	if f.EntityType != "" {
+		filter["entity_type"] = f.EntityType
+	}
+	if f.EntityID != "" {
+		filter["entity_id"] = f.EntityID
+	}
+	if f.Action != "" {
+		filter["action"] = f.Action
+	}
+	if f.From != nil || f.To != nil {
+		createdAt := bson.M{}
+		if f.From != nil {
+			createdAt["$gte"] = *f.From
+		}
+		if f.To != nil {
+			createdAt["$lt"] = *f.To
+		}
+		filter["created_at"] = createdAt
+	}
+	return filter
+}
+
+func (r *auditLogRepository) List(f model.AuditLogFilter, limit, offset int) ([]model.AuditLog, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	var logs []model.AuditLog
+	cursor, err := r.collection.Find(ctx, r.buildFilter(f), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &logs); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+func (r *auditLogRepository) Count(f model.AuditLogFilter) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, r.buildFilter(f))
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// Each memanggil fn untuk setiap catatan (terbaru dulu) tanpa memuat semuanya
+// ke memori sekaligus; dipakai untuk export CSV
+func (r *auditLogRepository) Each(f model.AuditLogFilter, limit int, fn func(*model.AuditLog) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, r.buildFilter(f), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var entry model.AuditLog
+		if err := cursor.Decode(&entry); err != nil {
+			return err
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (r *auditLogRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}, Options: options.Index().SetName("idx_created_at")},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("idx_actor")},
+		{Keys: bson.D{{Key: "entity_type", Value: 1}, {Key: "entity_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("idx_entity")},
+	})
+	return err
+}
@@ -3,17 +3,41 @@ package repository
 import (
 	"alumni-crud-api/app/model"
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Definisikan Interface untuk Dependency Injection
 type AuthRepository interface {
 	GetUserByUsernameOrEmail(identifier string) (*model.User, string, error)
 	GetUserByID(id string) (*model.User, error)
+	GetPasswordHash(id string) (string, error)
+	UpdatePassword(id string, passwordHash string) error
+	SetPendingTOTPSecret(id string, secret string) error
+	EnableTOTP(id string, secret string, recoveryCodeHashes []string, step int64) error
+	DisableTOTP(id string) error
+	UseTOTPStep(id string, step int64) (bool, error)
+	ConsumeRecoveryCode(id string, codeHash string) (bool, error)
+	CreateUser(user *model.User) (*model.User, error)
+	UpdateUser(id string, req *model.UpdateUserRequest, passwordHash string) (*model.User, error)
+	DisableUser(id string) error
+	EnableUser(id string) error
+	DeleteUser(id string) error
+	GetAllUsersWithPagination(search, sortBy, order string, limit, offset int) ([]model.User, error)
+	CountUsersWithSearch(search string) (int, error)
+	CountUsersByRole(role string) (int, error)
+	GetUserByEmail(email string) (*model.User, error)
+	GetUserByOIDCSubject(subject string) (*model.User, error)
+	LinkOIDCSubject(id primitive.ObjectID, subject string) error
+	MarkEmailVerified(id primitive.ObjectID, email string) error
+	EnsureIndexes() error
+	FindDuplicates() ([]model.DuplicateGroup, error)
 }
 
 type authRepository struct {
@@ -31,15 +55,17 @@ func (r *authRepository) GetUserByUsernameOrEmail(identifier string) (*model.Use
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Mencari berdasarkan username atau email
+	// Mencari berdasarkan username atau email. Email selalu disimpan huruf
+	// kecil; collation sama dengan unique index sehingga Admin = admin.
 	filter := bson.M{
 		"$or": []bson.M{
 			{"username": identifier},
-			{"email": identifier},
+			{"email": strings.ToLower(identifier)},
 		},
 	}
 
-	err := r.collection.FindOne(ctx, filter).Decode(&user)
+	opts := options.FindOne().SetCollation(emailCollation)
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, "", err
@@ -71,3 +97,441 @@ func (r *authRepository) GetUserByID(id string) (*model.User, error) {
 
 	return &user, nil
 }
+
+func (r *authRepository) GetPasswordHash(id string) (string, error) {
+	var user model.User
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", err // ID tidak valid
+	}
+
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user)
+	if err != nil {
+		return "", err // Termasuk mongo.ErrNoDocuments
+	}
+	return user.PasswordHash, nil
+}
+
+func (r *authRepository) UpdatePassword(id string, passwordHash string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"password_hash":       passwordHash,
+			"password_changed_at": time.Now(),
+			"updated_at":          time.Now(),
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user tidak ditemukan")
+	}
+	return nil
+}
+
+func (r *authRepository) updateUserByID(id string, filter bson.M, update bson.M) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID tidak valid: %v", err)
+	}
+	filter["_id"] = objID
+
+	return r.collection.UpdateOne(ctx, filter, update)
+}
+
+func (r *authRepository) SetPendingTOTPSecret(id string, secret string) error {
+	update := bson.M{"$set": bson.M{"totp_pending_secret": secret, "updated_at": time.Now()}}
+	result, err := r.updateUserByID(id, bson.M{}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user tidak ditemukan")
+	}
+	return nil
+}
+
+func (r *authRepository) EnableTOTP(id string, secret string, recoveryCodeHashes []string, step int64) error {
+	update := bson.M{
+		"$set": bson.M{
+			"totp_enabled":   true,
+			"totp_secret":    secret,
+			"totp_last_step": step,
+			"recovery_codes": recoveryCodeHashes,
+			"updated_at":     time.Now(),
+		},
+		"$unset": bson.M{"totp_pending_secret": ""},
+	}
+	result, err := r.updateUserByID(id, bson.M{}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user tidak ditemukan")
+	}
+	return nil
+}
+
+func (r *authRepository) DisableTOTP(id string) error {
+	update := bson.M{
+		"$set": bson.M{"totp_enabled": false, "updated_at": time.Now()},
+		"$unset": bson.M{
+			"totp_secret":         "",
+			"totp_pending_secret": "",
+			"totp_last_step":      "",
+			"recovery_codes":      "",
+		},
+	}
+	result, err := r.updateUserByID(id, bson.M{}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user tidak ditemukan")
+	}
+	return nil
+}
+
+// UseTOTPStep mencatat step TOTP yang dipakai secara atomik. Mengembalikan
+// false jika step tersebut (atau yang lebih baru) sudah pernah dipakai.
+func (r *authRepository) UseTOTPStep(id string, step int64) (bool, error) {
+	filter := bson.M{
+		"$or": []bson.M{
+			{"totp_last_step": bson.M{"$exists": false}},
+			{"totp_last_step": bson.M{"$lt": step}},
+		},
+	}
+	result, err := r.updateUserByID(id, filter, bson.M{"$set": bson.M{"totp_last_step": step}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// ConsumeRecoveryCode menghapus hash kode pemulihan dari daftar (sekali pakai)
+func (r *authRepository) ConsumeRecoveryCode(id string, codeHash string) (bool, error) {
+	filter := bson.M{"recovery_codes": codeHash}
+	result, err := r.updateUserByID(id, filter, bson.M{"$pull": bson.M{"recovery_codes": codeHash}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *authRepository) CreateUser(user *model.User) (*model.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
+	result, err := r.collection.InsertOne(ctx, user)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("username atau email sudah digunakan")
+		}
+		return nil, err
+	}
+
+	user.ID = result.InsertedID.(primitive.ObjectID)
+	user.PasswordHash = ""
+	return user, nil
+}
+
+func (r *authRepository) UpdateUser(id string, req *model.UpdateUserRequest, passwordHash string) (*model.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	set := bson.M{
+		"username":   req.Username,
+		"email":      req.Email,
+		"role":       req.Role,
+		"updated_at": time.Now(),
+	}
+	if passwordHash != "" {
+		set["password_hash"] = passwordHash
+	}
+	update := bson.M{"$set": set}
+	if req.Scope.IsEmpty() {
+		update["$unset"] = bson.M{"scope": ""}
+	} else {
+		set["scope"] = req.Scope
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updatedUser model.User
+	err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": objID}, update, opts).Decode(&updatedUser)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("user tidak ditemukan")
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("username atau email sudah digunakan")
+		}
+		return nil, err
+	}
+
+	updatedUser.PasswordHash = ""
+	return &updatedUser, nil
+}
+
+func (r *authRepository) DisableUser(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"is_disabled": true,
+			"disabled_at": now,
+			"updated_at":  now,
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user tidak ditemukan")
+	}
+	return nil
+}
+
+func (r *authRepository) EnableUser(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"is_disabled": false, "updated_at": time.Now()},
+		"$unset": bson.M{"disabled_at": ""},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user tidak ditemukan")
+	}
+	return nil
+}
+
+func (r *authRepository) DeleteUser(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("user tidak ditemukan")
+	}
+	return nil
+}
+
+func (r *authRepository) buildUserSearchFilter(search string) bson.M {
+	if search == "" {
+		return bson.M{}
+	}
+	searchRegex := bson.M{"$regex": search, "$options": "i"}
+	return bson.M{
+		"$or": []bson.M{
+			{"username": searchRegex},
+			{"email": searchRegex},
+			{"role": searchRegex},
+		},
+	}
+}
+
+func (r *authRepository) GetAllUsersWithPagination(search, sortBy, order string, limit, offset int) ([]model.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := r.buildUserSearchFilter(search)
+
+	validSortColumns := map[string]bool{"id": true, "username": true, "email": true, "role": true, "created_at": true}
+	if !validSortColumns[sortBy] {
+		sortBy = "created_at"
+	}
+	if sortBy == "id" {
+		sortBy = "_id"
+	}
+
+	sortOrder := 1 // asc
+	if order == "desc" {
+		sortOrder = -1 // desc
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: sortBy, Value: sortOrder}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset)).
+		SetProjection(bson.M{"password_hash": 0})
+
+	users := []model.User{}
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *authRepository) CountUsersWithSearch(search string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, r.buildUserSearchFilter(search))
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+func (r *authRepository) CountUsersByRole(role string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"role": role})
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+func (r *authRepository) GetUserByEmail(email string) (*model.User, error) {
+	var user model.User
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := r.collection.FindOne(ctx, bson.M{"email": strings.ToLower(email)}).Decode(&user)
+	if err != nil {
+		return nil, err // Termasuk mongo.ErrNoDocuments
+	}
+	user.PasswordHash = ""
+	return &user, nil
+}
+
+func (r *authRepository) GetUserByOIDCSubject(subject string) (*model.User, error) {
+	var user model.User
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := r.collection.FindOne(ctx, bson.M{"oidc_subject": subject}).Decode(&user)
+	if err != nil {
+		return nil, err // Termasuk mongo.ErrNoDocuments
+	}
+	user.PasswordHash = ""
+	return &user, nil
+}
+
+// LinkOIDCSubject menghubungkan akun dengan subject IdP. Akun yang sudah
+// terhubung ke subject lain tidak diubah.
+func (r *authRepository) LinkOIDCSubject(id primitive.ObjectID, subject string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "oidc_subject": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"oidc_subject": subject, "updated_at": time.Now()}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("akun SSO sudah terhubung ke user lain")
+		}
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user sudah terhubung ke akun SSO lain")
+	}
+	return nil
+}
+
+// MarkEmailVerified mencatat bahwa user membuktikan kepemilikan email. Tidak
+// berpengaruh jika email user sudah diganti sejak link dikirim.
+func (r *authRepository) MarkEmailVerified(id primitive.ObjectID, email string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	email = strings.ToLower(email)
+	filter := bson.M{"_id": id, "email": email}
+	update := bson.M{"$set": bson.M{"verified_email": email, "updated_at": time.Now()}}
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// EnsureIndexes membuat unique index untuk username dan email.
+// Akan gagal jika data lama masih mengandung duplikat.
+func (r *authRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "username", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_username").SetCollation(emailCollation),
+		},
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_email").SetCollation(emailCollation),
+		},
+		{
+			Keys: bson.D{{Key: "oidc_subject", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_oidc_subject").
+				SetPartialFilterExpression(bson.M{"oidc_subject": bson.M{"$exists": true}}),
+		},
+	})
+	return err
+}
+
+// FindDuplicates melaporkan username dan email yang dipakai lebih dari satu user
+func (r *authRepository) FindDuplicates() ([]model.DuplicateGroup, error) {
+	groups, err := findDuplicates(r.collection, "username", true)
+	if err != nil {
+		return nil, err
+	}
+	emails, err := findDuplicates(r.collection, "email", true)
+	if err != nil {
+		return nil, err
+	}
+	return append(groups, emails...), nil
+}
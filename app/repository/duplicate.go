@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"alumni-crud-api/app/model"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// findDuplicates mencari nilai field yang dipakai lebih dari satu dokumen.
+// caseInsensitive untuk field yang unique index-nya memakai collation strength 2.
+func findDuplicates(collection *mongo.Collection, field string, caseInsensitive bool) ([]model.DuplicateGroup, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	key := interface{}("$" + field)
+	if caseInsensitive {
+		key = bson.M{"$toLower": "$" + field}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{field: bson.M{"$exists": true}}}},
+		{{Key: "$group", Value: bson.M{"_id": key, "count": bson.M{"$sum": 1}, "ids": bson.M{"$push": "$_id"}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$sort", Value: bson.M{"count": -1}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Value interface{}          `bson:"_id"`
+		Count int                  `bson:"count"`
+		IDs   []primitive.ObjectID `bson:"ids"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	groups := make([]model.DuplicateGroup, 0, len(rows))
+	for _, row := range rows {
+		groups = append(groups, model.DuplicateGroup{
+			Collection: collection.Name(),
+			Field:      field,
+			Value:      row.Value,
+			Count:      row.Count,
+			IDs:        row.IDs,
+		})
+	}
+	return groups, nil
+}
+
+// duplicateKeyError memilih pesan berdasarkan nama unique index yang dilanggar
+func duplicateKeyError(err error, messages map[string]string, fallback string) error {
+	for index, msg := range messages {
+		if strings.Contains(err.Error(), index) {
+			return errors.New(msg)
+		}
+	}
+	return errors.New(fallback)
+}
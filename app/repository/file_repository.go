@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type FileRepository interface {
@@ -16,6 +17,10 @@ type FileRepository interface {
 	GetByID(id string) (*model.File, error)
 	GetByAlumniID(alumniID primitive.ObjectID) ([]model.File, error)
 	Delete(id string) error
+	SetHiddenByAlumni(alumniID primitive.ObjectID, hidden bool) ([]model.File, error) // Cascade dari trash/restore alumni
+	DeleteByAlumni(alumniID primitive.ObjectID) ([]model.File, error)
+	IsHidden(fileName string) (bool, error)
+	EnsureIndexes() error
 }
 
 type fileRepository struct {
@@ -52,7 +57,7 @@ func (r *fileRepository) GetByID(id string) (*model.File, error) {
 	}
 
 	var file model.File
-	if err := r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&file); err != nil {
+	if err := r.collection.FindOne(ctx, visibleOnly(bson.M{"_id": objID})).Decode(&file); err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, fmt.Errorf("file tidak ditemukan")
 		}
@@ -66,7 +71,7 @@ func (r *fileRepository) GetByAlumniID(alumniID primitive.ObjectID) ([]model.Fil
 	defer cancel()
 
 	var files []model.File
-	filter := bson.M{"alumni_id": alumniID}
+	filter := visibleOnly(bson.M{"alumni_id": alumniID})
 	cursor, err := r.collection.Find(ctx, filter)
 	if err != nil {
 		return nil, err
@@ -97,3 +102,94 @@ func (r *fileRepository) Delete(id string) error {
 	}
 	return nil
 }
+
+// visibleOnly mengecualikan file milik alumni yang sedang di trash
+func visibleOnly(filter bson.M) bson.M {
+	filter["is_hidden"] = bson.M{"$ne": true}
+	return filter
+}
+
+// SetHiddenByAlumni menyembunyikan atau menampilkan kembali semua file milik
+// alumni dan mengembalikan file yang statusnya berubah
+func (r *fileRepository) SetHiddenByAlumni(alumniID primitive.ObjectID, hidden bool) ([]model.File, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"alumni_id": alumniID, "is_hidden": bson.M{"$ne": true}}
+	update := bson.M{"$set": bson.M{"is_hidden": true}}
+	if !hidden {
+		filter["is_hidden"] = true
+		update = bson.M{"$unset": bson.M{"is_hidden": ""}}
+	}
+
+	var files []model.File
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	if _, err := r.collection.UpdateMany(ctx, filter, update); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// DeleteByAlumni menghapus metadata semua file milik alumni dan mengembalikan
+// data yang dihapus agar file fisiknya bisa ikut dihapus
+func (r *fileRepository) DeleteByAlumni(alumniID primitive.ObjectID) ([]model.File, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"alumni_id": alumniID}
+	var files []model.File
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.collection.DeleteMany(ctx, filter); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// IsHidden memeriksa apakah file dengan nama tersimpan tertentu sedang
+// disembunyikan. Nama yang tidak tercatat dianggap tidak disembunyikan.
+func (r *fileRepository) IsHidden(fileName string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"file_name": fileName, "is_hidden": true})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *fileRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "alumni_id", Value: 1}},
+			Options: options.Index().SetName("idx_alumni"),
+		},
+		{
+			Keys:    bson.D{{Key: "file_name", Value: 1}},
+			Options: options.Index().SetName("idx_file_name"),
+		},
+	})
+	return err
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"alumni-crud-api/app/model"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LoginAttemptRepository interface {
+	Record(attempt *model.LoginAttempt) error
+	GetThrottle(key string) (*model.LoginThrottle, error)
+	RegisterFailure(key string, window time.Duration) (*model.LoginThrottle, error)
+	SetLockedUntil(key string, until time.Time) error
+	ResetThrottle(key string) error
+	ListAttempts(filter model.LoginAttemptFilter, limit, offset int) ([]model.LoginAttempt, error)
+	CountAttempts(filter model.LoginAttemptFilter) (int, error)
+	EnsureIndexes() error
+}
+
+type loginAttemptRepository struct {
+	attemptCollection  *mongo.Collection
+	throttleCollection *mongo.Collection
+}
+
+func NewLoginAttemptRepository(db *mongo.Database) LoginAttemptRepository {
+	return &loginAttemptRepository{
+		attemptCollection:  db.Collection("login_attempts"),
+		throttleCollection: db.Collection("login_throttles"),
+	}
+}
+
+func (r *loginAttemptRepository) Record(attempt *model.LoginAttempt) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	attempt.CreatedAt = time.Now()
+	_, err := r.attemptCollection.InsertOne(ctx, attempt)
+	return err
+}
+
+// GetThrottle mengembalikan nil (tanpa error) jika key belum pernah gagal
+func (r *loginAttemptRepository) GetThrottle(key string) (*model.LoginThrottle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var throttle model.LoginThrottle
+	if err := r.throttleCollection.FindOne(ctx, bson.M{"_id": key}).Decode(&throttle); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// RegisterFailure menambah penghitung kegagalan secara atomik. Penghitung
+// dimulai ulang dari 1 jika kegagalan terakhir sudah lebih lama dari window.
+func (r *loginAttemptRepository) RegisterFailure(key string, window time.Duration) (*model.LoginThrottle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	cutoff := now.Add(-window)
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"failures": bson.M{"$cond": bson.A{
+				bson.M{"$lt": bson.A{bson.M{"$ifNull": bson.A{"$last_failure_at", cutoff}}, cutoff}},
+				1,
+				bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$failures", 0}}, 1}},
+			}},
+			"last_failure_at": now,
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var throttle model.LoginThrottle
+	if err := r.throttleCollection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&throttle); err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r *loginAttemptRepository) SetLockedUntil(key string, until time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.throttleCollection.UpdateOne(ctx, bson.M{"_id": key}, bson.M{"$set": bson.M{"locked_until": until}})
+	return err
+}
+
+func (r *loginAttemptRepository) ResetThrottle(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.throttleCollection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+func (r *loginAttemptRepository) buildAttemptFilter(f model.LoginAttemptFilter) bson.M {
+	filter := bson.M{}
+	if f.Identifier != "" {
+		filter["identifier"] = f.Identifier
+	}
+	if f.UserID != nil {
+		filter["user_id"] = *f.UserID
+	}
+	if f.IP != "" {
+		filter["ip"] = f.IP
+	}
+	if f.Result != "" {
+		filter["result"] = f.Result
+	}
+	if f.From != nil || f.To != nil {
+		createdAt := bson.M{}
+		if f.From != nil {
+			createdAt["$gte"] = *f.From
+		}
+		if f.To != nil {
+			createdAt["$lte"] = *f.To
+		}
+		filter["created_at"] = createdAt
+	}
+	return filter
+}
+
+func (r *loginAttemptRepository) ListAttempts(filter model.LoginAttemptFilter, limit, offset int) ([]model.LoginAttempt, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	attempts := []model.LoginAttempt{}
+	cursor, err := r.attemptCollection.Find(ctx, r.buildAttemptFilter(filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (r *loginAttemptRepository) CountAttempts(filter model.LoginAttemptFilter) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := r.attemptCollection.CountDocuments(ctx, r.buildAttemptFilter(filter))
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+func (r *loginAttemptRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.attemptCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_user_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "identifier", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_identifier_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "ip", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_ip_created"),
+		},
+	})
+	return err
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"alumni-crud-api/app/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OIDCStateRepository interface {
+	Create(state *model.OIDCLoginState) error
+	Consume(stateHash string) (*model.OIDCLoginState, error)
+	EnsureIndexes() error
+}
+
+type oidcStateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOIDCStateRepository(db *mongo.Database) OIDCStateRepository {
+	return &oidcStateRepository{
+		collection: db.Collection("oidc_states"),
+	}
+}
+
+func (r *oidcStateRepository) Create(state *model.OIDCLoginState) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	state.CreatedAt = time.Now()
+	result, err := r.collection.InsertOne(ctx, state)
+	if err != nil {
+		return err
+	}
+	state.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Consume mengambil sekaligus menghapus state, sehingga callback yang sama
+// tidak bisa diputar ulang
+func (r *oidcStateRepository) Consume(stateHash string) (*model.OIDCLoginState, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"state_hash": stateHash,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+
+	var state model.OIDCLoginState
+	if err := r.collection.FindOneAndDelete(ctx, filter).Decode(&state); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("state login SSO tidak valid atau sudah kedaluwarsa")
+		}
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (r *oidcStateRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "state_hash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_state_hash"),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("ttl_expires_at"),
+		},
+	})
+	return err
+}
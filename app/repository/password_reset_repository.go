@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"alumni-crud-api/app/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PasswordResetRepository interface {
+	Create(reset *model.PasswordReset) error
+	Consume(tokenHash string) (*model.PasswordReset, error)
+	InvalidateForUser(userID primitive.ObjectID) error
+	EnsureIndexes() error
+}
+
+type passwordResetRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPasswordResetRepository(db *mongo.Database) PasswordResetRepository {
+	return &passwordResetRepository{
+		collection: db.Collection("password_resets"),
+	}
+}
+
+func (r *passwordResetRepository) Create(reset *model.PasswordReset) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reset.CreatedAt = time.Now()
+	result, err := r.collection.InsertOne(ctx, reset)
+	if err != nil {
+		return err
+	}
+	reset.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Consume menandai token sebagai terpakai secara atomik. Token yang sudah
+// dipakai atau expired tidak akan cocok dengan filter.
+func (r *passwordResetRepository) Consume(tokenHash string) (*model.PasswordReset, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"token_hash": tokenHash,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+	update := bson.M{"$set": bson.M{"used_at": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var reset model.PasswordReset
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&reset); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("token reset tidak valid atau sudah kedaluwarsa")
+		}
+		return nil, err
+	}
+	return &reset, nil
+}
+
+// InvalidateForUser membatalkan semua token reset yang belum terpakai milik user
+func (r *passwordResetRepository) InvalidateForUser(userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "used_at": bson.M{"$exists": false}}
+	_, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"used_at": time.Now()}})
+	return err
+}
+
+func (r *passwordResetRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_token_hash"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("idx_user_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("ttl_expires_at"),
+		},
+	})
+	return err
+}
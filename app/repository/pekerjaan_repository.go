@@ -14,33 +14,81 @@ import (
 )
 
 type PekerjaanRepository interface {
-	GetAll() ([]model.PekerjaanAlumni, error)
+	GetAll(scope *model.DataScope) ([]model.PekerjaanAlumni, error)
 	GetByID(id string) (*model.PekerjaanAlumni, error)
 	GetByAlumniID(alumniID string) ([]model.PekerjaanAlumni, error)
-	Create(pekerjaan *model.CreatePekerjaanRequest) (*model.PekerjaanAlumni, error)
-	Update(id string, pekerjaan *model.UpdatePekerjaanRequest) (*model.PekerjaanAlumni, error)
-	Delete(id string) error // Hard delete (admin)
-	GetAllWithPagination(search, sortBy, order string, limit, offset int) ([]model.PekerjaanAlumni, error)
-	CountWithSearch(search string) (int, error)
-	SoftDelete(id string, deleterID primitive.ObjectID) error
-	ListTrashAdmin(search string, limit, offset int) ([]model.PekerjaanAlumni, error)
-	ListTrashUser(alumniID primitive.ObjectID, search string, limit, offset int) ([]model.PekerjaanAlumni, error)
-	Restore(id string) error
-	HardDeleteAdmin(id string) error
-	HardDeleteUser(id string, alumniID primitive.ObjectID) error
+	Create(pekerjaan *model.CreatePekerjaanRequest, periode *model.PeriodePekerjaan, approvalStatus string) (*model.PekerjaanAlumni, error)
+	Update(id string, pekerjaan *model.UpdatePekerjaanRequest, periode *model.PeriodePekerjaan, version int) (*model.PekerjaanAlumni, error)
+	Patch(id string, fields bson.M, version int) (*model.PekerjaanAlumni, error)
+	Delete(id string, version int) error // Hard delete (admin)
+	GetAllWithPagination(search, sortBy, order string, limit, offset int, scope *model.DataScope) ([]model.PekerjaanAlumni, error)
+	CountWithSearch(search string, scope *model.DataScope) (int, error)
+	SoftDelete(id string, deleterID primitive.ObjectID, reason string, version int) error
+	ListTrashAdmin(search string, limit, offset int, scope *model.DataScope) ([]model.PekerjaanTrashItem, error)
+	CountTrashAdmin(search string, scope *model.DataScope) (int, error)
+	ListTrashUser(alumniID primitive.ObjectID, search string, limit, offset int) ([]model.PekerjaanTrashItem, error)
+	CountTrashUser(alumniID primitive.ObjectID, search string) (int, error)
+	ListHardDeletableAdmin(search string, limit int, scope *model.DataScope) ([]primitive.ObjectID, error) // Kandidat bulk purge/empty trash
+	ListHardDeletableUser(alumniID primitive.ObjectID, search string, limit int) ([]primitive.ObjectID, error)
+	Restore(id string, restorerID primitive.ObjectID, version int) error
+	HardDeleteAdmin(id string, version int) error
+	HardDeleteUser(id string, alumniID primitive.ObjectID, version int) error
 	GetByIDWithDeleted(id string) (*model.PekerjaanAlumni, error) // Untuk restore/harddelete
+	FindOverlappingFullTime(alumniID primitive.ObjectID, periode *model.PeriodePekerjaan, excludeID *primitive.ObjectID) ([]model.PekerjaanAlumni, error)
+	SetApprovalStatus(id, status string, reviewerID *primitive.ObjectID, reason string) (*model.PekerjaanAlumni, error)
+	SoftDeleteByAlumni(alumniID, deleterID primitive.ObjectID) ([]model.PekerjaanAlumni, error) // Cascade dari trash alumni
+	RestoreByAlumni(alumniID, restorerID primitive.ObjectID) ([]model.PekerjaanAlumni, error)
+	DeleteByAlumni(alumniID primitive.ObjectID) ([]model.PekerjaanAlumni, error) // Cascade dari hard delete alumni
+	CountLegalHoldByAlumni(alumniID primitive.ObjectID) (int, error)
+	SetLegalHold(id string, hold bool, by primitive.ObjectID, reason string) (*model.PekerjaanAlumni, error)
+	ListPurgeable(cutoff time.Time, limit int, scope *model.DataScope) ([]model.PekerjaanAlumni, error)
+	CountPurgeable(cutoff time.Time, scope *model.DataScope) (int, error)
+	CountHeldExpired(cutoff time.Time, scope *model.DataScope) (int, error)
+	Purge(id primitive.ObjectID, cutoff time.Time) (bool, error)
+	EnsureIndexes() error
 }
 
 type pekerjaanRepository struct {
-	collection *mongo.Collection
+	collection       *mongo.Collection
+	alumniCollection *mongo.Collection // Untuk membatasi query berdasarkan jurusan alumni pemilik
 }
 
 func NewPekerjaanRepository(db *mongo.Database) PekerjaanRepository {
 	return &pekerjaanRepository{
-		collection: db.Collection("pekerjaan_alumni"),
+		collection:       db.Collection("pekerjaan_alumni"),
+		alumniCollection: db.Collection("alumni"),
 	}
 }
 
+// applyScope menambahkan filter alumni_id ke filter utama agar hanya pekerjaan
+// milik alumni dalam scope (jurusan) yang ikut. Scope nil tidak mengubah filter.
+func (r *pekerjaanRepository) applyScope(ctx context.Context, filter bson.M, scope *model.DataScope) error {
+	if scope == nil {
+		return nil
+	}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := r.alumniCollection.Find(ctx, jurusanScopeFilter(scope), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	filter["alumni_id"] = bson.M{"$in": ids}
+	return nil
+}
+
 // buildPekerjaanSearchFilter adalah helper internal
 func (r *pekerjaanRepository) buildPekerjaanSearchFilter(search string) bson.M {
 	if search == "" {
@@ -58,13 +106,23 @@ func (r *pekerjaanRepository) buildPekerjaanSearchFilter(search string) bson.M {
 	}
 }
 
-func (r *pekerjaanRepository) GetAll() ([]model.PekerjaanAlumni, error) {
+// approvedOnly menyembunyikan pekerjaan yang masih menunggu persetujuan atau
+// ditolak dari daftar umum. Data lama tanpa approval_status tetap tampil.
+func approvedOnly(filter bson.M) bson.M {
+	filter["approval_status"] = bson.M{"$nin": bson.A{model.ApprovalPending, model.ApprovalRejected}}
+	return filter
+}
+
+func (r *pekerjaanRepository) GetAll(scope *model.DataScope) ([]model.PekerjaanAlumni, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	var pekerjaan []model.PekerjaanAlumni
-	filter := bson.M{"is_deleted": false}
-	opts := options.Find().SetSort(bson.D{{"created_at", -1}})
+	filter := approvedOnly(bson.M{"is_deleted": false})
+	if err := r.applyScope(ctx, filter, scope); err != nil {
+		return nil, err
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
 	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
@@ -128,7 +186,7 @@ func (r *pekerjaanRepository) GetByAlumniID(alumniID string) ([]model.PekerjaanA
 
 	var pekerjaan []model.PekerjaanAlumni
 	filter := bson.M{"alumni_id": alumniObjID, "is_deleted": false}
-	opts := options.Find().SetSort(bson.D{{"tanggal_mulai_kerja", -1}})
+	opts := options.Find().SetSort(bson.D{{Key: "tanggal_mulai_kerja", Value: -1}})
 	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
@@ -141,7 +199,7 @@ func (r *pekerjaanRepository) GetByAlumniID(alumniID string) ([]model.PekerjaanA
 	return pekerjaan, nil
 }
 
-func (r *pekerjaanRepository) Create(req *model.CreatePekerjaanRequest) (*model.PekerjaanAlumni, error) {
+func (r *pekerjaanRepository) Create(req *model.CreatePekerjaanRequest, periode *model.PeriodePekerjaan, approvalStatus string) (*model.PekerjaanAlumni, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -158,13 +216,16 @@ func (r *pekerjaanRepository) Create(req *model.CreatePekerjaanRequest) (*model.
 		BidangIndustri:      req.BidangIndustri,
 		LokasiKerja:         req.LokasiKerja,
 		GajiRange:           req.GajiRange,
-		TanggalMulaiKerja:   req.TanggalMulaiKerja,
-		TanggalSelesaiKerja: req.TanggalSelesaiKerja,
+		TanggalMulaiKerja:   periode.Mulai,
+		TanggalSelesaiKerja: periode.Selesai,
 		StatusPekerjaan:     req.StatusPekerjaan,
+		JenisPekerjaan:      req.JenisPekerjaan,
 		DeskripsiPekerjaan:  req.DeskripsiPekerjaan,
 		IsDeleted:           false,
+		ApprovalStatus:      approvalStatus,
 		CreatedAt:           now,
 		UpdatedAt:           now,
+		Version:             1,
 	}
 
 	result, err := r.collection.InsertOne(ctx, newPekerjaan)
@@ -176,7 +237,9 @@ func (r *pekerjaanRepository) Create(req *model.CreatePekerjaanRequest) (*model.
 	return &newPekerjaan, nil
 }
 
-func (r *pekerjaanRepository) Update(id string, req *model.UpdatePekerjaanRequest) (*model.PekerjaanAlumni, error) {
+// Update hanya berhasil jika versi dokumen masih sama dengan version
+// (optimistic concurrency), lalu menaikkan versinya
+func (r *pekerjaanRepository) Update(id string, req *model.UpdatePekerjaanRequest, periode *model.PeriodePekerjaan, version int) (*model.PekerjaanAlumni, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -192,21 +255,23 @@ func (r *pekerjaanRepository) Update(id string, req *model.UpdatePekerjaanReques
 			"bidang_industri":       req.BidangIndustri,
 			"lokasi_kerja":          req.LokasiKerja,
 			"gaji_range":            req.GajiRange,
-			"tanggal_mulai_kerja":   req.TanggalMulaiKerja,
-			"tanggal_selesai_kerja": req.TanggalSelesaiKerja,
+			"tanggal_mulai_kerja":   periode.Mulai,
+			"tanggal_selesai_kerja": periode.Selesai,
 			"status_pekerjaan":      req.StatusPekerjaan,
+			"jenis_pekerjaan":       req.JenisPekerjaan,
 			"deskripsi_pekerjaan":   req.DeskripsiPekerjaan,
 			"updated_at":            time.Now(),
 		},
+		"$inc": bson.M{"version": 1},
 	}
 
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	var updatedPekerjaan model.PekerjaanAlumni
-	filter := bson.M{"_id": objID, "is_deleted": false}
+	filter := bson.M{"_id": objID, "is_deleted": false, "version": versionFilter(version)}
 	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updatedPekerjaan)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("pekerjaan tidak ditemukan")
+			return nil, versionConflictOr(ctx, r.collection, bson.M{"_id": objID, "is_deleted": false}, "pekerjaan tidak ditemukan")
 		}
 		return nil, err
 	}
@@ -214,7 +279,30 @@ func (r *pekerjaanRepository) Update(id string, req *model.UpdatePekerjaanReques
 	return &updatedPekerjaan, nil
 }
 
-func (r *pekerjaanRepository) Delete(id string) error {
+// Patch hanya menulis field yang diberikan (nil = $unset), dengan syarat versi
+// yang sama seperti Update. Nama field harus sudah divalidasi oleh service.
+func (r *pekerjaanRepository) Patch(id string, fields bson.M, version int) (*model.PekerjaanAlumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	filter := bson.M{"_id": objID, "is_deleted": false, "version": versionFilter(version)}
+	var p model.PekerjaanAlumni
+	if err := r.collection.FindOneAndUpdate(ctx, filter, patchUpdate(fields), opts).Decode(&p); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, versionConflictOr(ctx, r.collection, bson.M{"_id": objID, "is_deleted": false}, "pekerjaan tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *pekerjaanRepository) Delete(id string, version int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -223,19 +311,29 @@ func (r *pekerjaanRepository) Delete(id string) error {
 		return fmt.Errorf("ID tidak valid: %v", err)
 	}
 
-	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objID})
+	// Legal hold dicek ulang di filter agar hold yang dipasang setelah
+	// pengecekan di service tetap mencegah penghapusan
+	filter := bson.M{"_id": objID, "legal_hold": bson.M{"$ne": true}}
+	result, err := r.collection.DeleteOne(ctx, withVersion(filter, version))
 	if err != nil {
 		return err
 	}
 
 	if result.DeletedCount == 0 {
-		return fmt.Errorf("pekerjaan tidak ditemukan")
+		held, err := r.collection.CountDocuments(ctx, bson.M{"_id": objID, "legal_hold": true})
+		if err != nil {
+			return err
+		}
+		if held > 0 {
+			return fmt.Errorf("pekerjaan dalam legal hold")
+		}
+		return versionConflictOr(ctx, r.collection, filter, "pekerjaan tidak ditemukan")
 	}
 
 	return nil
 }
 
-func (r *pekerjaanRepository) SoftDelete(id string, deleterID primitive.ObjectID) error {
+func (r *pekerjaanRepository) SoftDelete(id string, deleterID primitive.ObjectID, reason string, version int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -244,27 +342,32 @@ func (r *pekerjaanRepository) SoftDelete(id string, deleterID primitive.ObjectID
 		return fmt.Errorf("ID tidak valid: %v", err)
 	}
 
+	set := bson.M{
+		"is_deleted": true,
+		"deleted_at": time.Now(),
+		"deleted_by": deleterID,
+		"updated_at": time.Now(),
+	}
+	if reason != "" {
+		set["delete_reason"] = reason
+	}
 	update := bson.M{
-		"$set": bson.M{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-			"deleted_by": deleterID,
-			"updated_at": time.Now(),
-		},
+		"$set": set,
+		"$inc": bson.M{"version": 1},
 	}
 	filter := bson.M{"_id": objID, "is_deleted": false}
 
-	result, err := r.collection.UpdateOne(ctx, filter, update)
+	result, err := r.collection.UpdateOne(ctx, withVersion(filter, version), update)
 	if err != nil {
 		return err
 	}
 	if result.ModifiedCount == 0 {
-		return fmt.Errorf("pekerjaan tidak ditemukan atau sudah dihapus")
+		return versionConflictOr(ctx, r.collection, filter, "pekerjaan tidak ditemukan atau sudah dihapus")
 	}
 	return nil
 }
 
-func (r *pekerjaanRepository) GetAllWithPagination(search, sortBy, order string, limit, offset int) ([]model.PekerjaanAlumni, error) {
+func (r *pekerjaanRepository) GetAllWithPagination(search, sortBy, order string, limit, offset int, scope *model.DataScope) ([]model.PekerjaanAlumni, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -273,6 +376,10 @@ func (r *pekerjaanRepository) GetAllWithPagination(search, sortBy, order string,
 	if search != "" {
 		mainFilter = bson.M{"is_deleted": false, "$and": []bson.M{searchFilter}}
 	}
+	approvedOnly(mainFilter)
+	if err := r.applyScope(ctx, mainFilter, scope); err != nil {
+		return nil, err
+	}
 
 	validSortColumns := map[string]bool{"id": true, "alumni_id": true, "nama_perusahaan": true, "posisi_jabatan": true, "bidang_industri": true, "lokasi_kerja": true, "status_pekerjaan": true, "tanggal_mulai_kerja": true, "created_at": true}
 	if !validSortColumns[sortBy] {
@@ -288,7 +395,7 @@ func (r *pekerjaanRepository) GetAllWithPagination(search, sortBy, order string,
 	}
 
 	opts := options.Find().
-		SetSort(bson.D{{sortBy, sortOrder}}).
+		SetSort(bson.D{{Key: sortBy, Value: sortOrder}}).
 		SetLimit(int64(limit)).
 		SetSkip(int64(offset))
 
@@ -307,7 +414,7 @@ func (r *pekerjaanRepository) GetAllWithPagination(search, sortBy, order string,
 	return pekerjaan, nil
 }
 
-func (r *pekerjaanRepository) CountWithSearch(search string) (int, error) {
+func (r *pekerjaanRepository) CountWithSearch(search string, scope *model.DataScope) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -316,6 +423,10 @@ func (r *pekerjaanRepository) CountWithSearch(search string) (int, error) {
 	if search != "" {
 		mainFilter = bson.M{"is_deleted": false, "$and": []bson.M{searchFilter}}
 	}
+	approvedOnly(mainFilter)
+	if err := r.applyScope(ctx, mainFilter, scope); err != nil {
+		return 0, err
+	}
 
 	count, err := r.collection.CountDocuments(ctx, mainFilter)
 	if err != nil {
@@ -324,67 +435,179 @@ func (r *pekerjaanRepository) CountWithSearch(search string) (int, error) {
 	return int(count), nil
 }
 
-func (r *pekerjaanRepository) ListTrashAdmin(search string, limit, offset int) ([]model.PekerjaanAlumni, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	searchFilter := r.buildPekerjaanSearchFilter(search)
+// trashAdminFilter adalah filter trash untuk admin, dibatasi scope jurusan
+func (r *pekerjaanRepository) trashAdminFilter(ctx context.Context, search string, scope *model.DataScope) (bson.M, error) {
 	mainFilter := bson.M{"is_deleted": true}
 	if search != "" {
-		mainFilter = bson.M{"is_deleted": true, "$and": []bson.M{searchFilter}}
+		mainFilter["$and"] = []bson.M{r.buildPekerjaanSearchFilter(search)}
+	}
+	if err := r.applyScope(ctx, mainFilter, scope); err != nil {
+		return nil, err
 	}
+	return mainFilter, nil
+}
 
-	opts := options.Find().
-		SetSort(bson.D{{"deleted_at", -1}}).
-		SetLimit(int64(limit)).
-		SetSkip(int64(offset))
+// trashUserFilter adalah filter trash milik satu alumni
+func (r *pekerjaanRepository) trashUserFilter(alumniID primitive.ObjectID, search string) bson.M {
+	mainFilter := bson.M{
+		"is_deleted": true,
+		"alumni_id":  alumniID,
+	}
+	if search != "" {
+		mainFilter["$and"] = []bson.M{r.buildPekerjaanSearchFilter(search)}
+	}
+	return mainFilter
+}
 
-	var pekerjaan []model.PekerjaanAlumni
-	cursor, err := r.collection.Find(ctx, mainFilter, opts)
+// listTrash mengambil satu halaman trash, dilengkapi username penghapus serta
+// nama dan NIM alumni pemilik
+func (r *pekerjaanRepository) listTrash(ctx context.Context, filter bson.M, limit, offset int) ([]model.PekerjaanTrashItem, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.D{{Key: "deleted_at", Value: -1}}}},
+		{{Key: "$skip", Value: int64(offset)}},
+		{{Key: "$limit", Value: int64(limit)}},
+		{{Key: "$lookup", Value: bson.M{"from": "alumni", "localField": "alumni_id", "foreignField": "_id", "as": "_alumni"}}},
+		{{Key: "$lookup", Value: bson.M{"from": "users", "localField": "deleted_by", "foreignField": "_id", "as": "_deleter"}}},
+		{{Key: "$set", Value: bson.M{
+			"alumni_nama":         bson.M{"$arrayElemAt": bson.A{"$_alumni.nama", 0}},
+			"alumni_nim":          bson.M{"$arrayElemAt": bson.A{"$_alumni.nim", 0}},
+			"deleted_by_username": bson.M{"$arrayElemAt": bson.A{"$_deleter.username", 0}},
+		}}},
+		{{Key: "$unset", Value: bson.A{"_alumni", "_deleter"}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	if err = cursor.All(ctx, &pekerjaan); err != nil {
+	var items []model.PekerjaanTrashItem
+	if err = cursor.All(ctx, &items); err != nil {
 		return nil, err
 	}
-	return pekerjaan, nil
+	return items, nil
 }
 
-func (r *pekerjaanRepository) ListTrashUser(alumniID primitive.ObjectID, search string, limit, offset int) ([]model.PekerjaanAlumni, error) {
+func (r *pekerjaanRepository) ListTrashAdmin(search string, limit, offset int, scope *model.DataScope) ([]model.PekerjaanTrashItem, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	searchFilter := r.buildPekerjaanSearchFilter(search)
-	mainFilter := bson.M{
-		"is_deleted": true,
-		"alumni_id":  alumniID,
+	filter, err := r.trashAdminFilter(ctx, search, scope)
+	if err != nil {
+		return nil, err
 	}
+	return r.listTrash(ctx, filter, limit, offset)
+}
 
-	if search != "" {
-		mainFilter["$and"] = []bson.M{searchFilter}
+func (r *pekerjaanRepository) CountTrashAdmin(search string, scope *model.DataScope) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter, err := r.trashAdminFilter(ctx, search, scope)
+	if err != nil {
+		return 0, err
+	}
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+func (r *pekerjaanRepository) ListTrashUser(alumniID primitive.ObjectID, search string, limit, offset int) ([]model.PekerjaanTrashItem, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return r.listTrash(ctx, r.trashUserFilter(alumniID, search), limit, offset)
+}
+
+func (r *pekerjaanRepository) CountTrashUser(alumniID primitive.ObjectID, search string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, r.trashUserFilter(alumniID, search))
+	if err != nil {
+		return 0, err
 	}
+	return int(count), nil
+}
 
+// listHardDeletable mengambil ID pekerjaan di trash yang bisa langsung dihapus
+// permanen, terbaru lebih dulu seperti urutan listTrash
+func (r *pekerjaanRepository) listHardDeletable(ctx context.Context, filter bson.M, limit int) ([]primitive.ObjectID, error) {
 	opts := options.Find().
-		SetSort(bson.D{{"deleted_at", -1}}).
-		SetLimit(int64(limit)).
-		SetSkip(int64(offset))
-
-	var pekerjaan []model.PekerjaanAlumni
-	cursor, err := r.collection.Find(ctx, mainFilter, opts)
+		SetProjection(bson.M{"_id": 1}).
+		SetSort(bson.D{{Key: "deleted_at", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, hardDeletableOnly(filter), opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	if err = cursor.All(ctx, &pekerjaan); err != nil {
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
 		return nil, err
 	}
-	return pekerjaan, nil
+	ids := make([]primitive.ObjectID, len(docs))
+	for i, d := range docs {
+		ids[i] = d.ID
+	}
+	return ids, nil
+}
+
+func (r *pekerjaanRepository) ListHardDeletableAdmin(search string, limit int, scope *model.DataScope) ([]primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter, err := r.trashAdminFilter(ctx, search, scope)
+	if err != nil {
+		return nil, err
+	}
+	return r.listHardDeletable(ctx, filter, limit)
+}
+
+func (r *pekerjaanRepository) ListHardDeletableUser(alumniID primitive.ObjectID, search string, limit int) ([]primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return r.listHardDeletable(ctx, r.trashUserFilter(alumniID, search), limit)
 }
 
-func (r *pekerjaanRepository) Restore(id string) error {
+// restoreUpdate adalah update pipeline untuk mengembalikan pekerjaan dari
+// trash. Informasi penghapusan dipindahkan ke restore_history sebelum dihapus
+// dari dokumen.
+func restoreUpdate(restorerID primitive.ObjectID, withAlumni bool) mongo.Pipeline {
+	now := time.Now()
+	event := bson.M{
+		"restored_at":   now,
+		"restored_by":   restorerID,
+		"deleted_at":    "$deleted_at",
+		"deleted_by":    "$deleted_by",
+		"delete_reason": "$delete_reason",
+	}
+	if withAlumni {
+		event["with_alumni"] = true
+	}
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"is_deleted": false,
+			"updated_at": now,
+			"version":    bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}},
+			"restore_history": bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$restore_history", bson.A{}}},
+				bson.A{event},
+			}},
+		}}},
+		{{Key: "$unset", Value: bson.A{"deleted_at", "deleted_by", "delete_reason", "deleted_with_alumni"}}},
+	}
+}
+
+func (r *pekerjaanRepository) Restore(id string, restorerID primitive.ObjectID, version int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -393,29 +616,19 @@ func (r *pekerjaanRepository) Restore(id string) error {
 		return fmt.Errorf("ID tidak valid: %v", err)
 	}
 
-	update := bson.M{
-		"$set": bson.M{
-			"is_deleted": false,
-			"updated_at": time.Now(),
-		},
-		"$unset": bson.M{
-			"deleted_at": "",
-			"deleted_by": "",
-		},
-	}
 	filter := bson.M{"_id": objID, "is_deleted": true}
 
-	result, err := r.collection.UpdateOne(ctx, filter, update)
+	result, err := r.collection.UpdateOne(ctx, withVersion(filter, version), restoreUpdate(restorerID, false))
 	if err != nil {
 		return err
 	}
 	if result.ModifiedCount == 0 {
-		return fmt.Errorf("pekerjaan tidak ditemukan di trash")
+		return versionConflictOr(ctx, r.collection, filter, "pekerjaan tidak ditemukan di trash")
 	}
 	return nil
 }
 
-func (r *pekerjaanRepository) HardDeleteAdmin(id string) error {
+func (r *pekerjaanRepository) HardDeleteAdmin(id string, version int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -424,18 +637,18 @@ func (r *pekerjaanRepository) HardDeleteAdmin(id string) error {
 		return fmt.Errorf("ID tidak valid: %v", err)
 	}
 
-	filter := bson.M{"_id": objID, "is_deleted": true}
-	result, err := r.collection.DeleteOne(ctx, filter)
+	filter := hardDeletableOnly(bson.M{"_id": objID, "is_deleted": true})
+	result, err := r.collection.DeleteOne(ctx, withVersion(filter, version))
 	if err != nil {
 		return err
 	}
 	if result.DeletedCount == 0 {
-		return fmt.Errorf("pekerjaan tidak ditemukan di trash")
+		return r.hardDeleteConflict(ctx, objID, filter, "pekerjaan tidak ditemukan di trash")
 	}
 	return nil
 }
 
-func (r *pekerjaanRepository) HardDeleteUser(id string, alumniID primitive.ObjectID) error {
+func (r *pekerjaanRepository) HardDeleteUser(id string, alumniID primitive.ObjectID, version int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -444,17 +657,367 @@ func (r *pekerjaanRepository) HardDeleteUser(id string, alumniID primitive.Objec
 		return fmt.Errorf("ID tidak valid: %v", err)
 	}
 
-	filter := bson.M{
-		"_id":        objID,
-		"is_deleted": true,
-		"alumni_id":  alumniID,
-	}
-	result, err := r.collection.DeleteOne(ctx, filter)
+	filter := hardDeletableOnly(bson.M{"_id": objID, "is_deleted": true, "alumni_id": alumniID})
+	result, err := r.collection.DeleteOne(ctx, withVersion(filter, version))
 	if err != nil {
 		return err
 	}
 	if result.DeletedCount == 0 {
-		return fmt.Errorf("pekerjaan tidak ditemukan di trash atau Anda tidak memiliki akses")
+		return r.hardDeleteConflict(ctx, objID, filter, "pekerjaan tidak ditemukan di trash atau Anda tidak memiliki akses")
 	}
 	return nil
 }
+
+// hardDeleteConflict menjelaskan kenapa hard delete tidak menghapus apa pun:
+// legal hold, ikut terhapus bersama alumni, versi berubah, atau tidak ada
+func (r *pekerjaanRepository) hardDeleteConflict(ctx context.Context, objID primitive.ObjectID, filter bson.M, notFound string) error {
+	var current model.PekerjaanAlumni
+	err := r.collection.FindOne(ctx, bson.M{"_id": objID, "is_deleted": true}).Decode(&current)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	if err == nil && current.LegalHold {
+		return fmt.Errorf("pekerjaan dalam legal hold")
+	}
+	if err == nil && current.DeletedWithAlumni {
+		return fmt.Errorf("pekerjaan terhapus bersama alumni")
+	}
+	return versionConflictOr(ctx, r.collection, filter, notFound)
+}
+
+// FindOverlappingFullTime mencari pekerjaan full time lain milik alumni yang
+// periodenya bertumpuk dengan periode. Tanggal selesai kosong berarti masih
+// berjalan; pindah kerja di hari yang sama (selesai = mulai) tidak dianggap
+// tumpang tindih. Pekerjaan di trash dan yang ditolak admin diabaikan.
+func (r *pekerjaanRepository) FindOverlappingFullTime(alumniID primitive.ObjectID, periode *model.PeriodePekerjaan, excludeID *primitive.ObjectID) ([]model.PekerjaanAlumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"alumni_id":       alumniID,
+		"is_deleted":      false,
+		"approval_status": bson.M{"$ne": model.ApprovalRejected},
+		"jenis_pekerjaan": bson.M{"$in": bson.A{model.JenisFullTime, "", nil}},
+		"$or": bson.A{
+			bson.M{"tanggal_selesai_kerja": nil},
+			bson.M{"tanggal_selesai_kerja": bson.M{"$gt": periode.Mulai}},
+		},
+	}
+	if periode.Selesai != nil {
+		filter["tanggal_mulai_kerja"] = bson.M{"$lt": *periode.Selesai}
+	}
+	if excludeID != nil {
+		filter["_id"] = bson.M{"$ne": *excludeID}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "tanggal_mulai_kerja", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var pekerjaan []model.PekerjaanAlumni
+	if err := cursor.All(ctx, &pekerjaan); err != nil {
+		return nil, err
+	}
+	return pekerjaan, nil
+}
+
+// SetApprovalStatus mengubah status persetujuan. Status pending menghapus data
+// review sebelumnya; approved/rejected mencatat reviewer dan alasan penolakan.
+func (r *pekerjaanRepository) SetApprovalStatus(id, status string, reviewerID *primitive.ObjectID, reason string) (*model.PekerjaanAlumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	now := time.Now()
+	set := bson.M{"approval_status": status, "updated_at": now}
+	unset := bson.M{"reject_reason": ""}
+	if reviewerID != nil {
+		set["reviewed_by"] = *reviewerID
+		set["reviewed_at"] = now
+	} else {
+		unset["reviewed_by"] = ""
+		unset["reviewed_at"] = ""
+	}
+	if reason != "" {
+		set["reject_reason"] = reason
+		delete(unset, "reject_reason")
+	}
+	update := bson.M{"$set": set, "$unset": unset, "$inc": bson.M{"version": 1}}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var p model.PekerjaanAlumni
+	filter := bson.M{"_id": objID, "is_deleted": false}
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&p); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("pekerjaan tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SoftDeleteByAlumni memindahkan semua pekerjaan aktif milik alumni ke trash
+// dan menandainya deleted_with_alumni, agar restore alumni hanya
+// mengembalikan pekerjaan yang ikut terhapus (bukan yang sudah di trash
+// sebelumnya). Mengembalikan kondisi pekerjaan sebelum dihapus.
+func (r *pekerjaanRepository) SoftDeleteByAlumni(alumniID, deleterID primitive.ObjectID) ([]model.PekerjaanAlumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"alumni_id": alumniID, "is_deleted": false}
+	var pekerjaan []model.PekerjaanAlumni
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &pekerjaan); err != nil {
+		return nil, err
+	}
+	if len(pekerjaan) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(pekerjaan))
+	for i, p := range pekerjaan {
+		ids[i] = p.ID
+	}
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"is_deleted":          true,
+			"deleted_at":          now,
+			"deleted_by":          deleterID,
+			"deleted_with_alumni": true,
+			"updated_at":          now,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+	if _, err := r.collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "is_deleted": false}, update); err != nil {
+		return nil, err
+	}
+	return pekerjaan, nil
+}
+
+// RestoreByAlumni mengembalikan pekerjaan yang ikut terhapus bersama alumni.
+// Mengembalikan kondisi pekerjaan sebelum direstore.
+func (r *pekerjaanRepository) RestoreByAlumni(alumniID, restorerID primitive.ObjectID) ([]model.PekerjaanAlumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"alumni_id": alumniID, "is_deleted": true, "deleted_with_alumni": true}
+	var pekerjaan []model.PekerjaanAlumni
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &pekerjaan); err != nil {
+		return nil, err
+	}
+	if len(pekerjaan) == 0 {
+		return nil, nil
+	}
+
+	if _, err := r.collection.UpdateMany(ctx, filter, restoreUpdate(restorerID, true)); err != nil {
+		return nil, err
+	}
+	return pekerjaan, nil
+}
+
+// DeleteByAlumni menghapus permanen semua pekerjaan milik alumni, baik yang
+// aktif maupun yang di trash, dan mengembalikan data yang dihapus
+func (r *pekerjaanRepository) DeleteByAlumni(alumniID primitive.ObjectID) ([]model.PekerjaanAlumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"alumni_id": alumniID}
+	var pekerjaan []model.PekerjaanAlumni
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err = cursor.All(ctx, &pekerjaan); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.collection.DeleteMany(ctx, filter); err != nil {
+		return nil, err
+	}
+	return pekerjaan, nil
+}
+
+func (r *pekerjaanRepository) CountLegalHoldByAlumni(alumniID primitive.ObjectID) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"alumni_id": alumniID, "legal_hold": true})
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// SetLegalHold memasang atau melepas legal hold, baik untuk pekerjaan aktif
+// maupun yang sudah di trash
+func (r *pekerjaanRepository) SetLegalHold(id string, hold bool, by primitive.ObjectID, reason string) (*model.PekerjaanAlumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"$set":   bson.M{"updated_at": now},
+		"$unset": bson.M{"legal_hold": "", "legal_hold_reason": "", "legal_hold_by": "", "legal_hold_at": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+	if hold {
+		update["$set"] = bson.M{
+			"legal_hold":        true,
+			"legal_hold_reason": reason,
+			"legal_hold_by":     by,
+			"legal_hold_at":     now,
+			"updated_at":        now,
+		}
+		delete(update, "$unset")
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var p model.PekerjaanAlumni
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": objID}, update, opts).Decode(&p); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("pekerjaan tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// expiredFilter mencocokkan pekerjaan yang sudah di trash sebelum cutoff.
+// Pekerjaan yang ikut terhapus bersama alumninya tidak ikut; nasibnya
+// mengikuti alumni (restore atau hard delete alumni).
+func expiredFilter(cutoff time.Time) bson.M {
+	return bson.M{
+		"is_deleted":          true,
+		"deleted_at":          bson.M{"$lt": cutoff},
+		"deleted_with_alumni": bson.M{"$ne": true},
+	}
+}
+
+// hardDeletableOnly membatasi filter trash ke pekerjaan yang boleh dihapus
+// permanen: tidak dalam legal hold dan tidak ikut terhapus bersama alumninya
+func hardDeletableOnly(filter bson.M) bson.M {
+	filter["legal_hold"] = bson.M{"$ne": true}
+	filter["deleted_with_alumni"] = bson.M{"$ne": true}
+	return filter
+}
+
+// purgeableFilter adalah expiredFilter tanpa pekerjaan yang dalam legal hold
+func purgeableFilter(cutoff time.Time) bson.M {
+	return hardDeletableOnly(expiredFilter(cutoff))
+}
+
+func (r *pekerjaanRepository) ListPurgeable(cutoff time.Time, limit int, scope *model.DataScope) ([]model.PekerjaanAlumni, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := purgeableFilter(cutoff)
+	if err := r.applyScope(ctx, filter, scope); err != nil {
+		return nil, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "deleted_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	var pekerjaan []model.PekerjaanAlumni
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &pekerjaan); err != nil {
+		return nil, err
+	}
+	return pekerjaan, nil
+}
+
+func (r *pekerjaanRepository) CountPurgeable(cutoff time.Time, scope *model.DataScope) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := purgeableFilter(cutoff)
+	if err := r.applyScope(ctx, filter, scope); err != nil {
+		return 0, err
+	}
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// CountHeldExpired menghitung pekerjaan yang sudah melewati masa simpan
+// tetapi tidak dihapus karena legal hold
+func (r *pekerjaanRepository) CountHeldExpired(cutoff time.Time, scope *model.DataScope) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := expiredFilter(cutoff)
+	filter["legal_hold"] = true
+	if err := r.applyScope(ctx, filter, scope); err != nil {
+		return 0, err
+	}
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// Purge menghapus permanen satu pekerjaan jika masih memenuhi syarat
+// auto-purge, sehingga item yang baru saja direstore atau diberi legal hold
+// tidak ikut terhapus. Mengembalikan false jika sudah tidak memenuhi syarat.
+func (r *pekerjaanRepository) Purge(id primitive.ObjectID, cutoff time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := purgeableFilter(cutoff)
+	filter["_id"] = id
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// EnsureIndexes membuat index untuk daftar pekerjaan per alumni dan trash
+func (r *pekerjaanRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "alumni_id", Value: 1}, {Key: "is_deleted", Value: 1}},
+			Options: options.Index().SetName("idx_alumni_deleted"),
+		},
+		{
+			// Auto-purge trash mencari item berdasarkan waktu dihapus
+			Keys:    bson.D{{Key: "is_deleted", Value: 1}, {Key: "deleted_at", Value: 1}},
+			Options: options.Index().SetName("idx_deleted_at"),
+		},
+	})
+	return err
+}
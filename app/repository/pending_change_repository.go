@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"alumni-crud-api/app/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PendingChangeRepository interface {
+	Submit(change *model.PendingChange) (*model.PendingChange, error)
+	GetByID(id string) (*model.PendingChange, error)
+	List(filter model.PendingChangeFilter, limit, offset int) ([]model.PendingChange, error)
+	Count(filter model.PendingChangeFilter) (int, error)
+	Claim(id string, reviewerID primitive.ObjectID) (*model.PendingChange, error)
+	Release(id string) error
+	Resolve(id, status string, reviewerID primitive.ObjectID, reason string) (*model.PendingChange, error)
+	EnsureIndexes() error
+}
+
+type pendingChangeRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPendingChangeRepository(db *mongo.Database) PendingChangeRepository {
+	return &pendingChangeRepository{
+		collection: db.Collection("pending_changes"),
+	}
+}
+
+// Submit menyimpan perubahan baru. Setiap data hanya punya satu perubahan
+// pending; pengajuan berikutnya menggantikan isi perubahan sebelumnya.
+func (r *pendingChangeRepository) Submit(change *model.PendingChange) (*model.PendingChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"entity_type": change.EntityType,
+		"entity_id":   change.EntityID,
+		"status":      model.ApprovalPending,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"action":                change.Action,
+			"alumni_id":             change.AlumniID,
+			"jurusan":               change.Jurusan,
+			"changes":               change.Changes,
+			"submitted_by":          change.SubmittedBy,
+			"submitted_by_username": change.SubmittedByUsername,
+			"updated_at":            now,
+		},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var saved model.PendingChange
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func (r *pendingChangeRepository) GetByID(id string) (*model.PendingChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	var change model.PendingChange
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&change); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("perubahan tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &change, nil
+}
+
+func (r *pendingChangeRepository) buildFilter(f model.PendingChangeFilter) bson.M {
+	filter := jurusanScopeFilter(f.Scope)
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	if f.EntityType != "" {
+		filter["entity_type"] = f.EntityType
+	}
+	if f.SubmittedBy != nil {
+		filter["submitted_by"] = *f.SubmittedBy
+	}
+	return filter
+}
+
+func (r *pendingChangeRepository) List(f model.PendingChangeFilter, limit, offset int) ([]model.PendingChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Antrian diproses dari pengajuan paling lama
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	var changes []model.PendingChange
+	cursor, err := r.collection.Find(ctx, r.buildFilter(f), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *pendingChangeRepository) Count(f model.PendingChangeFilter) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, r.buildFilter(f))
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// Claim mengambil perubahan pending untuk diterapkan (pending -> approving)
+// secara atomik, sehingga dua admin tidak bisa menerapkan perubahan yang sama.
+func (r *pendingChangeRepository) Claim(id string, reviewerID primitive.ObjectID) (*model.PendingChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	update := bson.M{"$set": bson.M{
+		"status":      model.ChangeStatusApproving,
+		"reviewed_by": reviewerID,
+		"updated_at":  time.Now(),
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	filter := bson.M{"_id": objID, "status": model.ApprovalPending}
+	var change model.PendingChange
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&change); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("perubahan tidak ditemukan atau sudah diproses")
+		}
+		return nil, err
+	}
+	return &change, nil
+}
+
+// Release mengembalikan perubahan yang gagal diterapkan ke pending. Bila
+// pengaju sudah mengirim perubahan baru selama proses berjalan, perubahan
+// lama ditutup sebagai rejected karena sudah digantikan.
+func (r *pendingChangeRepository) Release(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	filter := bson.M{"_id": objID, "status": model.ChangeStatusApproving}
+	_, err = r.collection.UpdateOne(ctx, filter, bson.M{
+		"$set":   bson.M{"status": model.ApprovalPending, "updated_at": time.Now()},
+		"$unset": bson.M{"reviewed_by": ""},
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		_, err = r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+			"status":        model.ApprovalRejected,
+			"reject_reason": "digantikan pengajuan yang lebih baru",
+			"updated_at":    time.Now(),
+		}})
+	}
+	return err
+}
+
+// Resolve menandai perubahan sebagai approved/rejected. Approve hanya berlaku
+// untuk perubahan yang sudah di-Claim, reject hanya untuk perubahan pending.
+// Perubahan yang sudah diproses admin lain dianggap tidak ada.
+func (r *pendingChangeRepository) Resolve(id, status string, reviewerID primitive.ObjectID, reason string) (*model.PendingChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID tidak valid: %v", err)
+	}
+
+	now := time.Now()
+	set := bson.M{
+		"status":      status,
+		"reviewed_by": reviewerID,
+		"reviewed_at": now,
+		"updated_at":  now,
+	}
+	if reason != "" {
+		set["reject_reason"] = reason
+	}
+
+	from := model.ApprovalPending
+	if status == model.ApprovalApproved {
+		from = model.ChangeStatusApproving
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	filter := bson.M{"_id": objID, "status": from}
+	var change model.PendingChange
+	if err := r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": set}, opts).Decode(&change); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("perubahan tidak ditemukan atau sudah diproses")
+		}
+		return nil, err
+	}
+	return &change, nil
+}
+
+func (r *pendingChangeRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// Satu perubahan pending per data
+			Keys: bson.D{{Key: "entity_type", Value: 1}, {Key: "entity_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": model.ApprovalPending}).
+				SetName("uniq_pending_entity"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("idx_status_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "submitted_by", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_submitted_by"),
+		},
+	})
+	return err
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"alumni-crud-api/app/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RecordVersionRepository interface {
+	Append(v *model.RecordVersion) error
+	GetLatest(entityType, entityID string) (*model.RecordVersion, error)
+	GetByVersion(entityType, entityID string, version int) (*model.RecordVersion, error)
+	GetPrevious(entityType, entityID string, version int) (*model.RecordVersion, error)
+	GetAsOf(entityType, entityID string, at time.Time) (*model.RecordVersion, error)
+	List(entityType, entityID string, limit, offset int) ([]model.RecordVersion, error)
+	Count(entityType, entityID string) (int, error)
+	EnsureIndexes() error
+}
+
+type recordVersionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRecordVersionRepository(db *mongo.Database) RecordVersionRepository {
+	return &recordVersionRepository{
+		collection: db.Collection("record_versions"),
+	}
+}
+
+// Append menyimpan satu versi. Nomor versi mengikuti field version dokumen
+// (nilai ETag) yang diisi pemanggil; versi yang sudah tercatat ditolak.
+// Snapshot tanpa version (data sebelum backfill_version) diberi nomor setelah
+// versi terakhir; jika dua perubahan bersamaan mendapat nomor yang sama,
+// unique index menolak salah satunya dan nomor dihitung ulang.
+func (r *recordVersionRepository) Append(v *model.RecordVersion) error {
+	if v.CreatedAt.IsZero() {
+		v.CreatedAt = time.Now()
+	}
+	if v.Version > 0 {
+		return r.insert(v)
+	}
+
+	for attempt := 0; attempt < 3; attempt++ {
+		latest, err := r.GetLatest(v.EntityType, v.EntityID)
+		if err != nil && err.Error() != "versi tidak ditemukan" {
+			return err
+		}
+		v.Version = 1
+		if latest != nil {
+			v.Version = latest.Version + 1
+		}
+
+		err = r.insert(v)
+		if err != nil && err.Error() == "versi sudah tercatat" {
+			continue
+		}
+		return err
+	}
+	return fmt.Errorf("gagal menyimpan versi %s %s: konflik nomor versi", v.EntityType, v.EntityID)
+}
+
+func (r *recordVersionRepository) insert(v *model.RecordVersion) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.InsertOne(ctx, v)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("versi sudah tercatat")
+	}
+	if err != nil {
+		return err
+	}
+	v.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *recordVersionRepository) findOne(filter bson.M, opts *options.FindOneOptions) (*model.RecordVersion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var v model.RecordVersion
+	if err := r.collection.FindOne(ctx, filter, opts).Decode(&v); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("versi tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *recordVersionRepository) GetLatest(entityType, entityID string) (*model.RecordVersion, error) {
+	filter := bson.M{"entity_type": entityType, "entity_id": entityID}
+	return r.findOne(filter, options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}))
+}
+
+func (r *recordVersionRepository) GetByVersion(entityType, entityID string, version int) (*model.RecordVersion, error) {
+	filter := bson.M{"entity_type": entityType, "entity_id": entityID, "version": version}
+	return r.findOne(filter, options.FindOne())
+}
+
+// GetPrevious mengambil versi tercatat terakhir sebelum version. Nomor versi
+// bisa melompat jika ada perubahan yang riwayatnya gagal disimpan.
+func (r *recordVersionRepository) GetPrevious(entityType, entityID string, version int) (*model.RecordVersion, error) {
+	filter := bson.M{"entity_type": entityType, "entity_id": entityID, "version": bson.M{"$lt": version}}
+	return r.findOne(filter, options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}))
+}
+
+// GetAsOf mengambil versi yang berlaku pada waktu tertentu
+func (r *recordVersionRepository) GetAsOf(entityType, entityID string, at time.Time) (*model.RecordVersion, error) {
+	filter := bson.M{"entity_type": entityType, "entity_id": entityID, "created_at": bson.M{"$lte": at}}
+	return r.findOne(filter, options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}))
+}
+
+func (r *recordVersionRepository) List(entityType, entityID string, limit, offset int) ([]model.RecordVersion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "version", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	var versions []model.RecordVersion
+	cursor, err := r.collection.Find(ctx, bson.M{"entity_type": entityType, "entity_id": entityID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (r *recordVersionRepository) Count(entityType, entityID string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"entity_type": entityType, "entity_id": entityID})
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+func (r *recordVersionRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "entity_type", Value: 1}, {Key: "entity_id", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("uniq_entity_version"),
+	})
+	return err
+}
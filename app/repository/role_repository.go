@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"alumni-crud-api/app/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RoleRepository interface {
+	GetAll() ([]model.Role, error)
+	GetByName(name string) (*model.Role, error)
+	Create(role *model.Role) (*model.Role, error)
+	Update(name string, req *model.UpdateRoleRequest) (*model.Role, error)
+	Delete(name string) error
+	SeedDefaults() error
+	EnsureIndexes() error
+}
+
+type roleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRoleRepository(db *mongo.Database) RoleRepository {
+	return &roleRepository{
+		collection: db.Collection("roles"),
+	}
+}
+
+func (r *roleRepository) GetAll() ([]model.Role, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	roles := []model.Role{}
+	if err = cursor.All(ctx, &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *roleRepository) GetByName(name string) (*model.Role, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var role model.Role
+	if err := r.collection.FindOne(ctx, bson.M{"name": name}).Decode(&role); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("role tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) Create(role *model.Role) (*model.Role, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	role.CreatedAt = now
+	role.UpdatedAt = now
+
+	result, err := r.collection.InsertOne(ctx, role)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("role sudah ada")
+		}
+		return nil, err
+	}
+	role.ID = result.InsertedID.(primitive.ObjectID)
+	return role, nil
+}
+
+func (r *roleRepository) Update(name string, req *model.UpdateRoleRequest) (*model.Role, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"description": req.Description,
+			"permissions": req.Permissions,
+			"updated_at":  time.Now(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var role model.Role
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"name": name}, update, opts).Decode(&role); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("role tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) Delete(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"name": name, "is_system": bson.M{"$ne": true}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("role tidak ditemukan")
+	}
+	return nil
+}
+
+// SeedDefaults membuat role bawaan jika belum ada. Permission role admin
+// selalu disamakan dengan model.AllPermissions agar permission baru langsung berlaku.
+func (r *roleRepository) SeedDefaults() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	opts := options.Update().SetUpsert(true)
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"name": model.RoleAdmin}, bson.M{
+		"$set": bson.M{
+			"permissions": model.AllPermissions,
+			"is_system":   true,
+			"updated_at":  now,
+		},
+		"$setOnInsert": bson.M{
+			"description": "Akses penuh ke seluruh data dan pengaturan",
+			"created_at":  now,
+		},
+	}, opts)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.UpdateOne(ctx, bson.M{"name": model.RoleUser}, bson.M{
+		"$set": bson.M{"is_system": true},
+		"$setOnInsert": bson.M{
+			"description": "Alumni; hanya dapat mengelola data miliknya sendiri",
+			"permissions": []string{},
+			"created_at":  now,
+			"updated_at":  now,
+		},
+	}, opts)
+	return err
+}
+
+func (r *roleRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("uniq_name"),
+	})
+	return err
+}
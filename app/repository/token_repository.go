@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"alumni-crud-api/app/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(token *model.RefreshToken) error
+	GetRefreshTokenByHash(hash string) (*model.RefreshToken, error)
+	MarkRefreshTokenUsed(id primitive.ObjectID) (bool, error)
+	RevokeFamily(familyID string, reason string) error
+	RevokeAllForUser(userID primitive.ObjectID, reason string) error
+	RevokeAccessToken(jti string, userID primitive.ObjectID, expiresAt time.Time, reason string) error
+	IsAccessTokenRevoked(jti string) (bool, error)
+	EnsureIndexes() error
+}
+
+type tokenRepository struct {
+	refreshCollection *mongo.Collection
+	revokedCollection *mongo.Collection
+}
+
+func NewTokenRepository(db *mongo.Database) TokenRepository {
+	return &tokenRepository{
+		refreshCollection: db.Collection("refresh_tokens"),
+		revokedCollection: db.Collection("revoked_tokens"),
+	}
+}
+
+func (r *tokenRepository) CreateRefreshToken(token *model.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	token.CreatedAt = time.Now()
+	result, err := r.refreshCollection.InsertOne(ctx, token)
+	if err != nil {
+		return err
+	}
+	token.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *tokenRepository) GetRefreshTokenByHash(hash string) (*model.RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var token model.RefreshToken
+	if err := r.refreshCollection.FindOne(ctx, bson.M{"token_hash": hash}).Decode(&token); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("refresh token tidak ditemukan")
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed menandai token sudah dirotasi secara atomik.
+// Mengembalikan false jika token sudah pernah dipakai atau dicabut (indikasi reuse).
+func (r *tokenRepository) MarkRefreshTokenUsed(id primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":        id,
+		"used_at":    bson.M{"$exists": false},
+		"revoked_at": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"used_at": time.Now()}}
+
+	result, err := r.refreshCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *tokenRepository) RevokeFamily(familyID string, reason string) error {
+	return r.revokeRefreshTokens(bson.M{"family_id": familyID}, reason)
+}
+
+func (r *tokenRepository) RevokeAllForUser(userID primitive.ObjectID, reason string) error {
+	return r.revokeRefreshTokens(bson.M{"user_id": userID}, reason)
+}
+
+// revokeRefreshTokens mencabut semua refresh token yang cocok dengan filter,
+// sekaligus memasukkan access token pasangannya yang belum expired ke daftar revoked.
+func (r *tokenRepository) revokeRefreshTokens(filter bson.M, reason string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	accessFilter := bson.M{"access_expires_at": bson.M{"$gt": now}}
+	for k, v := range filter {
+		accessFilter[k] = v
+	}
+	cursor, err := r.refreshCollection.Find(ctx, accessFilter)
+	if err != nil {
+		return err
+	}
+	var tokens []model.RefreshToken
+	if err = cursor.All(ctx, &tokens); err != nil {
+		return err
+	}
+	for _, t := range tokens {
+		if err := r.insertRevoked(ctx, t.AccessJTI, t.UserID, t.AccessExpiresAt, reason); err != nil {
+			return err
+		}
+	}
+
+	revokeFilter := bson.M{"revoked_at": bson.M{"$exists": false}}
+	for k, v := range filter {
+		revokeFilter[k] = v
+	}
+	update := bson.M{"$set": bson.M{"revoked_at": now, "revoke_reason": reason}}
+	_, err = r.refreshCollection.UpdateMany(ctx, revokeFilter, update)
+	return err
+}
+
+func (r *tokenRepository) RevokeAccessToken(jti string, userID primitive.ObjectID, expiresAt time.Time, reason string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return r.insertRevoked(ctx, jti, userID, expiresAt, reason)
+}
+
+func (r *tokenRepository) insertRevoked(ctx context.Context, jti string, userID primitive.ObjectID, expiresAt time.Time, reason string) error {
+	if jti == "" {
+		return nil
+	}
+	revoked := model.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		Reason:    reason,
+		ExpiresAt: expiresAt,
+		RevokedAt: time.Now(),
+	}
+	// Upsert agar pencabutan ganda tidak error
+	opts := options.Update().SetUpsert(true)
+	_, err := r.revokedCollection.UpdateOne(ctx, bson.M{"jti": jti}, bson.M{"$setOnInsert": revoked}, opts)
+	return err
+}
+
+func (r *tokenRepository) IsAccessTokenRevoked(jti string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := r.revokedCollection.CountDocuments(ctx, bson.M{"jti": jti}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// EnsureIndexes membuat index pencarian token dan TTL index agar
+// dokumen yang sudah expired dibersihkan otomatis oleh MongoDB.
+func (r *tokenRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.refreshCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_token_hash"),
+		},
+		{
+			Keys:    bson.D{{Key: "family_id", Value: 1}},
+			Options: options.Index().SetName("idx_family_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("idx_user_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("ttl_expires_at"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.revokedCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "jti", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_jti"),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("ttl_expires_at"),
+		},
+	})
+	return err
+}
@@ -3,10 +3,17 @@ package service
 import (
 	"alumni-crud-api/app/model"
 	"alumni-crud-api/app/repository"
+	"alumni-crud-api/config"
 	"alumni-crud-api/helper"
+	"crypto/rand"
 	"errors"
+	"fmt"
 	"log"
+	"math/big"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -14,35 +21,83 @@ import (
 )
 
 type AlumniService interface {
-	GetAllAlumni() ([]model.Alumni, error)
-	GetAlumniByID(id string) (*model.Alumni, error)
-	CreateAlumni(req *model.CreateAlumniRequest, userID string) (*model.Alumni, error)
-	UpdateAlumni(id string, req *model.UpdateAlumniRequest) (*model.Alumni, error)
-	DeleteAlumni(id string) error
-	GetAlumniWithPagination(search, sortBy, order string, page, limit int) (*model.AlumniResponse, error)
+	GetAllAlumni(scope *model.DataScope) ([]model.Alumni, error)
+	GetAlumniByID(id string, scope *model.DataScope) (*model.Alumni, error)
+	CreateAlumni(req *model.CreateAlumniRequest, scope *model.DataScope) (*model.Alumni, error)
+	UpdateAlumni(id string, req *model.UpdateAlumniRequest, scope *model.DataScope, version int) (*model.Alumni, error)
+	PatchAlumni(id string, patch []byte, contentType string, scope *model.DataScope, version int) (*model.Alumni, error)
+	DeleteAlumni(id string, actor *model.Actor, version int) (*model.AlumniCascade, error)
+	ListTrash(search string, page, limit int, scope *model.DataScope) (*model.AlumniResponse, error)
+	RestoreAlumni(id string, actor *model.Actor, version int) (*model.Alumni, *model.AlumniCascade, error)
+	HardDeleteAlumni(id string, scope *model.DataScope, version int) (*model.AlumniCascade, error)
+	GetAlumniWithPagination(search, sortBy, order string, page, limit int, scope *model.DataScope) (*model.AlumniResponse, error)
+	LinkUser(id string, userID string, scope *model.DataScope, version int) (*model.Alumni, error)
+	UnlinkUser(id string, scope *model.DataScope, version int) (*model.Alumni, error)
+	RequestClaim(userID string, nim string) (*model.ClaimAlumniResponse, error)
+	VerifyClaim(userID string, req *model.VerifyClaimAlumniRequest) (*model.Alumni, error)
+	GetMyAlumni(userID string) (*model.Alumni, error)
+	UpdateMyAlumni(actor *model.Actor, req *model.UpdateMyAlumniRequest, version int) (*model.Alumni, *model.PendingChange, error)
 
 	HandleGetAllAlumni(c *fiber.Ctx) error
 	HandleGetAlumniByID(c *fiber.Ctx) error
 	HandleCreateAlumni(c *fiber.Ctx) error
 	HandleUpdateAlumni(c *fiber.Ctx) error
+	HandlePatchAlumni(c *fiber.Ctx) error
 	HandleDeleteAlumni(c *fiber.Ctx) error
+	HandleListTrash(c *fiber.Ctx) error
+	HandleRestoreAlumni(c *fiber.Ctx) error
+	HandleHardDeleteAlumni(c *fiber.Ctx) error
+	HandleLinkUser(c *fiber.Ctx) error
+	HandleUnlinkUser(c *fiber.Ctx) error
+	HandleRequestClaim(c *fiber.Ctx) error
+	HandleVerifyClaim(c *fiber.Ctx) error
+	HandleGetMyAlumni(c *fiber.Ctx) error
+	HandleUpdateMyAlumni(c *fiber.Ctx) error
 }
 
 type alumniService struct {
-	alumniRepo repository.AlumniRepository
+	alumniRepo    repository.AlumniRepository
+	pekerjaanRepo repository.PekerjaanRepository // Cascade trash/restore/hard delete
+	fileRepo      repository.FileRepository
+	authRepo      repository.AuthRepository
+	claimRepo     repository.AlumniClaimRepository
+	moderation    ModerationService
+	audit         AuditService
+	mailer        helper.Mailer
+	cfg           *config.Config
 }
 
-func NewAlumniService(alumniRepo repository.AlumniRepository) AlumniService {
+func NewAlumniService(
+	alumniRepo repository.AlumniRepository,
+	pekerjaanRepo repository.PekerjaanRepository,
+	fileRepo repository.FileRepository,
+	authRepo repository.AuthRepository,
+	claimRepo repository.AlumniClaimRepository,
+	moderation ModerationService,
+	audit AuditService,
+	mailer helper.Mailer,
+	cfg *config.Config,
+) AlumniService {
 	return &alumniService{
-		alumniRepo: alumniRepo,
+		alumniRepo:    alumniRepo,
+		pekerjaanRepo: pekerjaanRepo,
+		fileRepo:      fileRepo,
+		authRepo:      authRepo,
+		claimRepo:     claimRepo,
+		moderation:    moderation,
+		audit:         audit,
+		mailer:        mailer,
+		cfg:           cfg,
 	}
 }
 
-func (s *alumniService) GetAllAlumni() ([]model.Alumni, error) {
-	return s.alumniRepo.GetAll()
+func (s *alumniService) GetAllAlumni(scope *model.DataScope) ([]model.Alumni, error) {
+	return s.alumniRepo.GetAll(scope)
 }
 
-func (s *alumniService) GetAlumniByID(id string) (*model.Alumni, error) {
+// GetAlumniByID mengembalikan "alumni tidak ditemukan" juga untuk alumni di luar
+// scope, supaya admin jurusan lain tidak bisa menebak keberadaan data.
+func (s *alumniService) GetAlumniByID(id string, scope *model.DataScope) (*model.Alumni, error) {
 	alumni, err := s.alumniRepo.GetByID(id)
 	if err != nil {
 		if err.Error() == "alumni tidak ditemukan" || err == mongo.ErrNoDocuments {
@@ -51,61 +106,263 @@ func (s *alumniService) GetAlumniByID(id string) (*model.Alumni, error) {
 		log.Printf("[ERROR] GetAlumniByID service: %v", err)
 		return nil, errors.New("gagal mengambil data alumni")
 	}
+	if !scope.Allows(alumni.Jurusan) {
+		return nil, errors.New("alumni tidak ditemukan")
+	}
 	return alumni, nil
 }
 
-func (s *alumniService) CreateAlumni(req *model.CreateAlumniRequest, userID string) (*model.Alumni, error) {
+// CreateAlumni membuat data alumni tanpa akun terhubung. Akun dihubungkan
+// lewat LinkUser (admin) atau klaim NIM oleh alumni sendiri.
+func (s *alumniService) CreateAlumni(req *model.CreateAlumniRequest, scope *model.DataScope) (*model.Alumni, error) {
 	if err := helper.ValidateCreateAlumni(req.NIM, req.Nama, req.Jurusan, req.Email, req.Angkatan, req.TahunLulus); err != nil {
 		return nil, err
 	}
+	if !scope.Allows(req.Jurusan) {
+		return nil, errors.New("jurusan di luar scope Anda")
+	}
+
+	return s.alumniRepo.Create(req, primitive.NilObjectID)
+}
 
-	userObjID, err := primitive.ObjectIDFromHex(userID)
+// UpdateAlumni menolak perubahan jika version (dari If-Match) bukan versi
+// terbaru; helper.AnyVersion berarti tanpa pengecekan dari client.
+func (s *alumniService) UpdateAlumni(id string, req *model.UpdateAlumniRequest, scope *model.DataScope, version int) (*model.Alumni, error) {
+	// Check if alumni exists (dan berada dalam scope)
+	current, err := s.GetAlumniByID(id, scope)
 	if err != nil {
-		return nil, errors.New("UserID tidak valid")
+		return nil, err // "alumni tidak ditemukan" atau error lain
+	}
+	if !helper.VersionMatches(current.Version, version) {
+		return nil, errors.New("versi data tidak cocok")
 	}
 
-	// Cek apakah user ini sudah punya data alumni
-	_, err = s.alumniRepo.GetByUserID(userID)
-	if err == nil {
-		return nil, errors.New("user ini sudah terdaftar sebagai alumni")
+	if err := helper.ValidateUpdateAlumni(req.Nama, req.Jurusan, req.Email, req.Angkatan, req.TahunLulus); err != nil {
+		return nil, err
+	}
+	// Admin jurusan tidak boleh memindahkan alumni keluar dari scope-nya
+	if !scope.Allows(req.Jurusan) {
+		return nil, errors.New("jurusan di luar scope Anda")
 	}
 
-	return s.alumniRepo.Create(req, userObjID)
+	return s.alumniRepo.Update(id, req, current.Version)
 }
 
-func (s *alumniService) UpdateAlumni(id string, req *model.UpdateAlumniRequest) (*model.Alumni, error) {
-	// Check if alumni exists
-	_, err := s.alumniRepo.GetByID(id)
+// PatchAlumni menerapkan merge patch / JSON Patch ke field yang bisa diubah
+// lewat PUT. Validasi dilakukan pada hasil gabungan dan hanya field yang
+// berubah yang ditulis.
+func (s *alumniService) PatchAlumni(id string, patch []byte, contentType string, scope *model.DataScope, version int) (*model.Alumni, error) {
+	current, err := s.GetAlumniByID(id, scope)
 	if err != nil {
-		return nil, err // "alumni tidak ditemukan" atau error lain
+		return nil, err
+	}
+	if !helper.VersionMatches(current.Version, version) {
+		return nil, errors.New("versi data tidak cocok")
 	}
 
+	base := alumniUpdateFrom(current)
+	var req model.UpdateAlumniRequest
+	if err := patchRequest(base, patch, contentType, &req); err != nil {
+		return nil, err
+	}
 	if err := helper.ValidateUpdateAlumni(req.Nama, req.Jurusan, req.Email, req.Angkatan, req.TahunLulus); err != nil {
 		return nil, err
 	}
+	if !scope.Allows(req.Jurusan) {
+		return nil, errors.New("jurusan di luar scope Anda")
+	}
+
+	fields, err := changedFields(base, &req)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return current, nil
+	}
+	return s.alumniRepo.Patch(id, fields, current.Version)
+}
+
+// DeleteAlumni memindahkan alumni ke trash. Pekerjaan aktifnya ikut masuk
+// trash dan file-nya disembunyikan; semuanya kembali saat alumni direstore.
+func (s *alumniService) DeleteAlumni(id string, actor *model.Actor, version int) (*model.AlumniCascade, error) {
+	deleterObjID, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return nil, errors.New("user ID tidak valid")
+	}
+
+	// Check if alumni exists (dan berada dalam scope)
+	current, err := s.GetAlumniByID(id, actor.Scope)
+	if err != nil {
+		return nil, err // "alumni tidak ditemukan" atau error lain
+	}
+	if !helper.VersionMatches(current.Version, version) {
+		return nil, errors.New("versi data tidak cocok")
+	}
+
+	if _, err := s.alumniRepo.SoftDelete(id, deleterObjID, current.Version); err != nil {
+		return nil, err
+	}
+
+	// Alumni sudah di trash; jika cascade gagal, sisanya tetap bisa
+	// dibereskan dengan restore atau hard delete
+	cascade := &model.AlumniCascade{}
+	if cascade.Pekerjaan, err = s.pekerjaanRepo.SoftDeleteByAlumni(current.ID, deleterObjID); err != nil {
+		log.Printf("[ERROR] Gagal memindahkan pekerjaan alumni %s ke trash: %v", id, err)
+		return cascade, errors.New("gagal memindahkan pekerjaan alumni ke trash")
+	}
+	if cascade.Files, err = s.fileRepo.SetHiddenByAlumni(current.ID, true); err != nil {
+		log.Printf("[ERROR] Gagal menyembunyikan file alumni %s: %v", id, err)
+		return cascade, errors.New("gagal menyembunyikan file alumni")
+	}
+	return cascade, nil
+}
+
+func (s *alumniService) ListTrash(search string, page, limit int, scope *model.DataScope) (*model.AlumniResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	alumni, err := s.alumniRepo.ListTrash(search, limit, offset, scope)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.alumniRepo.CountTrash(search, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := (total + limit - 1) / limit
+	if total == 0 {
+		pages = 0
+	}
+	if alumni == nil {
+		alumni = []model.Alumni{}
+	}
+
+	return &model.AlumniResponse{
+		Data: alumni,
+		Meta: model.MetaInfo{
+			Page:   page,
+			Limit:  limit,
+			Total:  total,
+			Pages:  pages,
+			SortBy: "deleted_at",
+			Order:  "desc",
+			Search: search,
+		},
+	}, nil
+}
 
-	return s.alumniRepo.Update(id, req)
+// getTrashed mengambil alumni di trash; alumni di luar scope dianggap tidak ada
+func (s *alumniService) getTrashed(id string, scope *model.DataScope) (*model.Alumni, error) {
+	alumni, err := s.alumniRepo.GetByIDWithDeleted(id)
+	if err != nil {
+		if err.Error() == "alumni tidak ditemukan" {
+			return nil, err
+		}
+		log.Printf("[ERROR] getTrashed alumni: %v", err)
+		return nil, errors.New("gagal mengambil data alumni")
+	}
+	if !scope.Allows(alumni.Jurusan) {
+		return nil, errors.New("alumni tidak ditemukan")
+	}
+	if !alumni.IsDeleted {
+		return nil, errors.New("alumni tidak ditemukan di trash")
+	}
+	return alumni, nil
+}
+
+// RestoreAlumni mengembalikan alumni dari trash beserta pekerjaan yang ikut
+// terhapus bersamanya dan file-nya. Pekerjaan yang sudah di trash sebelum
+// alumni dihapus tetap di trash.
+func (s *alumniService) RestoreAlumni(id string, actor *model.Actor, version int) (*model.Alumni, *model.AlumniCascade, error) {
+	restorerObjID, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return nil, nil, errors.New("user ID tidak valid")
+	}
+
+	current, err := s.getTrashed(id, actor.Scope)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !helper.VersionMatches(current.Version, version) {
+		return nil, nil, errors.New("versi data tidak cocok")
+	}
+
+	alumni, err := s.alumniRepo.Restore(id, current.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cascade := &model.AlumniCascade{}
+	if cascade.Pekerjaan, err = s.pekerjaanRepo.RestoreByAlumni(current.ID, restorerObjID); err != nil {
+		log.Printf("[ERROR] Gagal restore pekerjaan alumni %s: %v", id, err)
+		return alumni, cascade, errors.New("gagal restore pekerjaan alumni")
+	}
+	if cascade.Files, err = s.fileRepo.SetHiddenByAlumni(current.ID, false); err != nil {
+		log.Printf("[ERROR] Gagal menampilkan kembali file alumni %s: %v", id, err)
+		return alumni, cascade, errors.New("gagal menampilkan kembali file alumni")
+	}
+	return alumni, cascade, nil
 }
 
-func (s *alumniService) DeleteAlumni(id string) error {
-	// Check if alumni exists
-	_, err := s.alumniRepo.GetByID(id)
+// HardDeleteAlumni menghapus permanen alumni yang sudah di trash beserta semua
+// pekerjaan, metadata file dan file fisiknya. Data terkait dihapus lebih dulu
+// agar hard delete yang gagal di tengah jalan bisa diulang tanpa meninggalkan
+// data yatim.
+func (s *alumniService) HardDeleteAlumni(id string, scope *model.DataScope, version int) (*model.AlumniCascade, error) {
+	current, err := s.getTrashed(id, scope)
 	if err != nil {
-		return err // "alumni tidak ditemukan" atau error lain
+		return nil, err
+	}
+	if !helper.VersionMatches(current.Version, version) {
+		return nil, errors.New("versi data tidak cocok")
+	}
+
+	// Pekerjaan dalam legal hold tidak boleh ikut terhapus
+	held, err := s.pekerjaanRepo.CountLegalHoldByAlumni(current.ID)
+	if err != nil {
+		return nil, err
+	}
+	if held > 0 {
+		return nil, fmt.Errorf("alumni memiliki %d pekerjaan dalam legal hold", held)
+	}
+
+	cascade := &model.AlumniCascade{}
+	if cascade.Pekerjaan, err = s.pekerjaanRepo.DeleteByAlumni(current.ID); err != nil {
+		log.Printf("[ERROR] Gagal menghapus pekerjaan alumni %s: %v", id, err)
+		return nil, errors.New("gagal menghapus pekerjaan alumni")
+	}
+	if cascade.Files, err = s.fileRepo.DeleteByAlumni(current.ID); err != nil {
+		log.Printf("[ERROR] Gagal menghapus metadata file alumni %s: %v", id, err)
+		return cascade, errors.New("gagal menghapus file alumni")
+	}
+	if err := s.alumniRepo.HardDelete(id); err != nil {
+		return cascade, err
 	}
 
-	return s.alumniRepo.Delete(id)
+	// File fisik dihapus terakhir; kegagalan hanya dicatat seperti di DeleteFile
+	for _, f := range cascade.Files {
+		if err := os.Remove(f.FilePath); err != nil {
+			log.Printf("Peringatan: Gagal menghapus file fisik '%s' dari server: %v", f.FilePath, err)
+		}
+	}
+	return cascade, nil
 }
 
-func (s *alumniService) GetAlumniWithPagination(search, sortBy, order string, page, limit int) (*model.AlumniResponse, error) {
+func (s *alumniService) GetAlumniWithPagination(search, sortBy, order string, page, limit int, scope *model.DataScope) (*model.AlumniResponse, error) {
 	offset := (page - 1) * limit
 
-	alumni, err := s.alumniRepo.GetAllWithPagination(search, sortBy, order, limit, offset)
+	alumni, err := s.alumniRepo.GetAllWithPagination(search, sortBy, order, limit, offset, scope)
 	if err != nil {
 		return nil, err
 	}
 
-	total, err := s.alumniRepo.CountWithSearch(search)
+	total, err := s.alumniRepo.CountWithSearch(search, scope)
 	if err != nil {
 		return nil, err
 	}
@@ -131,6 +388,272 @@ func (s *alumniService) GetAlumniWithPagination(search, sortBy, order string, pa
 	return response, nil
 }
 
+// linkedAccount mengembalikan akun alumni yang terhubung ke data ini, atau nil.
+// Data lama menyimpan ID admin pembuatnya di user_id; link ke akun selain
+// role "user" seperti itu dianggap belum terhubung.
+func (s *alumniService) linkedAccount(alumni *model.Alumni) (*model.User, error) {
+	if alumni.UserID.IsZero() {
+		return nil, nil
+	}
+	user, err := s.authRepo.GetUserByID(alumni.UserID.Hex())
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != model.RoleUser {
+		return nil, nil
+	}
+	return user, nil
+}
+
+// alumniAccount memastikan user adalah akun alumni (role "user") yang belum
+// terhubung ke data alumni mana pun
+func (s *alumniService) alumniAccount(userID string) (*model.User, error) {
+	if _, err := primitive.ObjectIDFromHex(userID); err != nil {
+		return nil, errors.New("user tidak ditemukan")
+	}
+	user, err := s.authRepo.GetUserByID(userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("user tidak ditemukan")
+		}
+		return nil, err
+	}
+	if user.Role != model.RoleUser {
+		return nil, errors.New("hanya akun dengan role user yang dapat dihubungkan ke data alumni")
+	}
+	if existing, err := s.alumniRepo.GetByUserID(userID); err == nil {
+		return nil, fmt.Errorf("user sudah terhubung ke alumni %s", existing.NIM)
+	}
+	return user, nil
+}
+
+// LinkUser dipakai admin untuk menghubungkan akun user ke data alumni
+func (s *alumniService) LinkUser(id string, userID string, scope *model.DataScope, version int) (*model.Alumni, error) {
+	alumni, err := s.GetAlumniByID(id, scope)
+	if err != nil {
+		return nil, err
+	}
+	if !helper.VersionMatches(alumni.Version, version) {
+		return nil, errors.New("versi data tidak cocok")
+	}
+	user, err := s.alumniAccount(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.linkedAccount(alumni)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		return nil, errors.New("alumni sudah terhubung ke user lain, lepaskan dulu link-nya")
+	}
+
+	if err := s.claimRepo.InvalidateForAlumni(alumni.ID); err != nil {
+		log.Printf("[ERROR] AlumniService InvalidateForAlumni: %v", err)
+	}
+	return s.alumniRepo.SetUserID(id, user.ID, alumni.UserID, alumni.Version)
+}
+
+func (s *alumniService) UnlinkUser(id string, scope *model.DataScope, version int) (*model.Alumni, error) {
+	current, err := s.GetAlumniByID(id, scope)
+	if err != nil {
+		return nil, err
+	}
+	if !helper.VersionMatches(current.Version, version) {
+		return nil, errors.New("versi data tidak cocok")
+	}
+	return s.alumniRepo.SetUserID(id, primitive.NilObjectID, current.UserID, current.Version)
+}
+
+// RequestClaim mengirim kode verifikasi ke email yang tercatat di data alumni
+// dengan NIM tersebut. Hanya pemilik email itu yang bisa menyelesaikan klaim.
+func (s *alumniService) RequestClaim(userID string, nim string) (*model.ClaimAlumniResponse, error) {
+	nim = strings.TrimSpace(nim)
+	if nim == "" {
+		return nil, errors.New("NIM harus diisi")
+	}
+	user, err := s.alumniAccount(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	alumni, err := s.alumniRepo.GetByNIM(nim)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.linkedAccount(alumni)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		return nil, errors.New("data alumni ini sudah diklaim")
+	}
+	if alumni.Email == "" {
+		return nil, errors.New("data alumni tidak memiliki email untuk verifikasi, hubungi admin")
+	}
+
+	failures, err := s.checkClaimLimit(alumni.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return nil, err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	// Hanya kode terbaru milik user ini yang berlaku; kode user lain tidak diganggu
+	if err := s.claimRepo.InvalidateForUser(alumni.ID, user.ID); err != nil {
+		return nil, err
+	}
+	claim := &model.AlumniClaim{
+		AlumniID:  alumni.ID,
+		UserID:    user.ID,
+		CodeHash:  helper.HashToken(code),
+		Attempts:  failures,
+		ExpiresAt: time.Now().Add(s.cfg.AlumniClaimTTL),
+	}
+	if err := s.claimRepo.Create(claim); err != nil {
+		return nil, err
+	}
+
+	body := fmt.Sprintf("Halo %s,\n\n"+
+		"Akun %s meminta untuk dihubungkan dengan data alumni NIM %s.\n"+
+		"Kode verifikasi Anda (berlaku %s):\n\n%s\n\n"+
+		"Jika ini bukan Anda, abaikan email ini.\n",
+		alumni.Nama, user.Username, alumni.NIM, s.cfg.AlumniClaimTTL, code)
+	if err := s.mailer.Send(alumni.Email, "Verifikasi Data Alumni", body); err != nil {
+		log.Printf("[ERROR] AlumniService kirim kode klaim ke %s: %v", alumni.Email, err)
+		return nil, errors.New("gagal mengirim kode verifikasi")
+	}
+
+	return &model.ClaimAlumniResponse{
+		EmailMasked: maskEmail(alumni.Email),
+		ExpiresAt:   claim.ExpiresAt,
+	}, nil
+}
+
+// checkClaimLimit membatasi permintaan kode klaim dalam satu masa berlaku kode,
+// per user dan per alumni. Mengembalikan jumlah salah kode user ini yang
+// dibawa ke klaim baru.
+func (s *alumniService) checkClaimLimit(alumniID, userID primitive.ObjectID) (int, error) {
+	claims, err := s.claimRepo.ListSince(alumniID, time.Now().Add(-s.cfg.AlumniClaimTTL))
+	if err != nil {
+		return 0, err
+	}
+	requests, failures := 0, 0
+	for _, claim := range claims {
+		if claim.UserID == userID {
+			requests++
+			// Klaim baru sudah membawa jumlah salah klaim sebelumnya
+			if claim.Attempts > failures {
+				failures = claim.Attempts
+			}
+		}
+	}
+	if requests >= repository.MaxClaimRequestsPerUser ||
+		failures >= repository.MaxClaimAttempts ||
+		len(claims) >= repository.MaxClaimRequestsPerAlumni {
+		return 0, errors.New("terlalu banyak permintaan klaim, coba lagi nanti")
+	}
+	return failures, nil
+}
+
+func (s *alumniService) VerifyClaim(userID string, req *model.VerifyClaimAlumniRequest) (*model.Alumni, error) {
+	if strings.TrimSpace(req.NIM) == "" || strings.TrimSpace(req.Code) == "" {
+		return nil, errors.New("NIM dan kode verifikasi harus diisi")
+	}
+	user, err := s.alumniAccount(userID)
+	if err != nil {
+		return nil, err
+	}
+	alumni, err := s.alumniRepo.GetByNIM(strings.TrimSpace(req.NIM))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.claimRepo.Consume(alumni.ID, user.ID, helper.HashToken(strings.TrimSpace(req.Code))); err != nil {
+		if failErr := s.claimRepo.RegisterFailure(alumni.ID, user.ID); failErr != nil {
+			log.Printf("[ERROR] AlumniService RegisterFailure: %v", failErr)
+		}
+		return nil, err
+	}
+
+	// Cek ulang: data bisa saja dihubungkan admin selama kode menunggu
+	if alumni, err = s.alumniRepo.GetByNIM(alumni.NIM); err != nil {
+		return nil, err
+	}
+	current, err := s.linkedAccount(alumni)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		return nil, errors.New("data alumni ini sudah diklaim")
+	}
+	linked, err := s.alumniRepo.SetUserID(alumni.ID.Hex(), user.ID, alumni.UserID, alumni.Version)
+	if err != nil && err.Error() == "versi data tidak cocok" {
+		return nil, errors.New("data alumni ini sudah diklaim")
+	}
+	return linked, err
+}
+
+func (s *alumniService) GetMyAlumni(userID string) (*model.Alumni, error) {
+	alumni, err := s.alumniRepo.GetByUserID(userID)
+	if err != nil {
+		if err.Error() == "alumni tidak ditemukan" {
+			return nil, errors.New("akun Anda belum terhubung ke data alumni")
+		}
+		return nil, err
+	}
+	return alumni, nil
+}
+
+// UpdateMyAlumni hanya mengubah data kontak; field lain tetap dikelola admin.
+// Jika ALUMNI_REQUIRE_APPROVAL aktif, perubahan diajukan ke antrian moderasi.
+func (s *alumniService) UpdateMyAlumni(actor *model.Actor, req *model.UpdateMyAlumniRequest, version int) (*model.Alumni, *model.PendingChange, error) {
+	alumni, err := s.GetMyAlumni(actor.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !helper.VersionMatches(alumni.Version, version) {
+		return nil, nil, errors.New("versi data tidak cocok")
+	}
+
+	update := &model.UpdateAlumniRequest{
+		Nama:       alumni.Nama,
+		Jurusan:    alumni.Jurusan,
+		Angkatan:   alumni.Angkatan,
+		TahunLulus: alumni.TahunLulus,
+		Email:      strings.TrimSpace(req.Email),
+		NoTelepon:  req.NoTelepon,
+		Alamat:     req.Alamat,
+	}
+	if err := helper.ValidateUpdateAlumni(update.Nama, update.Jurusan, update.Email, update.Angkatan, update.TahunLulus); err != nil {
+		return nil, nil, err
+	}
+
+	if s.cfg.AlumniRequireApproval {
+		change, err := s.moderation.SubmitAlumniUpdate(actor, alumni, update)
+		return nil, change, err
+	}
+	updated, err := s.alumniRepo.Update(alumni.ID.Hex(), update, alumni.Version)
+	return updated, nil, err
+}
+
+// maskEmail menyamarkan email untuk ditampilkan, misalnya "b***@kampus.ac.id"
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+	return local[:1] + "***@" + domain
+}
+
 // --- Handlers ---
 
 // HandleGetAllAlumni godoc
@@ -152,6 +675,7 @@ func (s *alumniService) GetAlumniWithPagination(search, sortBy, order string, pa
 func (s *alumniService) HandleGetAllAlumni(c *fiber.Ctx) error {
 	username := c.Locals("username").(string)
 	role := c.Locals("role").(string)
+	actor := c.Locals("actor").(*model.Actor)
 	log.Printf("User %s (%s) accessing GET /alumni", username, role)
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
@@ -168,7 +692,7 @@ func (s *alumniService) HandleGetAllAlumni(c *fiber.Ctx) error {
 	}
 
 	if c.Query("page") != "" || c.Query("limit") != "" || c.Query("search") != "" || c.Query("sortBy") != "" || c.Query("order") != "" {
-		response, err := s.GetAlumniWithPagination(search, sortBy, order, page, limit)
+		response, err := s.GetAlumniWithPagination(search, sortBy, order, page, limit, actor.Scope)
 		if err != nil {
 			log.Printf("[ERROR] Alumni pagination service error: %v", err)
 			return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil data alumni")
@@ -181,7 +705,7 @@ func (s *alumniService) HandleGetAllAlumni(c *fiber.Ctx) error {
 		})
 	}
 
-	alumni, err := s.GetAllAlumni()
+	alumni, err := s.GetAllAlumni(actor.Scope)
 	if err != nil {
 		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil data alumni")
 	}
@@ -207,11 +731,12 @@ func (s *alumniService) HandleGetAllAlumni(c *fiber.Ctx) error {
 func (s *alumniService) HandleGetAlumniByID(c *fiber.Ctx) error {
 	username := c.Locals("username").(string)
 	role := c.Locals("role").(string)
+	actor := c.Locals("actor").(*model.Actor)
 	id := c.Params("id") // ID sekarang string
 
 	log.Printf("User %s (%s) accessing GET /alumni/%s", username, role, id)
 
-	alumni, err := s.GetAlumniByID(id)
+	alumni, err := s.GetAlumniByID(id, actor.Scope)
 	if err != nil {
 		if err.Error() == "alumni tidak ditemukan" {
 			return helper.ErrorResponse(c, fiber.StatusNotFound, "Alumni tidak ditemukan")
@@ -219,6 +744,7 @@ func (s *alumniService) HandleGetAlumniByID(c *fiber.Ctx) error {
 		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
 	}
 
+	helper.SetETag(c, alumni.Version)
 	return helper.SuccessResponse(c, "Alumni data retrieved successfully", alumni)
 }
 
@@ -247,12 +773,16 @@ func (s *alumniService) HandleCreateAlumni(c *fiber.Ctx) error {
 		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	userIDStr := c.Locals("user_id").(string)
+	actor := c.Locals("actor").(*model.Actor)
 
-	alumni, err := s.CreateAlumni(&req, userIDStr)
+	alumni, err := s.CreateAlumni(&req, actor.Scope)
 	if err != nil {
+		if isDuplicateAlumniError(err) {
+			return helper.ErrorResponse(c, fiber.StatusConflict, err.Error())
+		}
 		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
+	s.audit.Record(c, model.AuditActionCreate, model.AuditEntityAlumni, alumni.ID.Hex(), nil, alumni)
 
 	return helper.CreatedResponse(c, "Alumni created successfully", alumni)
 }
@@ -268,30 +798,377 @@ func (s *alumniService) HandleUpdateAlumni(c *fiber.Ctx) error {
 		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	alumni, err := s.UpdateAlumni(id, &req)
+	version, err := helper.IfMatchVersion(c, s.cfg.RequireIfMatch)
+	if err != nil {
+		return helper.PreconditionErrorResponse(c, err)
+	}
+
+	actor := c.Locals("actor").(*model.Actor)
+	before, _ := s.alumniRepo.GetByID(id)
+	alumni, err := s.UpdateAlumni(id, &req, actor.Scope, version)
 	if err != nil {
 		if err.Error() == "alumni tidak ditemukan" {
 			return helper.ErrorResponse(c, fiber.StatusNotFound, "Alumni tidak ditemukan")
 		}
+		if helper.IsPreconditionError(err) {
+			return helper.PreconditionErrorResponse(c, err)
+		}
+		if isDuplicateAlumniError(err) {
+			return helper.ErrorResponse(c, fiber.StatusConflict, err.Error())
+		}
 		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
+	s.audit.Record(c, model.AuditActionUpdate, model.AuditEntityAlumni, id, before, alumni)
 
+	helper.SetETag(c, alumni.Version)
 	return helper.SuccessResponse(c, "Alumni updated successfully", alumni)
 }
 
+func (s *alumniService) HandlePatchAlumni(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+	id := c.Params("id")
+
+	log.Printf("Admin %s patching alumni ID %s", username, id)
+
+	version, err := helper.IfMatchVersion(c, s.cfg.RequireIfMatch)
+	if err != nil {
+		return helper.PreconditionErrorResponse(c, err)
+	}
+
+	actor := c.Locals("actor").(*model.Actor)
+	before, _ := s.alumniRepo.GetByID(id)
+	alumni, err := s.PatchAlumni(id, c.Body(), c.Get(fiber.HeaderContentType), actor.Scope, version)
+	if err != nil {
+		switch {
+		case err.Error() == "alumni tidak ditemukan":
+			return helper.ErrorResponse(c, fiber.StatusNotFound, "Alumni tidak ditemukan")
+		case helper.IsPreconditionError(err):
+			return helper.PreconditionErrorResponse(c, err)
+		case strings.HasPrefix(err.Error(), "content type"):
+			c.Set("Accept-Patch", helper.AcceptPatch)
+			return helper.ErrorResponse(c, fiber.StatusUnsupportedMediaType, err.Error())
+		case strings.HasPrefix(err.Error(), "operasi test gagal"), isDuplicateAlumniError(err):
+			return helper.ErrorResponse(c, fiber.StatusConflict, err.Error())
+		}
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if before == nil || alumni.Version != before.Version {
+		s.audit.Record(c, model.AuditActionUpdate, model.AuditEntityAlumni, id, before, alumni)
+	}
+
+	helper.SetETag(c, alumni.Version)
+	return helper.SuccessResponse(c, "Alumni updated successfully", alumni)
+}
+
+// alumniTrashErrorResponse memetakan error trash/restore/hard delete alumni ke status HTTP
+func alumniTrashErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case err.Error() == "alumni tidak ditemukan":
+		return helper.ErrorResponse(c, fiber.StatusNotFound, "Alumni tidak ditemukan")
+	case err.Error() == "alumni tidak ditemukan di trash":
+		return helper.ErrorResponse(c, fiber.StatusNotFound, "Alumni tidak ditemukan di trash")
+	case helper.IsPreconditionError(err):
+		return helper.PreconditionErrorResponse(c, err)
+	case strings.HasSuffix(err.Error(), "dalam legal hold"):
+		return helper.ErrorResponse(c, fiber.StatusConflict, err.Error())
+	}
+	return helper.ErrorResponse(c, fiber.StatusInternalServerError, fallback+": "+err.Error())
+}
+
+// recordCascade mencatat audit untuk setiap pekerjaan dan file yang ikut
+// berubah bersama alumni
+func (s *alumniService) recordCascade(c *fiber.Ctx, action string, cascade *model.AlumniCascade) {
+	if cascade == nil {
+		return
+	}
+	for i := range cascade.Pekerjaan {
+		before := &cascade.Pekerjaan[i]
+		id := before.ID.Hex()
+		var after *model.PekerjaanAlumni
+		if action != model.AuditActionHardDelete {
+			after, _ = s.pekerjaanRepo.GetByIDWithDeleted(id)
+		}
+		s.audit.Record(c, action, model.AuditEntityPekerjaan, id, before, after)
+	}
+	for i := range cascade.Files {
+		before := &cascade.Files[i]
+		var after *model.File
+		if action != model.AuditActionHardDelete {
+			hidden := *before
+			hidden.IsHidden = action == model.AuditActionSoftDelete
+			after = &hidden
+		}
+		s.audit.Record(c, action, model.AuditEntityFile, before.ID.Hex(), before, after)
+	}
+}
+
 func (s *alumniService) HandleDeleteAlumni(c *fiber.Ctx) error {
 	username := c.Locals("username").(string)
 	id := c.Params("id") // ID sekarang string
 
-	log.Printf("Admin %s deleting alumni ID %s", username, id)
+	log.Printf("Admin %s moving alumni ID %s to trash", username, id)
 
-	err := s.DeleteAlumni(id)
+	version, err := helper.IfMatchVersion(c, s.cfg.RequireIfMatch)
 	if err != nil {
+		return helper.PreconditionErrorResponse(c, err)
+	}
+
+	actor := c.Locals("actor").(*model.Actor)
+	before, _ := s.alumniRepo.GetByID(id)
+	cascade, err := s.DeleteAlumni(id, actor, version)
+	if cascade == nil {
 		if err.Error() == "alumni tidak ditemukan" {
 			return helper.ErrorResponse(c, fiber.StatusNotFound, "Alumni tidak ditemukan")
 		}
+		if helper.IsPreconditionError(err) {
+			return helper.PreconditionErrorResponse(c, err)
+		}
 		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal menghapus alumni")
 	}
+	// Alumni sudah di trash walaupun cascade gagal, jadi tetap dicatat
+	after, _ := s.alumniRepo.GetByIDWithDeleted(id)
+	s.audit.Record(c, model.AuditActionSoftDelete, model.AuditEntityAlumni, id, before, after)
+	s.recordCascade(c, model.AuditActionSoftDelete, cascade)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Alumni dipindahkan ke trash, tetapi "+err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Alumni moved to trash successfully", fiber.Map{"cascade": cascade.Summary()})
+}
+
+func (s *alumniService) HandleListTrash(c *fiber.Ctx) error {
+	actor := c.Locals("actor").(*model.Actor)
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	search := c.Query("search", "")
+
+	response, err := s.ListTrash(search, page, limit, actor.Scope)
+	if err != nil {
+		log.Printf("[ERROR] Alumni trash service error: %v", err)
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil data trash")
+	}
 
-	return helper.SuccessResponse(c, "Alumni deleted successfully", nil)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Trash data retrieved successfully",
+		"data":    response.Data,
+		"meta":    response.Meta,
+	})
+}
+
+func (s *alumniService) HandleRestoreAlumni(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+	actor := c.Locals("actor").(*model.Actor)
+	id := c.Params("id")
+
+	log.Printf("Admin %s restoring alumni ID %s", username, id)
+
+	version, err := helper.IfMatchVersion(c, s.cfg.RequireIfMatch)
+	if err != nil {
+		return helper.PreconditionErrorResponse(c, err)
+	}
+
+	before, _ := s.alumniRepo.GetByIDWithDeleted(id)
+	alumni, cascade, err := s.RestoreAlumni(id, actor, version)
+	if alumni == nil {
+		return alumniTrashErrorResponse(c, err, "Gagal restore alumni")
+	}
+	s.audit.Record(c, model.AuditActionRestore, model.AuditEntityAlumni, id, before, alumni)
+	s.recordCascade(c, model.AuditActionRestore, cascade)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Alumni direstore, tetapi "+err.Error())
+	}
+
+	helper.SetETag(c, alumni.Version)
+	return helper.SuccessResponse(c, "Alumni restored successfully", fiber.Map{
+		"alumni":  alumni,
+		"cascade": cascade.Summary(),
+	})
+}
+
+func (s *alumniService) HandleHardDeleteAlumni(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+	actor := c.Locals("actor").(*model.Actor)
+	id := c.Params("id")
+
+	log.Printf("Admin %s permanently deleting alumni ID %s", username, id)
+
+	version, err := helper.IfMatchVersion(c, s.cfg.RequireIfMatch)
+	if err != nil {
+		return helper.PreconditionErrorResponse(c, err)
+	}
+
+	before, _ := s.alumniRepo.GetByIDWithDeleted(id)
+	cascade, err := s.HardDeleteAlumni(id, actor.Scope, version)
+	// Data terkait yang sudah terhapus tetap dicatat walaupun alumni gagal dihapus
+	s.recordCascade(c, model.AuditActionHardDelete, cascade)
+	if err != nil {
+		return alumniTrashErrorResponse(c, err, "Gagal hard delete alumni")
+	}
+	s.audit.Record(c, model.AuditActionHardDelete, model.AuditEntityAlumni, id, before, nil)
+
+	return helper.SuccessResponse(c, "Alumni permanently deleted", fiber.Map{"cascade": cascade.Summary()})
+}
+
+func (s *alumniService) HandleLinkUser(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+	actor := c.Locals("actor").(*model.Actor)
+	id := c.Params("id")
+
+	var req model.LinkAlumniUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	log.Printf("Admin %s linking alumni ID %s to user %s", username, id, req.UserID)
+
+	version, err := helper.IfMatchVersion(c, s.cfg.RequireIfMatch)
+	if err != nil {
+		return helper.PreconditionErrorResponse(c, err)
+	}
+
+	before, _ := s.alumniRepo.GetByID(id)
+	alumni, err := s.LinkUser(id, req.UserID, actor.Scope, version)
+	if err != nil {
+		return alumniLinkErrorResponse(c, err)
+	}
+	s.audit.Record(c, model.AuditActionLink, model.AuditEntityAlumni, id, before, alumni)
+	helper.SetETag(c, alumni.Version)
+	return helper.SuccessResponse(c, "Alumni linked successfully", alumni)
+}
+
+func (s *alumniService) HandleUnlinkUser(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+	actor := c.Locals("actor").(*model.Actor)
+	id := c.Params("id")
+	log.Printf("Admin %s unlinking alumni ID %s", username, id)
+
+	version, err := helper.IfMatchVersion(c, s.cfg.RequireIfMatch)
+	if err != nil {
+		return helper.PreconditionErrorResponse(c, err)
+	}
+
+	before, _ := s.alumniRepo.GetByID(id)
+	alumni, err := s.UnlinkUser(id, actor.Scope, version)
+	if err != nil {
+		return alumniLinkErrorResponse(c, err)
+	}
+	s.audit.Record(c, model.AuditActionUnlink, model.AuditEntityAlumni, id, before, alumni)
+	helper.SetETag(c, alumni.Version)
+	return helper.SuccessResponse(c, "Alumni unlinked successfully", alumni)
+}
+
+func (s *alumniService) HandleRequestClaim(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req model.ClaimAlumniRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	result, err := s.RequestClaim(userID, req.NIM)
+	if err != nil {
+		return alumniLinkErrorResponse(c, err)
+	}
+	return helper.SuccessResponse(c, "Kode verifikasi telah dikirim ke email alumni", result)
+}
+
+func (s *alumniService) HandleVerifyClaim(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req model.VerifyClaimAlumniRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	alumni, err := s.VerifyClaim(userID, &req)
+	if err != nil {
+		return alumniLinkErrorResponse(c, err)
+	}
+	s.audit.Record(c, model.AuditActionClaim, model.AuditEntityAlumni, alumni.ID.Hex(), nil, alumni)
+	return helper.SuccessResponse(c, "Data alumni berhasil diklaim", alumni)
+}
+
+func (s *alumniService) HandleGetMyAlumni(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	alumni, err := s.GetMyAlumni(userID)
+	if err != nil {
+		return alumniLinkErrorResponse(c, err)
+	}
+	helper.SetETag(c, alumni.Version)
+	return helper.SuccessResponse(c, "Alumni data retrieved successfully", alumni)
+}
+
+func (s *alumniService) HandleUpdateMyAlumni(c *fiber.Ctx) error {
+	actor := c.Locals("actor").(*model.Actor)
+
+	var req model.UpdateMyAlumniRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	version, err := helper.IfMatchVersion(c, s.cfg.RequireIfMatch)
+	if err != nil {
+		return helper.PreconditionErrorResponse(c, err)
+	}
+
+	before, _ := s.alumniRepo.GetByUserID(actor.UserID)
+	alumni, change, err := s.UpdateMyAlumni(actor, &req, version)
+	if err != nil {
+		return alumniLinkErrorResponse(c, err)
+	}
+	if change != nil {
+		s.audit.Record(c, model.AuditActionSubmit, model.AuditEntityPendingChange, change.ID.Hex(), nil, change)
+		return c.Status(fiber.StatusAccepted).JSON(helper.Response{
+			Success: true,
+			Message: "Perubahan data menunggu persetujuan admin",
+			Data:    change,
+		})
+	}
+	s.audit.Record(c, model.AuditActionUpdate, model.AuditEntityAlumni, alumni.ID.Hex(), before, alumni)
+	helper.SetETag(c, alumni.Version)
+	return helper.SuccessResponse(c, "Alumni updated successfully", alumni)
+}
+
+// isDuplicateAlumniError mengenali pelanggaran unique index NIM/email alumni (409)
+func isDuplicateAlumniError(err error) bool {
+	return strings.HasSuffix(err.Error(), "sudah digunakan alumni lain")
+}
+
+// alumniLinkErrorResponse memetakan error link, klaim dan /alumni/me ke status HTTP
+func alumniLinkErrorResponse(c *fiber.Ctx, err error) error {
+	msg := err.Error()
+	switch {
+	case msg == "alumni tidak ditemukan":
+		return helper.ErrorResponse(c, fiber.StatusNotFound, "Alumni tidak ditemukan")
+	case helper.IsPreconditionError(err):
+		return helper.PreconditionErrorResponse(c, err)
+	case msg == "user tidak ditemukan", msg == "akun Anda belum terhubung ke data alumni":
+		return helper.ErrorResponse(c, fiber.StatusNotFound, msg)
+	case msg == "data alumni ini sudah diklaim",
+		isDuplicateAlumniError(err),
+		strings.HasPrefix(msg, "alumni sudah terhubung"),
+		strings.HasPrefix(msg, "user sudah terhubung"):
+		return helper.ErrorResponse(c, fiber.StatusConflict, msg)
+	case strings.HasPrefix(msg, "hanya akun"):
+		return helper.ErrorResponse(c, fiber.StatusForbidden, msg)
+	case msg == "kode verifikasi salah atau sudah kedaluwarsa":
+		return helper.ErrorResponse(c, fiber.StatusUnprocessableEntity, msg)
+	case msg == "terlalu banyak permintaan klaim, coba lagi nanti":
+		return helper.ErrorResponse(c, fiber.StatusTooManyRequests, msg)
+	case msg == "gagal mengirim kode verifikasi":
+		return helper.ErrorResponse(c, fiber.StatusBadGateway, msg)
+	case strings.Contains(msg, "harus diisi"),
+		strings.Contains(msg, "tidak memiliki email"),
+		msg == "tidak ada perubahan yang diajukan",
+		strings.HasPrefix(msg, "ID tidak valid"),
+		strings.Contains(msg, "is required"),
+		strings.Contains(msg, "must be"),
+		strings.Contains(msg, "cannot be"):
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, msg)
+	default:
+		log.Printf("[ERROR] Alumni link service error: %v", err)
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memproses data alumni")
+	}
 }
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"alumni-crud-api/app/model"
+	"alumni-crud-api/app/repository"
+	"alumni-crud-api/config"
+	"alumni-crud-api/helper"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newClaimTestService(users ...*model.User) (AlumniService, *fakeClaimRepo, *model.Alumni) {
+	alumni := &model.Alumni{ID: primitive.NewObjectID(), NIM: "2101001", Nama: "Budi", Email: "budi@kampus.ac.id"}
+	claims := &fakeClaimRepo{}
+	svc := NewAlumniService(&fakeAlumniRepo{alumni: []*model.Alumni{alumni}}, nil, nil,
+		newFakeAuthRepo(users...), claims, nil, nil, &fakeMailer{},
+		&config.Config{AlumniClaimTTL: 30 * time.Minute})
+	return svc, claims, alumni
+}
+
+func TestRequestClaimKeepsOtherUsersCodes(t *testing.T) {
+	budi := &model.User{Username: "budi", Role: model.RoleUser}
+	penyusup := &model.User{Username: "penyusup", Role: model.RoleUser}
+	svc, claims, _ := newClaimTestService(budi, penyusup)
+
+	if _, err := svc.RequestClaim(budi.ID.Hex(), "2101001"); err != nil {
+		t.Fatalf("RequestClaim budi: %v", err)
+	}
+	if _, err := svc.RequestClaim(penyusup.ID.Hex(), "2101001"); err != nil {
+		t.Fatalf("RequestClaim penyusup: %v", err)
+	}
+	if n := len(claims.active(budi.ID)); n != 1 {
+		t.Errorf("kode aktif budi = %d, want 1 (tidak dibatalkan permintaan user lain)", n)
+	}
+
+	// Permintaan ulang hanya membatalkan kode milik user itu sendiri
+	if _, err := svc.RequestClaim(budi.ID.Hex(), "2101001"); err != nil {
+		t.Fatalf("RequestClaim ulang: %v", err)
+	}
+	if n := len(claims.active(budi.ID)); n != 1 {
+		t.Errorf("kode aktif budi setelah minta ulang = %d, want 1", n)
+	}
+	if n := len(claims.active(penyusup.ID)); n != 1 {
+		t.Errorf("kode aktif penyusup = %d, want 1", n)
+	}
+}
+
+func TestRequestClaimLimitsRequestsPerUser(t *testing.T) {
+	budi := &model.User{Username: "budi", Role: model.RoleUser}
+	svc, _, _ := newClaimTestService(budi)
+
+	for i := 0; i < repository.MaxClaimRequestsPerUser; i++ {
+		if _, err := svc.RequestClaim(budi.ID.Hex(), "2101001"); err != nil {
+			t.Fatalf("RequestClaim #%d: %v", i+1, err)
+		}
+	}
+	_, err := svc.RequestClaim(budi.ID.Hex(), "2101001")
+	if err == nil || err.Error() != "terlalu banyak permintaan klaim, coba lagi nanti" {
+		t.Fatalf("RequestClaim melewati batas error = %v", err)
+	}
+}
+
+func TestRequestClaimLimitsRequestsPerAlumni(t *testing.T) {
+	var users []*model.User
+	for i := 0; i <= repository.MaxClaimRequestsPerAlumni; i++ {
+		users = append(users, &model.User{Username: "user" + string(rune('a'+i)), Role: model.RoleUser})
+	}
+	svc, _, _ := newClaimTestService(users...)
+
+	for _, u := range users[:repository.MaxClaimRequestsPerAlumni] {
+		if _, err := svc.RequestClaim(u.ID.Hex(), "2101001"); err != nil {
+			t.Fatalf("RequestClaim %s: %v", u.Username, err)
+		}
+	}
+	last := users[repository.MaxClaimRequestsPerAlumni]
+	if _, err := svc.RequestClaim(last.ID.Hex(), "2101001"); err == nil {
+		t.Fatal("RequestClaim ke alumni yang sama dari terlalu banyak user seharusnya ditolak")
+	}
+}
+
+func TestRequestClaimCarriesFailedAttempts(t *testing.T) {
+	budi := &model.User{Username: "budi", Role: model.RoleUser}
+	svc, claims, _ := newClaimTestService(budi)
+
+	if _, err := svc.RequestClaim(budi.ID.Hex(), "2101001"); err != nil {
+		t.Fatal(err)
+	}
+	claims.active(budi.ID)[0].Attempts = repository.MaxClaimAttempts - 1
+
+	if _, err := svc.RequestClaim(budi.ID.Hex(), "2101001"); err != nil {
+		t.Fatal(err)
+	}
+	active := claims.active(budi.ID)
+	if len(active) != 1 || active[0].Attempts != repository.MaxClaimAttempts-1 {
+		t.Fatalf("klaim baru = %+v, want mewarisi %d salah kode", active, repository.MaxClaimAttempts-1)
+	}
+
+	active[0].Attempts = repository.MaxClaimAttempts
+	if _, err := svc.RequestClaim(budi.ID.Hex(), "2101001"); err == nil {
+		t.Fatal("RequestClaim setelah jatah salah kode habis seharusnya ditolak")
+	}
+}
+
+// Repository palsu tidak mengimplementasikan Create/Update, sehingga test di
+// bawah juga memastikan penolakan scope terjadi sebelum ada penulisan.
+func TestAlumniServiceEnforcesJurusanScope(t *testing.T) {
+	svc, _, alumni := newClaimTestService()
+	alumni.Jurusan = "Teknik Informatika"
+	teknik := &model.DataScope{Jurusan: []string{"teknik informatika"}}
+	ekonomi := &model.DataScope{Jurusan: []string{"Akuntansi"}}
+
+	if _, err := svc.GetAlumniByID(alumni.ID.Hex(), teknik); err != nil {
+		t.Fatalf("alumni dalam scope harus terbaca: %v", err)
+	}
+	// Di luar scope dilaporkan sama dengan data yang tidak ada
+	if _, err := svc.GetAlumniByID(alumni.ID.Hex(), ekonomi); err == nil || err.Error() != "alumni tidak ditemukan" {
+		t.Fatalf("alumni di luar scope: err = %v, ingin alumni tidak ditemukan", err)
+	}
+	if _, err := svc.GetAlumniByID(alumni.ID.Hex(), &model.DataScope{}); err == nil {
+		t.Fatal("scope tanpa jurusan tidak boleh membaca alumni apa pun")
+	}
+
+	move := &model.UpdateAlumniRequest{Nama: "Budi", Jurusan: "Akuntansi", Angkatan: 2021, TahunLulus: 2025, Email: "budi@kampus.ac.id"}
+	if _, err := svc.UpdateAlumni(alumni.ID.Hex(), move, teknik, helper.AnyVersion); err == nil || err.Error() != "jurusan di luar scope Anda" {
+		t.Fatalf("memindahkan alumni keluar scope: err = %v", err)
+	}
+	create := &model.CreateAlumniRequest{NIM: "2101002", Nama: "Sari", Jurusan: "Akuntansi", Angkatan: 2021, TahunLulus: 2025, Email: "sari@kampus.ac.id"}
+	if _, err := svc.CreateAlumni(create, teknik); err == nil || err.Error() != "jurusan di luar scope Anda" {
+		t.Fatalf("membuat alumni di luar scope: err = %v", err)
+	}
+}
+
+func TestHandleUpdateAlumniChecksIfMatch(t *testing.T) {
+	record := &model.Alumni{ID: primitive.NewObjectID(), NIM: "2101001", Nama: "Budi", Jurusan: "Teknik Informatika",
+		Angkatan: 2021, TahunLulus: 2025, Email: "budi@kampus.ac.id", Version: 5}
+	repo := &moderationAlumniRepo{fakeAlumniRepo: &fakeAlumniRepo{alumni: []*model.Alumni{record}}}
+	cfg := &config.Config{RequireIfMatch: true}
+	svc := NewAlumniService(repo, nil, nil, nil, nil, nil, &fakeAudit{}, nil, cfg)
+
+	app := fiber.New()
+	app.Put("/alumni/:id", func(c *fiber.Ctx) error {
+		c.Locals("username", "admin")
+		c.Locals("actor", &model.Actor{Username: "admin"})
+		return svc.HandleUpdateAlumni(c)
+	})
+	update := func(ifMatch string) *http.Response {
+		t.Helper()
+		body := `{"nama":"Budi Santoso","jurusan":"Teknik Informatika","angkatan":2021,"tahun_lulus":2025,"email":"budi@kampus.ac.id"}`
+		req := httptest.NewRequest("PUT", "/alumni/"+record.ID.Hex(), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := update("*"); resp.StatusCode != fiber.StatusPreconditionRequired {
+		t.Fatalf(`If-Match "*" saat wajib: status %d, ingin 428`, resp.StatusCode)
+	}
+	if resp := update(`"4"`); resp.StatusCode != fiber.StatusPreconditionFailed {
+		t.Fatalf("versi lama: status %d, ingin 412", resp.StatusCode)
+	}
+	if repo.updates != 0 {
+		t.Fatalf("update dengan If-Match salah tetap ditulis %d kali", repo.updates)
+	}
+
+	resp := update(`"5"`)
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get("ETag") != `"6"` {
+		t.Fatalf("versi terbaru: status %d ETag %s, ingin 200 dengan ETag \"6\"", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+
+	// Tanpa REQUIRE_IF_MATCH, "*" berarti tanpa pengecekan versi
+	cfg.RequireIfMatch = false
+	if resp := update("*"); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf(`If-Match "*" saat opsional: status %d, ingin 200`, resp.StatusCode)
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"alumni-crud-api/app/model"
+	"alumni-crud-api/app/repository"
+	"alumni-crud-api/helper"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxAPIKeyDays membatasi masa berlaku API key yang diminta
+const maxAPIKeyDays = 730
+
+type APIKeyService interface {
+	CreateAPIKey(req *model.CreateAPIKeyRequest, creator *model.Actor) (*model.CreateAPIKeyResponse, error)
+	ListAPIKeys() ([]model.APIKey, error)
+	RevokeAPIKey(id string) (*model.APIKey, error)
+	ValidateAPIKey(key string, ip string) (*model.APIKey, error)
+
+	HandleCreateAPIKey(c *fiber.Ctx) error
+	HandleListAPIKeys(c *fiber.Ctx) error
+	HandleRevokeAPIKey(c *fiber.Ctx) error
+}
+
+type apiKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	audit      AuditService
+}
+
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, audit AuditService) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		audit:      audit,
+	}
+}
+
+// CreateAPIKey membuat key baru dengan format "ak_<prefix>_<secret>".
+// Scope tidak boleh melebihi permission pembuatnya.
+func (s *apiKeyService) CreateAPIKey(req *model.CreateAPIKeyRequest, creator *model.Actor) (*model.CreateAPIKeyResponse, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, errors.New("Name is required")
+	}
+	if len(req.Scopes) == 0 {
+		return nil, errors.New("Scopes is required")
+	}
+	if err := helper.ValidatePermissions(req.Scopes); err != nil {
+		return nil, err
+	}
+	for _, scope := range req.Scopes {
+		for _, forbidden := range model.APIKeyForbiddenScopes {
+			if scope == forbidden {
+				return nil, fmt.Errorf("permission '%s' tidak boleh diberikan ke API key", scope)
+			}
+		}
+		if !creator.Can(scope) {
+			return nil, fmt.Errorf("permission '%s' tidak Anda miliki", scope)
+		}
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxAPIKeyDays {
+		return nil, fmt.Errorf("expires_in_days harus antara 0 dan %d", maxAPIKeyDays)
+	}
+
+	creatorID, err := primitive.ObjectIDFromHex(creator.UserID)
+	if err != nil {
+		return nil, errors.New("user ID tidak valid")
+	}
+
+	idBytes := make([]byte, 4)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, err
+	}
+	secret, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	prefix := model.APIKeyPrefix + hex.EncodeToString(idBytes)
+	key := prefix + "_" + secret
+
+	apiKey := &model.APIKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   helper.HashToken(key),
+		Scopes:    req.Scopes,
+		CreatedBy: creatorID,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	if err := s.apiKeyRepo.Create(apiKey); err != nil {
+		return nil, err
+	}
+	return &model.CreateAPIKeyResponse{Key: key, APIKey: apiKey}, nil
+}
+
+func (s *apiKeyService) ListAPIKeys() ([]model.APIKey, error) {
+	return s.apiKeyRepo.GetAll()
+}
+
+func (s *apiKeyService) RevokeAPIKey(id string) (*model.APIKey, error) {
+	return s.apiKeyRepo.Revoke(id)
+}
+
+// ValidateAPIKey memeriksa key dari header X-API-Key dan mencatat pemakaiannya.
+// Dipakai oleh middleware.AuthRequired.
+func (s *apiKeyService) ValidateAPIKey(key string, ip string) (*model.APIKey, error) {
+	if !strings.HasPrefix(key, model.APIKeyPrefix) {
+		return nil, errors.New("API key tidak valid")
+	}
+
+	apiKey, err := s.apiKeyRepo.GetActiveByHash(helper.HashToken(key))
+	if err != nil {
+		if err.Error() != "API key tidak ditemukan" {
+			log.Printf("[ERROR] APIKeyService GetActiveByHash: %v", err)
+		}
+		return nil, errors.New("API key tidak valid")
+	}
+
+	if err := s.apiKeyRepo.TouchLastUsed(apiKey.ID, ip); err != nil {
+		log.Printf("Peringatan: gagal mencatat pemakaian API key %s: %v", apiKey.Prefix, err)
+	}
+	return apiKey, nil
+}
+
+// --- Handlers ---
+
+func (s *apiKeyService) HandleCreateAPIKey(c *fiber.Ctx) error {
+	actor := c.Locals("actor").(*model.Actor)
+
+	var req model.CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	log.Printf("Admin %s creating API key %q: %v", actor.Username, req.Name, req.Scopes)
+
+	result, err := s.CreateAPIKey(&req, actor)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "tidak Anda miliki"), strings.Contains(msg, "tidak boleh diberikan"):
+			return helper.ErrorResponse(c, fiber.StatusForbidden, msg)
+		case strings.Contains(msg, "required"), strings.Contains(msg, "permission"), strings.Contains(msg, "expires_in_days"):
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, msg)
+		default:
+			log.Printf("[ERROR] APIKeyService CreateAPIKey: %v", err)
+			return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal membuat API key")
+		}
+	}
+
+	s.audit.Record(c, model.AuditActionCreate, model.AuditEntityAPIKey, result.APIKey.ID.Hex(), nil, result.APIKey)
+	return helper.CreatedResponse(c, "API key created successfully. Simpan key ini, key tidak akan ditampilkan lagi", result)
+}
+
+func (s *apiKeyService) HandleListAPIKeys(c *fiber.Ctx) error {
+	keys, err := s.ListAPIKeys()
+	if err != nil {
+		log.Printf("[ERROR] APIKeyService ListAPIKeys: %v", err)
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil data API key")
+	}
+	return helper.SuccessResponse(c, "API key data retrieved successfully", keys)
+}
+
+func (s *apiKeyService) HandleRevokeAPIKey(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+	id := c.Params("id")
+	log.Printf("Admin %s revoking API key ID %s", username, id)
+
+	revoked, err := s.RevokeAPIKey(id)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case msg == "API key tidak ditemukan":
+			return helper.ErrorResponse(c, fiber.StatusNotFound, "API key tidak ditemukan atau sudah dicabut")
+		case strings.HasPrefix(msg, "ID tidak valid"):
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, msg)
+		default:
+			log.Printf("[ERROR] APIKeyService RevokeAPIKey: %v", err)
+			return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mencabut API key")
+		}
+	}
+	s.audit.Record(c, model.AuditActionRevoke, model.AuditEntityAPIKey, id, nil, revoked)
+	return helper.SuccessResponse(c, "API key revoked successfully", nil)
+}
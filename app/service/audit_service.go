@@ -0,0 +1,350 @@
+package service
+
+import (
+	"alumni-crud-api/app/model"
+	"alumni-crud-api/app/repository"
+	"alumni-crud-api/helper"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxAuditExportRows membatasi jumlah baris satu kali export CSV
+const MaxAuditExportRows = 50000
+
+// auditRedactedFields tidak pernah ikut disimpan di snapshot audit
+var auditRedactedFields = []string{"password", "password_hash", "totp_secret", "totp_pending_secret", "recovery_codes", "key_hash", "code_hash"}
+
+// AuditService mencatat setiap perubahan data beserta pelaku dan konteks request.
+// Untuk alumni dan pekerjaan, setiap perubahan juga disimpan sebagai versi baru
+// di riwayat data (lihat HistoryService).
+type AuditService interface {
+	Record(c *fiber.Ctx, action, entityType, entityID string, before, after interface{})
+	RecordSystem(job, action, entityType, entityID string, before, after interface{})
+	ListAuditLogs(filter model.AuditLogFilter, page, limit int) ([]model.AuditLog, *model.MetaInfo, error)
+	ExportAuditLogsCSV(filter model.AuditLogFilter, limit int) ([]byte, error)
+
+	HandleListAuditLogs(c *fiber.Ctx) error
+	HandleExportAuditLogs(c *fiber.Ctx) error
+}
+
+type auditService struct {
+	auditRepo   repository.AuditLogRepository
+	versionRepo repository.RecordVersionRepository
+}
+
+func NewAuditService(auditRepo repository.AuditLogRepository, versionRepo repository.RecordVersionRepository) AuditService {
+	return &auditService{auditRepo: auditRepo, versionRepo: versionRepo}
+}
+
+// auditSnapshot mengubah data (struct/pointer) menjadi dokumen BSON tanpa field rahasia.
+// Nilai nil, termasuk pointer nil, menghasilkan snapshot kosong.
+func auditSnapshot(v interface{}) bson.M {
+	if v == nil {
+		return nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		log.Printf("[ERROR] Audit snapshot %T: %v", v, err)
+		return nil
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		log.Printf("[ERROR] Audit snapshot %T: %v", v, err)
+		return nil
+	}
+	for _, field := range auditRedactedFields {
+		delete(doc, field)
+	}
+	return doc
+}
+
+// Record menyimpan satu catatan audit. Pelaku diambil dari actor (atau local
+// token untuk endpoint tanpa LoadActor). Kegagalan hanya dicatat di log agar
+// perubahan yang sudah terjadi tetap dilaporkan berhasil ke client.
+func (s *auditService) Record(c *fiber.Ctx, action, entityType, entityID string, before, after interface{}) {
+	entry := &model.AuditLog{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     auditSnapshot(before),
+		After:      auditSnapshot(after),
+		IP:         c.IP(),
+		UserAgent:  c.Get(fiber.HeaderUserAgent),
+		Method:     c.Method(),
+		Path:       c.Path(),
+		CreatedAt:  time.Now(),
+	}
+	entry.RequestID, _ = c.Locals("requestid").(string)
+
+	userID, _ := c.Locals("user_id").(string)
+	entry.ActorUsername, _ = c.Locals("username").(string)
+	entry.ActorRole, _ = c.Locals("role").(string)
+	if actor, ok := c.Locals("actor").(*model.Actor); ok {
+		userID, entry.ActorUsername, entry.ActorRole = actor.UserID, actor.Username, actor.Role
+	}
+	if objID, err := primitive.ObjectIDFromHex(userID); err == nil {
+		entry.ActorID = &objID
+	}
+	s.save(entry)
+}
+
+// RecordSystem mencatat perubahan yang dilakukan job latar belakang (tanpa
+// request). Nama job disimpan sebagai pelaku dan path.
+func (s *auditService) RecordSystem(job, action, entityType, entityID string, before, after interface{}) {
+	s.save(&model.AuditLog{
+		ActorUsername: "system:" + job,
+		ActorRole:     "system",
+		Action:        action,
+		EntityType:    entityType,
+		EntityID:      entityID,
+		Before:        auditSnapshot(before),
+		After:         auditSnapshot(after),
+		Method:        "JOB",
+		Path:          job,
+		CreatedAt:     time.Now(),
+	})
+}
+
+// save menyimpan audit log lalu riwayat versi. Riwayat tetap dicatat walaupun
+// audit log gagal disimpan, karena keduanya disimpan terpisah.
+func (s *auditService) save(entry *model.AuditLog) {
+	if err := s.auditRepo.Insert(entry); err != nil {
+		log.Printf("[ERROR] Audit log gagal disimpan (%s %s %s): %v", entry.Action, entry.EntityType, entry.EntityID, err)
+	}
+
+	if entry.EntityType == model.AuditEntityAlumni || entry.EntityType == model.AuditEntityPekerjaan {
+		s.recordVersion(entry)
+	}
+}
+
+// snapshotVersion membaca field version dari snapshot; 0 jika tidak ada
+func snapshotVersion(snapshot bson.M) int {
+	switch v := snapshot["version"].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// recordVersion menyimpan kondisi data setelah perubahan dengan nomor versi
+// dokumennya. Snapshot sebelum perubahan ikut disimpan sebagai "baseline" jika
+// versinya belum ada di riwayat: data lama yang belum punya riwayat, atau
+// versi yang gagal disimpan pada perubahan sebelumnya.
+func (s *auditService) recordVersion(entry *model.AuditLog) {
+	if entry.After == nil {
+		return // Hard delete: riwayat yang ada tetap disimpan
+	}
+
+	latest, err := s.versionRepo.GetLatest(entry.EntityType, entry.EntityID)
+	if err != nil && err.Error() != "versi tidak ditemukan" {
+		log.Printf("[ERROR] Riwayat %s %s: %v", entry.EntityType, entry.EntityID, err)
+		return
+	}
+	beforeVersion := snapshotVersion(entry.Before)
+	if entry.Before != nil && (latest == nil || (beforeVersion > 0 && latest.Version < beforeVersion)) {
+		baseline := &model.RecordVersion{
+			EntityType: entry.EntityType,
+			EntityID:   entry.EntityID,
+			Version:    beforeVersion,
+			Action:     model.VersionActionBaseline,
+			Snapshot:   entry.Before,
+		}
+		if updatedAt, ok := entry.Before["updated_at"].(primitive.DateTime); ok {
+			baseline.CreatedAt = updatedAt.Time()
+		}
+		if err := s.versionRepo.Append(baseline); err != nil {
+			log.Printf("[ERROR] Riwayat baseline %s %s: %v", entry.EntityType, entry.EntityID, err)
+		}
+	}
+
+	version := &model.RecordVersion{
+		EntityType:        entry.EntityType,
+		EntityID:          entry.EntityID,
+		Version:           snapshotVersion(entry.After),
+		Action:            entry.Action,
+		Snapshot:          entry.After,
+		ChangedBy:         entry.ActorID,
+		ChangedByUsername: entry.ActorUsername,
+		RequestID:         entry.RequestID,
+		CreatedAt:         entry.CreatedAt,
+	}
+	if err := s.versionRepo.Append(version); err != nil {
+		log.Printf("[ERROR] Riwayat %s %s versi %d gagal disimpan: %v", entry.EntityType, entry.EntityID, version.Version, err)
+	}
+}
+
+func (s *auditService) ListAuditLogs(filter model.AuditLogFilter, page, limit int) ([]model.AuditLog, *model.MetaInfo, error) {
+	offset := (page - 1) * limit
+
+	logs, err := s.auditRepo.List(filter, limit, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+	total, err := s.auditRepo.Count(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pages := (total + limit - 1) / limit
+	if total == 0 {
+		pages = 0
+	}
+	return logs, &model.MetaInfo{Page: page, Limit: limit, Total: total, Pages: pages}, nil
+}
+
+func (s *auditService) ExportAuditLogsCSV(filter model.AuditLogFilter, limit int) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := []string{"created_at", "actor_id", "actor_username", "actor_role", "action", "entity_type", "entity_id", "ip", "user_agent", "request_id", "method", "path", "before", "after"}
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	err := s.auditRepo.Each(filter, limit, func(entry *model.AuditLog) error {
+		actorID := ""
+		if entry.ActorID != nil {
+			actorID = entry.ActorID.Hex()
+		}
+		return w.Write([]string{
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			actorID,
+			entry.ActorUsername,
+			entry.ActorRole,
+			entry.Action,
+			entry.EntityType,
+			entry.EntityID,
+			entry.IP,
+			entry.UserAgent,
+			entry.RequestID,
+			entry.Method,
+			entry.Path,
+			snapshotJSON(entry.Before),
+			snapshotJSON(entry.After),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func snapshotJSON(doc bson.M) string {
+	if doc == nil {
+		return ""
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return ""
+	}
+	return string(raw)
+}
+
+// --- Handlers ---
+
+// parseAuditTime menerima RFC3339 atau tanggal (YYYY-MM-DD). Untuk batas akhir,
+// tanggal saja berarti sampai akhir hari tersebut.
+func parseAuditTime(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("format waktu %q tidak valid (gunakan RFC3339 atau YYYY-MM-DD)", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+func auditFilterFromQuery(c *fiber.Ctx) (model.AuditLogFilter, error) {
+	filter := model.AuditLogFilter{
+		ActorUsername: c.Query("actor"),
+		EntityType:    c.Query("entity_type"),
+		EntityID:      c.Query("entity_id"),
+		Action:        c.Query("action"),
+	}
+	if actorID := c.Query("actor_id"); actorID != "" {
+		objID, err := primitive.ObjectIDFromHex(actorID)
+		if err != nil {
+			return filter, errors.New("actor_id tidak valid")
+		}
+		filter.ActorID = &objID
+	}
+
+	var err error
+	if filter.From, err = parseAuditTime(c.Query("from"), false); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseAuditTime(c.Query("to"), true); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func (s *auditService) HandleListAuditLogs(c *fiber.Ctx) error {
+	filter, err := auditFilterFromQuery(c)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	page, limit := paginationQuery(c)
+
+	logs, meta, err := s.ListAuditLogs(filter, page, limit)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil audit log")
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Audit log retrieved successfully",
+		"data":    logs,
+		"meta":    meta,
+	})
+}
+
+func (s *auditService) HandleExportAuditLogs(c *fiber.Ctx) error {
+	filter, err := auditFilterFromQuery(c)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "10000"))
+	if limit < 1 || limit > MaxAuditExportRows {
+		limit = MaxAuditExportRows
+	}
+
+	data, err := s.ExportAuditLogsCSV(filter, limit)
+	if err != nil {
+		log.Printf("[ERROR] Export audit log: %v", err)
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengekspor audit log")
+	}
+
+	filename := fmt.Sprintf("audit-log-%s.csv", time.Now().Format("20060102-150405"))
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	return c.Send(data)
+}
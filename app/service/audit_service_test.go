@@ -0,0 +1,69 @@
+package service
+
+import (
+	"alumni-crud-api/app/model"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func alumniAt(id primitive.ObjectID, version int, nama string) *model.Alumni {
+	return &model.Alumni{ID: id, NIM: "2101001", Nama: nama, Version: version}
+}
+
+func TestRecordVersionFollowsDocumentVersion(t *testing.T) {
+	versions := &fakeVersionRepo{}
+	audit := NewAuditService(&fakeAuditLogRepo{}, versions)
+	id := primitive.NewObjectID()
+
+	audit.RecordSystem("test", model.AuditActionCreate, model.AuditEntityAlumni, id.Hex(), nil, alumniAt(id, 1, "Budi"))
+	audit.RecordSystem("test", model.AuditActionUpdate, model.AuditEntityAlumni, id.Hex(), alumniAt(id, 1, "Budi"), alumniAt(id, 2, "Budi S"))
+
+	want := []string{"1:create", "2:update"}
+	if got := versions.numbers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("riwayat = %v, want %v", got, want)
+	}
+}
+
+func TestRecordVersionStartsWithBaseline(t *testing.T) {
+	versions := &fakeVersionRepo{}
+	audit := NewAuditService(&fakeAuditLogRepo{}, versions)
+	id := primitive.NewObjectID()
+
+	// Data lama versi 4 yang belum punya riwayat
+	audit.RecordSystem("test", model.AuditActionUpdate, model.AuditEntityAlumni, id.Hex(), alumniAt(id, 4, "Budi"), alumniAt(id, 5, "Budi S"))
+
+	want := []string{"4:baseline", "5:update"}
+	if got := versions.numbers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("riwayat = %v, want %v", got, want)
+	}
+}
+
+func TestRecordVersionRecoversLostVersion(t *testing.T) {
+	versions := &fakeVersionRepo{}
+	audit := NewAuditService(&fakeAuditLogRepo{}, versions)
+	id := primitive.NewObjectID()
+
+	audit.RecordSystem("test", model.AuditActionCreate, model.AuditEntityAlumni, id.Hex(), nil, alumniAt(id, 1, "Budi"))
+	versions.failNext = true
+	audit.RecordSystem("test", model.AuditActionUpdate, model.AuditEntityAlumni, id.Hex(), alumniAt(id, 1, "Budi"), alumniAt(id, 2, "Budi S"))
+	audit.RecordSystem("test", model.AuditActionUpdate, model.AuditEntityAlumni, id.Hex(), alumniAt(id, 2, "Budi S"), alumniAt(id, 3, "Budi Santoso"))
+
+	// Versi 2 yang gagal disimpan diambil dari snapshot sebelum perubahan berikutnya
+	want := []string{"1:create", "2:baseline", "3:update"}
+	if got := versions.numbers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("riwayat = %v, want %v", got, want)
+	}
+}
+
+func TestRecordVersionWithoutAuditLog(t *testing.T) {
+	versions := &fakeVersionRepo{}
+	audit := NewAuditService(&fakeAuditLogRepo{fail: true}, versions)
+	id := primitive.NewObjectID()
+
+	audit.RecordSystem("test", model.AuditActionCreate, model.AuditEntityAlumni, id.Hex(), nil, alumniAt(id, 1, "Budi"))
+	if got := versions.numbers(); !reflect.DeepEqual(got, []string{"1:create"}) {
+		t.Errorf("riwayat = %v, want versi tetap tercatat walau audit log gagal", got)
+	}
+}
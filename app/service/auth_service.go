@@ -3,57 +3,525 @@ package service
 import (
 	"alumni-crud-api/app/model"
 	"alumni-crud-api/app/repository"
+	"alumni-crud-api/config"
 	"alumni-crud-api/helper"
 	"errors"
+	"fmt"
 	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type AuthService struct {
-	authRepo repository.AuthRepository
+	authRepo    repository.AuthRepository
+	tokenRepo   repository.TokenRepository
+	resetRepo   repository.PasswordResetRepository
+	attemptRepo repository.LoginAttemptRepository
+	audit       AuditService
+	mailer      helper.Mailer
+	cfg         *config.Config
 }
 
-func NewAuthService(authRepo repository.AuthRepository) *AuthService {
+func NewAuthService(
+	authRepo repository.AuthRepository,
+	tokenRepo repository.TokenRepository,
+	resetRepo repository.PasswordResetRepository,
+	attemptRepo repository.LoginAttemptRepository,
+	audit AuditService,
+	mailer helper.Mailer,
+	cfg *config.Config,
+) *AuthService {
 	return &AuthService{
-		authRepo: authRepo,
+		authRepo:    authRepo,
+		tokenRepo:   tokenRepo,
+		resetRepo:   resetRepo,
+		attemptRepo: attemptRepo,
+		audit:       audit,
+		mailer:      mailer,
+		cfg:         cfg,
 	}
 }
 
-func (s *AuthService) Login(req model.LoginRequest) (*model.LoginResponse, error) {
+// dummyPasswordHash dibandingkan saat user tidak ditemukan agar waktu respons
+// sama dengan password salah (username tidak bisa ditebak lewat timing)
+var dummyPasswordHash, _ = helper.HashPassword("dummy-password-tidak-dipakai")
+
+// Login memverifikasi password. Jika user memakai 2FA (atau admin wajib 2FA tapi
+// belum mendaftar), yang dikembalikan adalah MFAChallenge, bukan token penuh.
+func (s *AuthService) Login(req model.LoginRequest, ip, userAgent string) (*model.LoginResponse, *model.MFAChallenge, error) {
+	attempt := &model.LoginAttempt{
+		Identifier: strings.ToLower(strings.TrimSpace(req.Username)),
+		IP:         ip,
+		UserAgent:  userAgent,
+	}
+	ipKey := "ip:" + ip
+
 	// Get user from database
 	user, passwordHash, err := s.authRepo.GetUserByUsernameOrEmail(req.Username)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("username atau password salah")
-		}
+	if err != nil && err != mongo.ErrNoDocuments {
 		log.Printf("[ERROR] AuthService Login: %v", err)
-		return nil, errors.New("error database")
+		return nil, nil, errors.New("error database")
+	}
+
+	// Key akun memakai ID jika user ada, agar login via username/email berbagi penghitung
+	userKey := "user:" + attempt.Identifier
+	if user != nil {
+		userKey = "user:" + user.ID.Hex()
+		attempt.UserID = &user.ID
+	}
+
+	if until := s.lockedUntil(userKey, ipKey); until != nil {
+		s.recordAttempt(attempt, model.LoginResultLocked)
+		return nil, nil, &LoginLockedError{Until: *until}
+	}
+
+	if user == nil {
+		helper.CheckPassword(req.Password, dummyPasswordHash)
+		s.registerLoginFailure(userKey, ipKey)
+		s.recordAttempt(attempt, model.LoginResultUnknownUser)
+		return nil, nil, errors.New("username atau password salah")
 	}
 
 	// Check password
 	if !helper.CheckPassword(req.Password, passwordHash) {
-		return nil, errors.New("username atau password salah")
+		s.registerLoginFailure(userKey, ipKey)
+		s.recordAttempt(attempt, model.LoginResultInvalidCredentials)
+		return nil, nil, errors.New("username atau password salah")
+	}
+
+	if user.IsDisabled {
+		s.recordAttempt(attempt, model.LoginResultDisabled)
+		return nil, nil, errors.New("akun dinonaktifkan")
+	}
+
+	// Penghitung akun baru direset setelah langkah 2FA berhasil
+	if user.TOTPEnabled {
+		s.recordAttempt(attempt, model.LoginResultMFAChallenge)
+		challenge, err := s.mfaChallenge(user, model.TokenPurposeMFA)
+		return nil, challenge, err
+	}
+	if s.mfaRequired(user) {
+		s.recordAttempt(attempt, model.LoginResultMFAChallenge)
+		challenge, err := s.mfaChallenge(user, model.TokenPurposeMFASetup)
+		return nil, challenge, err
 	}
 
-	// Generate JWT token
-	token, err := helper.GenerateToken(*user)
+	response, err := s.completeLogin(user, userKey, attempt)
+	return response, nil, err
+}
+
+// completeLogin mereset penghitung akun (penghitung IP dibiarkan), mencatat
+// login berhasil dan menerbitkan token penuh
+func (s *AuthService) completeLogin(user *model.User, userKey string, attempt *model.LoginAttempt) (*model.LoginResponse, error) {
+	if err := s.attemptRepo.ResetThrottle(userKey); err != nil {
+		log.Printf("[ERROR] AuthService ResetThrottle: %v", err)
+	}
+	s.recordAttempt(attempt, model.LoginResultSuccess)
+
+	// Setiap login memulai "family" refresh token baru
+	return s.issueTokens(user, uuid.New().String(), attempt.IP, attempt.UserAgent)
+}
+
+// LoginLockedError dikembalikan saat akun atau IP sedang dikunci sementara
+type LoginLockedError struct {
+	Until time.Time
+}
+
+func (e *LoginLockedError) Error() string {
+	return "terlalu banyak percobaan login gagal, coba lagi nanti"
+}
+
+// lockedUntil mengembalikan waktu kunci terlama dari key yang sedang terkunci
+func (s *AuthService) lockedUntil(keys ...string) *time.Time {
+	var until *time.Time
+	now := time.Now()
+	for _, key := range keys {
+		throttle, err := s.attemptRepo.GetThrottle(key)
+		if err != nil {
+			log.Printf("[ERROR] AuthService GetThrottle: %v", err)
+			continue
+		}
+		if throttle == nil || throttle.LockedUntil == nil || !throttle.LockedUntil.After(now) {
+			continue
+		}
+		if until == nil || throttle.LockedUntil.After(*until) {
+			until = throttle.LockedUntil
+		}
+	}
+	return until
+}
+
+// registerLoginFailure menaikkan penghitung akun dan IP. Setelah melewati batas,
+// key dikunci dengan durasi yang berlipat dua untuk setiap kegagalan berikutnya.
+func (s *AuthService) registerLoginFailure(userKey, ipKey string) {
+	limits := map[string]int{
+		userKey: s.cfg.LoginMaxFailures,
+		ipKey:   s.cfg.LoginIPMaxFailures,
+	}
+	for key, max := range limits {
+		throttle, err := s.attemptRepo.RegisterFailure(key, s.cfg.LoginFailureWindow)
+		if err != nil {
+			log.Printf("[ERROR] AuthService RegisterFailure: %v", err)
+			continue
+		}
+		if max <= 0 || throttle.Failures < max {
+			continue
+		}
+
+		lockout := s.cfg.LoginLockoutBase
+		for i := max; i < throttle.Failures && lockout < s.cfg.LoginLockoutMax; i++ {
+			lockout *= 2
+		}
+		if lockout > s.cfg.LoginLockoutMax {
+			lockout = s.cfg.LoginLockoutMax
+		}
+		if err := s.attemptRepo.SetLockedUntil(key, time.Now().Add(lockout)); err != nil {
+			log.Printf("[ERROR] AuthService SetLockedUntil: %v", err)
+		}
+		log.Printf("[WARN] Login %s dikunci selama %s setelah %d kegagalan", key, lockout, throttle.Failures)
+	}
+}
+
+func (s *AuthService) recordAttempt(attempt *model.LoginAttempt, result string) {
+	attempt.Result = result
+	if err := s.attemptRepo.Record(attempt); err != nil {
+		log.Printf("[ERROR] AuthService RecordLoginAttempt: %v", err)
+	}
+}
+
+// issueTokens menerbitkan pasangan access token + refresh token dalam satu family
+func (s *AuthService) issueTokens(user *model.User, familyID, ip, userAgent string) (*model.LoginResponse, error) {
+	token, claims, err := helper.GenerateToken(*user)
 	if err != nil {
 		log.Printf("[ERROR] AuthService GenerateToken: %v", err)
 		return nil, errors.New("gagal generate token")
 	}
 
+	refreshToken, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		log.Printf("[ERROR] AuthService GenerateOpaqueToken: %v", err)
+		return nil, errors.New("gagal generate token")
+	}
+
+	record := &model.RefreshToken{
+		UserID:          user.ID,
+		FamilyID:        familyID,
+		TokenHash:       helper.HashToken(refreshToken),
+		AccessJTI:       claims.ID,
+		AccessExpiresAt: claims.ExpiresAt.Time,
+		ExpiresAt:       time.Now().Add(helper.RefreshTokenTTL),
+		IP:              ip,
+		UserAgent:       userAgent,
+	}
+	if err := s.tokenRepo.CreateRefreshToken(record); err != nil {
+		log.Printf("[ERROR] AuthService CreateRefreshToken: %v", err)
+		return nil, errors.New("gagal generate token")
+	}
+
 	// Hapus hash sebelum mengirim response
 	user.PasswordHash = ""
 	response := &model.LoginResponse{
-		User:  *user,
-		Token: token,
+		User:             *user,
+		Token:            token,
+		ExpiresAt:        claims.ExpiresAt.Time,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: record.ExpiresAt,
 	}
 
 	return response, nil
 }
 
+// Refresh merotasi refresh token. Token lama hanya boleh dipakai sekali;
+// pemakaian ulang dianggap kebocoran dan seluruh family dicabut.
+func (s *AuthService) Refresh(refreshToken, ip, userAgent string) (*model.LoginResponse, error) {
+	record, err := s.tokenRepo.GetRefreshTokenByHash(helper.HashToken(refreshToken))
+	if err != nil {
+		if err.Error() == "refresh token tidak ditemukan" {
+			return nil, errors.New("refresh token tidak valid")
+		}
+		log.Printf("[ERROR] AuthService Refresh: %v", err)
+		return nil, errors.New("error database")
+	}
+
+	if record.RevokedAt != nil || time.Now().After(record.ExpiresAt) {
+		return nil, errors.New("refresh token tidak valid")
+	}
+
+	if record.UsedAt != nil {
+		s.revokeFamilyForReuse(record)
+		return nil, errors.New("refresh token sudah digunakan")
+	}
+
+	ok, err := s.tokenRepo.MarkRefreshTokenUsed(record.ID)
+	if err != nil {
+		log.Printf("[ERROR] AuthService MarkRefreshTokenUsed: %v", err)
+		return nil, errors.New("error database")
+	}
+	if !ok {
+		// Kalah balapan dengan request lain yang memakai token yang sama
+		s.revokeFamilyForReuse(record)
+		return nil, errors.New("refresh token sudah digunakan")
+	}
+
+	user, err := s.authRepo.GetUserByID(record.UserID.Hex())
+	if err != nil {
+		return nil, errors.New("refresh token tidak valid")
+	}
+	if user.IsDisabled {
+		_ = s.tokenRepo.RevokeFamily(record.FamilyID, "user_disabled")
+		return nil, errors.New("akun dinonaktifkan")
+	}
+
+	return s.issueTokens(user, record.FamilyID, ip, userAgent)
+}
+
+func (s *AuthService) revokeFamilyForReuse(record *model.RefreshToken) {
+	log.Printf("[WARN] Refresh token reuse terdeteksi untuk user %s (family %s), mencabut seluruh family", record.UserID.Hex(), record.FamilyID)
+	if err := s.tokenRepo.RevokeFamily(record.FamilyID, "reuse_detected"); err != nil {
+		log.Printf("[ERROR] AuthService RevokeFamily: %v", err)
+	}
+}
+
+// Logout mencabut access token saat ini dan, jika diberikan, sesi refresh token-nya
+func (s *AuthService) Logout(userID, jti string, accessExpiresAt time.Time, refreshToken string) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("user ID tidak valid")
+	}
+
+	if err := s.tokenRepo.RevokeAccessToken(jti, userObjID, accessExpiresAt, "logout"); err != nil {
+		log.Printf("[ERROR] AuthService RevokeAccessToken: %v", err)
+		return errors.New("gagal logout")
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	record, err := s.tokenRepo.GetRefreshTokenByHash(helper.HashToken(refreshToken))
+	if err != nil || record.UserID != userObjID {
+		return errors.New("refresh token tidak valid")
+	}
+	if err := s.tokenRepo.RevokeFamily(record.FamilyID, "logout"); err != nil {
+		log.Printf("[ERROR] AuthService RevokeFamily: %v", err)
+		return errors.New("gagal logout")
+	}
+	return nil
+}
+
+// LogoutAll mencabut semua sesi milik user, termasuk access token yang masih aktif
+func (s *AuthService) LogoutAll(userID, jti string, accessExpiresAt time.Time) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("user ID tidak valid")
+	}
+
+	if err := s.tokenRepo.RevokeAllForUser(userObjID, "logout_all"); err != nil {
+		log.Printf("[ERROR] AuthService RevokeAllForUser: %v", err)
+		return errors.New("gagal logout")
+	}
+	if err := s.tokenRepo.RevokeAccessToken(jti, userObjID, accessExpiresAt, "logout_all"); err != nil {
+		log.Printf("[ERROR] AuthService RevokeAccessToken: %v", err)
+		return errors.New("gagal logout")
+	}
+	return nil
+}
+
+// ValidateAccessToken memvalidasi signature/expiry JWT dan memastikan jti belum dicabut.
+// Dipakai oleh middleware.AuthRequired.
+func (s *AuthService) ValidateAccessToken(tokenString string) (*model.JWTClaims, error) {
+	claims, err := helper.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ID == "" {
+		return nil, errors.New("token tidak memiliki jti")
+	}
+	// Token challenge 2FA tidak boleh dipakai sebagai access token
+	if claims.Purpose != "" {
+		return nil, errors.New("token bukan access token")
+	}
+
+	revoked, err := s.tokenRepo.IsAccessTokenRevoked(claims.ID)
+	if err != nil {
+		log.Printf("[ERROR] AuthService IsAccessTokenRevoked: %v", err)
+		return nil, errors.New("gagal memeriksa status token")
+	}
+	if revoked {
+		return nil, errors.New("token sudah dicabut")
+	}
+	return claims, nil
+}
+
+func (s *AuthService) Register(req model.RegisterRequest) (*model.User, error) {
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+
+	if err := helper.ValidateRegister(req.Username, req.Email, req.Password); err != nil {
+		return nil, err
+	}
+
+	hash, err := helper.HashPassword(req.Password)
+	if err != nil {
+		log.Printf("[ERROR] AuthService HashPassword: %v", err)
+		return nil, errors.New("gagal memproses password")
+	}
+
+	// Pendaftaran mandiri selalu mendapat role "user"
+	return s.authRepo.CreateUser(&model.User{
+		Username:     req.Username,
+		Email:        req.Email,
+		PasswordHash: hash,
+		Role:         "user",
+	})
+}
+
+// ChangePassword mengganti password user yang sedang login. Semua sesi lain
+// dicabut sehingga user harus login ulang di perangkat lain.
+func (s *AuthService) ChangePassword(userID string, req model.ChangePasswordRequest) error {
+	currentHash, err := s.authRepo.GetPasswordHash(userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.New("user tidak ditemukan")
+		}
+		log.Printf("[ERROR] AuthService GetPasswordHash: %v", err)
+		return errors.New("error database")
+	}
+
+	if !helper.CheckPassword(req.CurrentPassword, currentHash) {
+		return errors.New("password saat ini salah")
+	}
+	if req.CurrentPassword == req.NewPassword {
+		return errors.New("password baru harus berbeda dari password saat ini")
+	}
+
+	return s.setPassword(userID, req.NewPassword, "password_changed")
+}
+
+// ForgotPassword mengirim link reset ke email user. Selalu mengembalikan nil untuk
+// email yang tidak terdaftar agar endpoint tidak bisa dipakai menebak akun.
+func (s *AuthService) ForgotPassword(email, ip string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return errors.New("email harus diisi")
+	}
+
+	user, _, err := s.authRepo.GetUserByUsernameOrEmail(email)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("[ERROR] AuthService ForgotPassword: %v", err)
+		}
+		return nil
+	}
+	if user.IsDisabled || user.Email != email {
+		return nil
+	}
+
+	// Hanya token terbaru yang berlaku
+	if err := s.resetRepo.InvalidateForUser(user.ID); err != nil {
+		log.Printf("[ERROR] AuthService InvalidateForUser: %v", err)
+		return errors.New("error database")
+	}
+
+	token, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		log.Printf("[ERROR] AuthService GenerateOpaqueToken: %v", err)
+		return errors.New("gagal membuat token reset")
+	}
+
+	reset := &model.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: helper.HashToken(token),
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(s.cfg.PasswordResetTTL),
+		RequestIP: ip,
+	}
+	if err := s.resetRepo.Create(reset); err != nil {
+		log.Printf("[ERROR] AuthService CreatePasswordReset: %v", err)
+		return errors.New("error database")
+	}
+
+	link := s.cfg.PasswordResetURL + "?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Halo %s,\n\n"+
+		"Kami menerima permintaan reset password untuk akun Anda.\n"+
+		"Buka link berikut untuk membuat password baru (berlaku %s):\n\n%s\n\n"+
+		"Jika Anda tidak merasa meminta reset password, abaikan email ini.\n",
+		user.Username, s.cfg.PasswordResetTTL, link)
+
+	if err := s.mailer.Send(user.Email, "Reset Password Alumni CRUD API", body); err != nil {
+		log.Printf("[ERROR] AuthService kirim email reset ke %s: %v", user.Email, err)
+	}
+	return nil
+}
+
+// ResetPassword memakai token reset (sekali pakai) untuk mengganti password
+// ResetPassword mengembalikan ID user yang password-nya direset
+func (s *AuthService) ResetPassword(req model.ResetPasswordRequest) (string, error) {
+	if req.Token == "" {
+		return "", errors.New("token reset harus diisi")
+	}
+	// Validasi dulu agar token tidak terbuang karena password tidak valid
+	if err := helper.ValidatePassword(req.NewPassword); err != nil {
+		return "", err
+	}
+
+	reset, err := s.resetRepo.Consume(helper.HashToken(req.Token))
+	if err != nil {
+		if err.Error() == "token reset tidak valid atau sudah kedaluwarsa" {
+			return "", err
+		}
+		log.Printf("[ERROR] AuthService ConsumePasswordReset: %v", err)
+		return "", errors.New("error database")
+	}
+
+	if err := s.setPassword(reset.UserID.Hex(), req.NewPassword, "password_reset"); err != nil {
+		return "", err
+	}
+	// Token yang sampai ke user membuktikan email tujuannya miliknya
+	if reset.Email != "" {
+		if err := s.authRepo.MarkEmailVerified(reset.UserID, reset.Email); err != nil {
+			log.Printf("[ERROR] AuthService MarkEmailVerified: %v", err)
+		}
+	}
+	return reset.UserID.Hex(), nil
+}
+
+func (s *AuthService) setPassword(userID, newPassword, reason string) error {
+	if err := helper.ValidatePassword(newPassword); err != nil {
+		return err
+	}
+
+	hash, err := helper.HashPassword(newPassword)
+	if err != nil {
+		log.Printf("[ERROR] AuthService HashPassword: %v", err)
+		return errors.New("gagal memproses password")
+	}
+
+	if err := s.authRepo.UpdatePassword(userID, hash); err != nil {
+		if err.Error() == "user tidak ditemukan" {
+			return err
+		}
+		log.Printf("[ERROR] AuthService UpdatePassword: %v", err)
+		return errors.New("error database")
+	}
+
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	if err := s.tokenRepo.RevokeAllForUser(userObjID, reason); err != nil {
+		log.Printf("[ERROR] AuthService RevokeAllForUser: %v", err)
+	}
+	if err := s.resetRepo.InvalidateForUser(userObjID); err != nil {
+		log.Printf("[ERROR] AuthService InvalidateForUser: %v", err)
+	}
+	return nil
+}
+
 func (s *AuthService) GetProfile(userID string) (*model.User, error) {
 	user, err := s.authRepo.GetUserByID(userID)
 	if err != nil {
@@ -67,14 +535,16 @@ func (s *AuthService) GetProfile(userID string) (*model.User, error) {
 
 // HandleLogin godoc
 // @Summary Login Pengguna
-// @Description Autentikasi pengguna dan dapatkan token JWT.
+// @Description Autentikasi pengguna dan dapatkan token JWT. Jika 2FA aktif, response berisi mfa_token untuk /auth/2fa/login.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param body body model.LoginRequest true "Kredensial Login"
 // @Success 200 {object} helper.Response{data=model.LoginResponse} "Login Berhasil"
+// @Success 202 {object} helper.Response{data=model.MFAChallenge} "Verifikasi 2FA diperlukan"
 // @Failure 400 {object} helper.Response "Request body tidak valid"
 // @Failure 401 {object} helper.Response "Username atau password salah"
+// @Failure 429 {object} helper.Response "Terlalu banyak percobaan gagal (lihat header Retry-After)"
 // @Router /auth/login [post]
 
 func (s *AuthService) HandleLogin(c *fiber.Ctx) error {
@@ -87,14 +557,265 @@ func (s *AuthService) HandleLogin(c *fiber.Ctx) error {
 		return helper.ErrorResponse(c, 400, "Username dan password harus diisi")
 	}
 
-	response, err := s.Login(req)
+	response, challenge, err := s.Login(req, c.IP(), c.Get("User-Agent"))
 	if err != nil {
+		var lockedErr *LoginLockedError
+		if errors.As(err, &lockedErr) {
+			retryAfter := int(time.Until(lockedErr.Until).Seconds()) + 1
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			return helper.ErrorResponse(c, 429, err.Error())
+		}
+		if err.Error() == "error database" {
+			return helper.ErrorResponse(c, 500, "Gagal memproses login")
+		}
+		if err.Error() == "akun dinonaktifkan" {
+			return helper.ErrorResponse(c, 403, "Akun Anda dinonaktifkan")
+		}
+		if err.Error() == "gagal generate token" {
+			return helper.ErrorResponse(c, 500, "Gagal memproses login")
+		}
 		return helper.ErrorResponse(c, 401, err.Error())
 	}
 
+	if challenge != nil {
+		// 202: password benar tapi login belum selesai
+		c.Status(fiber.StatusAccepted)
+		if challenge.MFASetupRequired {
+			return helper.SuccessResponse(c, "Admin wajib mengaktifkan 2FA sebelum login", challenge)
+		}
+		return helper.SuccessResponse(c, "Verifikasi 2FA diperlukan", challenge)
+	}
 	return helper.SuccessResponse(c, "Login berhasil", response)
 }
 
+// HandleRefresh godoc
+// @Summary Refresh Access Token
+// @Description Menukar refresh token dengan pasangan access + refresh token baru (rotasi).
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.RefreshTokenRequest true "Refresh Token"
+// @Success 200 {object} helper.Response{data=model.LoginResponse} "Token diperbarui"
+// @Failure 400 {object} helper.Response "Request body tidak valid"
+// @Failure 401 {object} helper.Response "Refresh token tidak valid atau sudah digunakan"
+// @Router /auth/refresh [post]
+
+func (s *AuthService) HandleRefresh(c *fiber.Ctx) error {
+	var req model.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return helper.ErrorResponse(c, 400, "Refresh token harus diisi")
+	}
+
+	response, err := s.Refresh(req.RefreshToken, c.IP(), c.Get("User-Agent"))
+	if err != nil {
+		switch err.Error() {
+		case "error database":
+			return helper.ErrorResponse(c, 500, "Gagal memperbarui token")
+		case "akun dinonaktifkan":
+			return helper.ErrorResponse(c, 403, "Akun Anda dinonaktifkan")
+		default:
+			return helper.ErrorResponse(c, 401, err.Error())
+		}
+	}
+
+	return helper.SuccessResponse(c, "Token berhasil diperbarui", response)
+}
+
+// HandleLogout godoc
+// @Summary Logout
+// @Description Mencabut access token saat ini dan (opsional) sesi refresh token-nya.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth[]
+// @Param body body model.LogoutRequest false "Refresh Token (opsional)"
+// @Success 200 {object} helper.Response "Logout berhasil"
+// @Failure 401 {object} helper.Response "Token tidak valid"
+// @Router /auth/logout [post]
+
+func (s *AuthService) HandleLogout(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	jti := c.Locals("jti").(string)
+	expiresAt := c.Locals("token_expires_at").(time.Time)
+
+	var req model.LogoutRequest
+	_ = c.BodyParser(&req) // Body opsional
+
+	if err := s.Logout(userID, jti, expiresAt, req.RefreshToken); err != nil {
+		if err.Error() == "refresh token tidak valid" {
+			return helper.ErrorResponse(c, 400, "Refresh token tidak valid")
+		}
+		return helper.ErrorResponse(c, 500, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "Logout berhasil", nil)
+}
+
+// HandleLogoutAll godoc
+// @Summary Logout dari Semua Perangkat
+// @Description Mencabut semua refresh token dan access token aktif milik user.
+// @Tags Auth
+// @Produce json
+// @Security ApiKeyAuth[]
+// @Success 200 {object} helper.Response "Logout dari semua sesi berhasil"
+// @Failure 401 {object} helper.Response "Token tidak valid"
+// @Router /auth/logout-all [post]
+
+func (s *AuthService) HandleLogoutAll(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	jti := c.Locals("jti").(string)
+	expiresAt := c.Locals("token_expires_at").(time.Time)
+
+	if err := s.LogoutAll(userID, jti, expiresAt); err != nil {
+		return helper.ErrorResponse(c, 500, err.Error())
+	}
+	s.audit.Record(c, model.AuditActionLogoutAll, model.AuditEntityUser, userID, nil, nil)
+
+	return helper.SuccessResponse(c, "Logout dari semua sesi berhasil", nil)
+}
+
+// HandleJWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public key yang dipakai untuk memverifikasi token yang diterbitkan API ini.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "JWKS"
+// @Router /.well-known/jwks.json [get]
+
+func (s *AuthService) HandleJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(helper.JWKS())
+}
+
+// HandleChangePassword godoc
+// @Summary Ganti Password
+// @Description Mengganti password user yang sedang login. Semua sesi dicabut setelah berhasil.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth[]
+// @Param body body model.ChangePasswordRequest true "Password lama dan baru"
+// @Success 200 {object} helper.Response "Password berhasil diubah"
+// @Failure 400 {object} helper.Response "Password baru tidak valid"
+// @Failure 401 {object} helper.Response "Password saat ini salah"
+// @Router /auth/password [put]
+
+func (s *AuthService) HandleChangePassword(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req model.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, 400, "Request body tidak valid")
+	}
+
+	if err := s.ChangePassword(userID, req); err != nil {
+		switch err.Error() {
+		case "password saat ini salah":
+			return helper.ErrorResponse(c, 401, "Password saat ini salah")
+		case "user tidak ditemukan":
+			return helper.ErrorResponse(c, 404, "User tidak ditemukan")
+		case "error database", "gagal memproses password":
+			return helper.ErrorResponse(c, 500, "Gagal mengubah password")
+		default:
+			return helper.ErrorResponse(c, 400, err.Error())
+		}
+	}
+	s.audit.Record(c, model.AuditActionPasswordChange, model.AuditEntityUser, userID, nil, nil)
+
+	return helper.SuccessResponse(c, "Password berhasil diubah, silakan login kembali", nil)
+}
+
+// HandleForgotPassword godoc
+// @Summary Lupa Password
+// @Description Mengirim link reset password ke email jika terdaftar.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.ForgotPasswordRequest true "Email akun"
+// @Success 200 {object} helper.Response "Instruksi reset dikirim jika email terdaftar"
+// @Failure 400 {object} helper.Response "Email harus diisi"
+// @Router /auth/forgot-password [post]
+
+func (s *AuthService) HandleForgotPassword(c *fiber.Ctx) error {
+	var req model.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, 400, "Request body tidak valid")
+	}
+
+	if err := s.ForgotPassword(req.Email, c.IP()); err != nil {
+		if err.Error() == "email harus diisi" {
+			return helper.ErrorResponse(c, 400, "Email harus diisi")
+		}
+		return helper.ErrorResponse(c, 500, "Gagal memproses permintaan reset password")
+	}
+
+	return helper.SuccessResponse(c, "Jika email terdaftar, instruksi reset password telah dikirim", nil)
+}
+
+// HandleResetPassword godoc
+// @Summary Reset Password
+// @Description Mengganti password memakai token dari email reset.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.ResetPasswordRequest true "Token dan password baru"
+// @Success 200 {object} helper.Response "Password berhasil direset"
+// @Failure 400 {object} helper.Response "Token tidak valid atau password tidak valid"
+// @Router /auth/reset-password [post]
+
+func (s *AuthService) HandleResetPassword(c *fiber.Ctx) error {
+	var req model.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, 400, "Request body tidak valid")
+	}
+
+	userID, err := s.ResetPassword(req)
+	if err != nil {
+		switch err.Error() {
+		case "error database", "gagal memproses password":
+			return helper.ErrorResponse(c, 500, "Gagal mereset password")
+		default:
+			return helper.ErrorResponse(c, 400, err.Error())
+		}
+	}
+	s.audit.Record(c, model.AuditActionPasswordReset, model.AuditEntityUser, userID, nil, nil)
+
+	return helper.SuccessResponse(c, "Password berhasil direset, silakan login", nil)
+}
+
+// HandleRegister godoc
+// @Summary Registrasi Pengguna
+// @Description Mendaftarkan akun baru dengan role "user".
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.RegisterRequest true "Data Registrasi"
+// @Success 201 {object} helper.Response{data=model.User} "Registrasi Berhasil"
+// @Failure 400 {object} helper.Response "Request body tidak valid"
+// @Failure 409 {object} helper.Response "Username atau email sudah digunakan"
+// @Router /auth/register [post]
+
+func (s *AuthService) HandleRegister(c *fiber.Ctx) error {
+	var req model.RegisterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, 400, "Request body tidak valid")
+	}
+
+	user, err := s.Register(req)
+	if err != nil {
+		if err.Error() == "username atau email sudah digunakan" {
+			return helper.ErrorResponse(c, 409, "Username atau email sudah digunakan")
+		}
+		if err.Error() == "gagal memproses password" {
+			return helper.ErrorResponse(c, 500, err.Error())
+		}
+		return helper.ErrorResponse(c, 400, err.Error())
+	}
+	s.audit.Record(c, model.AuditActionRegister, model.AuditEntityUser, user.ID.Hex(), nil, user)
+
+	return helper.CreatedResponse(c, "Registrasi berhasil", user)
+}
+
 // HandleGetProfile godoc
 // @Summary Get Profil Pengguna
 // @Description Mengambil profil pengguna yang sedang login (berdasarkan token).
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"alumni-crud-api/app/model"
+	"alumni-crud-api/config"
+	"alumni-crud-api/helper"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type authTestEnv struct {
+	service  *AuthService
+	authRepo *fakeAuthRepo
+	tokens   *fakeTokenRepo
+	attempts *fakeAttemptRepo
+}
+
+func newAuthTestEnv(t *testing.T, cfg *config.Config, users ...*model.User) *authTestEnv {
+	t.Helper()
+	setupTestJWT(t)
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	env := &authTestEnv{authRepo: newFakeAuthRepo(users...), tokens: &fakeTokenRepo{}, attempts: newFakeAttemptRepo()}
+	env.service = NewAuthService(env.authRepo, env.tokens, nil, env.attempts, nil, nil, cfg)
+	return env
+}
+
+// session menerbitkan sesi baru (family baru) seperti setelah login
+func (env *authTestEnv) session(t *testing.T, user *model.User, family string) *model.LoginResponse {
+	t.Helper()
+	clone := *user
+	response, err := env.service.issueTokens(&clone, family, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("issueTokens: %v", err)
+	}
+	return response
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	budi := &model.User{Username: "budi", Role: model.RoleUser}
+	env := newAuthTestEnv(t, nil, budi)
+	first := env.session(t, budi, "family-1")
+
+	second, err := env.service.Refresh(first.RefreshToken, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.Token == first.Token {
+		t.Error("Refresh mengembalikan token yang sama")
+	}
+
+	tokens := env.tokens.family("family-1")
+	if len(tokens) != 2 {
+		t.Fatalf("family berisi %d token, want 2", len(tokens))
+	}
+	if tokens[0].UsedAt == nil || tokens[1].UsedAt != nil {
+		t.Errorf("UsedAt = %v, %v; want token lama terpakai dan token baru belum", tokens[0].UsedAt, tokens[1].UsedAt)
+	}
+
+	// Token hasil rotasi tetap bisa dirotasi lagi
+	if _, err := env.service.Refresh(second.RefreshToken, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("Refresh kedua: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	budi := &model.User{Username: "budi", Role: model.RoleUser}
+	env := newAuthTestEnv(t, nil, budi)
+	stolen := env.session(t, budi, "family-1")
+	other := env.session(t, budi, "family-2")
+
+	rotated, err := env.service.Refresh(stolen.RefreshToken, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	// Token lama dipakai lagi (mis. dicuri): seluruh family dicabut
+	if _, err := env.service.Refresh(stolen.RefreshToken, "10.0.0.9", "attacker"); err == nil || err.Error() != "refresh token sudah digunakan" {
+		t.Fatalf("reuse error = %v, want refresh token sudah digunakan", err)
+	}
+	if _, err := env.service.Refresh(rotated.RefreshToken, "127.0.0.1", "test"); err == nil || err.Error() != "refresh token tidak valid" {
+		t.Errorf("token hasil rotasi setelah reuse error = %v, want tidak valid", err)
+	}
+	for _, token := range env.tokens.family("family-1") {
+		if token.RevokedAt == nil || token.RevokeReason != "reuse_detected" {
+			t.Errorf("token %s RevokedAt=%v reason=%q", token.ID.Hex(), token.RevokedAt, token.RevokeReason)
+		}
+		if env.tokens.revoked[token.AccessJTI] != "reuse_detected" {
+			t.Errorf("access token %s belum dicabut", token.AccessJTI)
+		}
+	}
+
+	// Sesi lain milik user yang sama tidak ikut dicabut
+	if _, err := env.service.Refresh(other.RefreshToken, "127.0.0.1", "test"); err != nil {
+		t.Errorf("Refresh family lain: %v", err)
+	}
+}
+
+func TestRefreshConcurrentReuse(t *testing.T) {
+	budi := &model.User{Username: "budi", Role: model.RoleUser}
+	env := newAuthTestEnv(t, nil, budi)
+	session := env.session(t, budi, "family-1")
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = env.service.Refresh(session.RefreshToken, "127.0.0.1", "test")
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+		} else if err.Error() != "refresh token sudah digunakan" {
+			t.Errorf("error = %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d refresh berhasil, want tepat 1", succeeded)
+	}
+	for _, token := range env.tokens.family("family-1") {
+		if token.RevokedAt == nil {
+			t.Errorf("token %s tidak dicabut setelah dipakai bersamaan", token.ID.Hex())
+		}
+	}
+}
+
+func TestRefreshRejectsInvalidTokens(t *testing.T) {
+	budi := &model.User{Username: "budi", Role: model.RoleUser}
+	nonaktif := &model.User{Username: "nonaktif", Role: model.RoleUser}
+	env := newAuthTestEnv(t, nil, budi, nonaktif)
+
+	expired := env.session(t, budi, "family-expired")
+	env.tokens.refresh[0].ExpiresAt = time.Now().Add(-time.Minute)
+	disabled := env.session(t, nonaktif, "family-disabled")
+	nonaktif.IsDisabled = true
+
+	tests := map[string]struct {
+		token   string
+		wantErr string
+	}{
+		"tidak dikenal": {"token-asal", "refresh token tidak valid"},
+		"kedaluwarsa":   {expired.RefreshToken, "refresh token tidak valid"},
+		"user nonaktif": {disabled.RefreshToken, "akun dinonaktifkan"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := env.service.Refresh(tt.token, "127.0.0.1", "test"); err == nil || err.Error() != tt.wantErr {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+	if tokens := env.tokens.family("family-disabled"); tokens[0].RevokeReason != "user_disabled" {
+		t.Errorf("family user nonaktif reason = %q, want user_disabled", tokens[0].RevokeReason)
+	}
+}
+
+func lockoutConfig() *config.Config {
+	return &config.Config{
+		LoginMaxFailures:   3,
+		LoginIPMaxFailures: 5,
+		LoginFailureWindow: time.Hour,
+		LoginLockoutBase:   time.Minute,
+		LoginLockoutMax:    4 * time.Minute,
+	}
+}
+
+func userWithPassword(t *testing.T, username, password string) *model.User {
+	t.Helper()
+	hash, err := helper.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &model.User{Username: username, Email: username + "@kampus.ac.id", PasswordHash: hash, Role: model.RoleUser}
+}
+
+func (env *authTestEnv) login(username, password, ip string) error {
+	_, _, err := env.service.Login(model.LoginRequest{Username: username, Password: password}, ip, "test")
+	return err
+}
+
+// lockedFor mengembalikan sisa durasi kunci key (0 jika tidak terkunci)
+func (env *authTestEnv) lockedFor(key string) time.Duration {
+	throttle, _ := env.attempts.GetThrottle(key)
+	if throttle == nil || throttle.LockedUntil == nil {
+		return 0
+	}
+	return time.Until(*throttle.LockedUntil).Round(time.Minute)
+}
+
+func TestLoginLocksAccountAfterMaxFailures(t *testing.T) {
+	budi := userWithPassword(t, "budi", "rahasia123")
+	env := newAuthTestEnv(t, lockoutConfig(), budi)
+
+	for i := 0; i < 3; i++ {
+		if err := env.login("budi", "salah", "10.0.0.1"); err == nil || err.Error() != "username atau password salah" {
+			t.Fatalf("percobaan %d error = %v", i+1, err)
+		}
+	}
+	if got := env.lockedFor("user:" + budi.ID.Hex()); got != time.Minute {
+		t.Fatalf("kunci akun = %s, want 1m", got)
+	}
+
+	// Password benar pun ditolak selama terkunci, termasuk lewat email dan IP lain
+	for _, identifier := range []string{"budi", "BUDI@kampus.ac.id"} {
+		var locked *LoginLockedError
+		if err := env.login(identifier, "rahasia123", "10.0.0.2"); !errors.As(err, &locked) {
+			t.Errorf("login %s saat terkunci error = %v, want LoginLockedError", identifier, err)
+		}
+	}
+
+	want := []string{
+		model.LoginResultInvalidCredentials, model.LoginResultInvalidCredentials, model.LoginResultInvalidCredentials,
+		model.LoginResultLocked, model.LoginResultLocked,
+	}
+	if got := env.attempts.results(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("riwayat login = %v, want %v", got, want)
+	}
+}
+
+func TestLoginLockoutDoublesUpToMax(t *testing.T) {
+	budi := userWithPassword(t, "budi", "rahasia123")
+	env := newAuthTestEnv(t, lockoutConfig(), budi)
+	key := "user:" + budi.ID.Hex()
+
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 4 * time.Minute} {
+		// Kunci sebelumnya dianggap sudah lewat; penghitung kegagalan tetap
+		if throttle := env.attempts.throttles[key]; throttle != nil {
+			throttle.LockedUntil = nil
+		}
+		for attempt := 0; env.lockedFor(key) == 0; attempt++ {
+			// IP berganti agar kunci per IP tidak ikut berlaku
+			ip := fmt.Sprintf("10.0.%d.%d", len(env.attempts.attempts), attempt)
+			if err := env.login("budi", "salah", ip); err == nil || attempt > 3 {
+				t.Fatalf("percobaan %d error = %v", attempt+1, err)
+			}
+		}
+		if got := env.lockedFor(key); got != want {
+			t.Errorf("kunci setelah %d kegagalan = %s, want %s", env.attempts.throttles[key].Failures, got, want)
+		}
+	}
+}
+
+func TestLoginLocksIPAcrossAccounts(t *testing.T) {
+	budi := userWithPassword(t, "budi", "rahasia123")
+	env := newAuthTestEnv(t, lockoutConfig(), budi)
+
+	// Menebak banyak akun (termasuk yang tidak ada) dari satu IP
+	for _, username := range []string{"andi", "citra", "dewi", "eka", "fajar"} {
+		if err := env.login(username, "salah", "10.0.0.9"); err == nil {
+			t.Fatalf("login %s berhasil", username)
+		}
+	}
+
+	var locked *LoginLockedError
+	if err := env.login("budi", "rahasia123", "10.0.0.9"); !errors.As(err, &locked) {
+		t.Errorf("login dari IP terkunci error = %v, want LoginLockedError", err)
+	}
+	if err := env.login("budi", "rahasia123", "10.0.0.1"); err != nil {
+		t.Errorf("login dari IP lain error = %v", err)
+	}
+}
+
+func TestLoginSuccessResetsAccountCounter(t *testing.T) {
+	budi := userWithPassword(t, "budi", "rahasia123")
+	env := newAuthTestEnv(t, lockoutConfig(), budi)
+
+	for i := 0; i < 2; i++ {
+		_ = env.login("budi", "salah", "10.0.0.1")
+	}
+	if err := env.login("budi", "rahasia123", "10.0.0.1"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if throttle, _ := env.attempts.GetThrottle("user:" + budi.ID.Hex()); throttle != nil {
+		t.Errorf("penghitung akun = %+v, want direset", throttle)
+	}
+	if throttle, _ := env.attempts.GetThrottle("ip:10.0.0.1"); throttle == nil || throttle.Failures != 2 {
+		t.Errorf("penghitung IP = %+v, want tetap 2", throttle)
+	}
+
+	// Dua kegagalan berikutnya belum mengunci karena hitungan mulai dari nol
+	for i := 0; i < 2; i++ {
+		_ = env.login("budi", "salah", "10.0.0.1")
+	}
+	if got := env.lockedFor("user:" + budi.ID.Hex()); got != 0 {
+		t.Errorf("akun terkunci %s setelah reset", got)
+	}
+}
@@ -0,0 +1,507 @@
+package service
+
+import (
+	"alumni-crud-api/app/model"
+	"alumni-crud-api/app/repository"
+	"alumni-crud-api/config"
+	"alumni-crud-api/helper"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Repository in-memory untuk test service. Interface repository di-embed
+// sehingga method yang tidak dipakai test akan panic jika terpanggil.
+
+// setupTestJWT memasang kunci HS256 untuk token yang diterbitkan service
+func setupTestJWT(t *testing.T) {
+	t.Helper()
+	err := helper.SetupJWT(&config.Config{
+		JWTSecret:     "secret-test-minimal-32-karakter!!",
+		JWTSecretKID:  "test",
+		JWTIssuer:     "alumni-test",
+		JWTAccessTTL:  15 * time.Minute,
+		JWTRefreshTTL: 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+type fakeAuthRepo struct {
+	repository.AuthRepository
+	mu    sync.Mutex
+	users []*model.User
+}
+
+func newFakeAuthRepo(users ...*model.User) *fakeAuthRepo {
+	for _, u := range users {
+		if u.ID.IsZero() {
+			u.ID = primitive.NewObjectID()
+		}
+	}
+	return &fakeAuthRepo{users: users}
+}
+
+// find mengembalikan salinan user (tanpa hash) dan hash password-nya
+func (r *fakeAuthRepo) find(match func(u *model.User) bool) (*model.User, string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if match(u) {
+			clone := *u
+			clone.PasswordHash = ""
+			return &clone, u.PasswordHash, nil
+		}
+	}
+	return nil, "", mongo.ErrNoDocuments
+}
+
+func (r *fakeAuthRepo) byID(id primitive.ObjectID) *model.User {
+	for _, u := range r.users {
+		if u.ID == id {
+			return u
+		}
+	}
+	return nil
+}
+
+func (r *fakeAuthRepo) GetUserByID(id string) (*model.User, error) {
+	user, _, err := r.find(func(u *model.User) bool { return u.ID.Hex() == id })
+	return user, err
+}
+
+func (r *fakeAuthRepo) GetUserByEmail(email string) (*model.User, error) {
+	user, _, err := r.find(func(u *model.User) bool { return strings.EqualFold(u.Email, email) })
+	return user, err
+}
+
+func (r *fakeAuthRepo) GetUserByUsernameOrEmail(identifier string) (*model.User, string, error) {
+	return r.find(func(u *model.User) bool {
+		return strings.EqualFold(u.Username, identifier) || strings.EqualFold(u.Email, identifier)
+	})
+}
+
+func (r *fakeAuthRepo) GetUserByOIDCSubject(subject string) (*model.User, error) {
+	user, _, err := r.find(func(u *model.User) bool { return u.OIDCSubject == subject })
+	return user, err
+}
+
+func (r *fakeAuthRepo) LinkOIDCSubject(id primitive.ObjectID, subject string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.OIDCSubject == subject && u.ID != id {
+			return fmt.Errorf("akun SSO sudah terhubung ke user lain")
+		}
+	}
+	user := r.byID(id)
+	if user == nil || user.OIDCSubject != "" {
+		return fmt.Errorf("user sudah terhubung ke akun SSO lain")
+	}
+	user.OIDCSubject = subject
+	return nil
+}
+
+func (r *fakeAuthRepo) MarkEmailVerified(id primitive.ObjectID, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user := r.byID(id); user != nil && user.Email == email {
+		user.VerifiedEmail = email
+	}
+	return nil
+}
+
+func (r *fakeAuthRepo) CreateUser(user *model.User) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if strings.EqualFold(u.Username, user.Username) || strings.EqualFold(u.Email, user.Email) {
+			return nil, fmt.Errorf("username atau email sudah digunakan")
+		}
+	}
+	stored := *user
+	stored.ID = primitive.NewObjectID()
+	r.users = append(r.users, &stored)
+	user.ID = stored.ID
+	user.PasswordHash = ""
+	return user, nil
+}
+
+type fakeStateRepo struct {
+	repository.OIDCStateRepository
+	mu     sync.Mutex
+	states map[string]*model.OIDCLoginState
+}
+
+func newFakeStateRepo() *fakeStateRepo {
+	return &fakeStateRepo{states: map[string]*model.OIDCLoginState{}}
+}
+
+func (r *fakeStateRepo) Create(state *model.OIDCLoginState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state.ID = primitive.NewObjectID()
+	clone := *state
+	r.states[state.StateHash] = &clone
+	return nil
+}
+
+func (r *fakeStateRepo) Consume(stateHash string) (*model.OIDCLoginState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.states[stateHash]
+	if !ok || !state.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("state login SSO tidak valid atau sudah kedaluwarsa")
+	}
+	delete(r.states, stateHash)
+	return state, nil
+}
+
+type fakeTokenRepo struct {
+	repository.TokenRepository
+	mu      sync.Mutex
+	refresh []*model.RefreshToken
+	revoked map[string]string // jti access token -> alasan
+}
+
+func (r *fakeTokenRepo) CreateRefreshToken(token *model.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token.ID = primitive.NewObjectID()
+	token.CreatedAt = time.Now()
+	clone := *token
+	r.refresh = append(r.refresh, &clone)
+	return nil
+}
+
+func (r *fakeTokenRepo) GetRefreshTokenByHash(hash string) (*model.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.refresh {
+		if t.TokenHash == hash {
+			clone := *t
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("refresh token tidak ditemukan")
+}
+
+func (r *fakeTokenRepo) MarkRefreshTokenUsed(id primitive.ObjectID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.refresh {
+		if t.ID == id && t.UsedAt == nil && t.RevokedAt == nil {
+			now := time.Now()
+			t.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeTokenRepo) RevokeFamily(familyID string, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.revoked == nil {
+		r.revoked = map[string]string{}
+	}
+	now := time.Now()
+	for _, t := range r.refresh {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			t.RevokedAt, t.RevokeReason = &now, reason
+			r.revoked[t.AccessJTI] = reason
+		}
+	}
+	return nil
+}
+
+// family mengembalikan salinan semua refresh token dalam satu family
+func (r *fakeTokenRepo) family(familyID string) []model.RefreshToken {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var tokens []model.RefreshToken
+	for _, t := range r.refresh {
+		if t.FamilyID == familyID {
+			tokens = append(tokens, *t)
+		}
+	}
+	return tokens
+}
+
+type fakeAttemptRepo struct {
+	repository.LoginAttemptRepository
+	mu        sync.Mutex
+	attempts  []model.LoginAttempt
+	throttles map[string]*model.LoginThrottle
+}
+
+func newFakeAttemptRepo() *fakeAttemptRepo {
+	return &fakeAttemptRepo{throttles: map[string]*model.LoginThrottle{}}
+}
+
+func (r *fakeAttemptRepo) Record(attempt *model.LoginAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts = append(r.attempts, *attempt)
+	return nil
+}
+
+func (r *fakeAttemptRepo) results() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := make([]string, len(r.attempts))
+	for i, a := range r.attempts {
+		results[i] = a.Result
+	}
+	return results
+}
+
+func (r *fakeAttemptRepo) GetThrottle(key string) (*model.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t, ok := r.throttles[key]; ok {
+		clone := *t
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeAttemptRepo) RegisterFailure(key string, window time.Duration) (*model.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.throttles[key]
+	if !ok || time.Since(t.LastFailureAt) > window {
+		t = &model.LoginThrottle{Key: key}
+		r.throttles[key] = t
+	}
+	t.Failures++
+	t.LastFailureAt = time.Now()
+	clone := *t
+	return &clone, nil
+}
+
+func (r *fakeAttemptRepo) SetLockedUntil(key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t, ok := r.throttles[key]; ok {
+		t.LockedUntil = &until
+	}
+	return nil
+}
+
+func (r *fakeAttemptRepo) ResetThrottle(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.throttles, key)
+	return nil
+}
+
+type fakeAlumniRepo struct {
+	repository.AlumniRepository
+	alumni []*model.Alumni
+}
+
+func (r *fakeAlumniRepo) GetByNIM(nim string) (*model.Alumni, error) {
+	for _, a := range r.alumni {
+		if a.NIM == nim {
+			clone := *a
+			return &clone, nil
+		}
+	}
+	return nil, errors.New("alumni tidak ditemukan")
+}
+
+func (r *fakeAlumniRepo) GetByID(id string) (*model.Alumni, error) {
+	for _, a := range r.alumni {
+		if a.ID.Hex() == id {
+			clone := *a
+			return &clone, nil
+		}
+	}
+	return nil, errors.New("alumni tidak ditemukan")
+}
+
+func (r *fakeAlumniRepo) GetByUserID(userID string) (*model.Alumni, error) {
+	return nil, errors.New("alumni tidak ditemukan")
+}
+
+func (r *fakeAlumniRepo) LinkUserByEmailOrNIM(userID primitive.ObjectID, email, nim string) (*model.Alumni, error) {
+	for _, a := range r.alumni {
+		if a.UserID.IsZero() && ((nim != "" && a.NIM == nim) || (email != "" && strings.EqualFold(a.Email, email))) {
+			a.UserID = userID
+			a.Version++
+			clone := *a
+			return &clone, nil
+		}
+	}
+	return nil, errors.New("alumni tidak ditemukan")
+}
+
+type fakeClaimRepo struct {
+	repository.AlumniClaimRepository
+	mu     sync.Mutex
+	claims []*model.AlumniClaim
+}
+
+func (r *fakeClaimRepo) Create(claim *model.AlumniClaim) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	claim.ID = primitive.NewObjectID()
+	claim.CreatedAt = time.Now()
+	clone := *claim
+	r.claims = append(r.claims, &clone)
+	return nil
+}
+
+func (r *fakeClaimRepo) InvalidateForUser(alumniID, userID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, c := range r.claims {
+		if c.AlumniID == alumniID && c.UserID == userID && c.UsedAt == nil {
+			c.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeClaimRepo) ListSince(alumniID primitive.ObjectID, since time.Time) ([]model.AlumniClaim, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var claims []model.AlumniClaim
+	for _, c := range r.claims {
+		if c.AlumniID == alumniID && !c.CreatedAt.Before(since) {
+			claims = append(claims, *c)
+		}
+	}
+	return claims, nil
+}
+
+// active mengembalikan klaim user yang masih bisa dipakai
+func (r *fakeClaimRepo) active(userID primitive.ObjectID) []*model.AlumniClaim {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var active []*model.AlumniClaim
+	for _, c := range r.claims {
+		if c.UserID == userID && c.UsedAt == nil && c.Attempts < repository.MaxClaimAttempts {
+			active = append(active, c)
+		}
+	}
+	return active
+}
+
+// fakeMailer menyimpan email yang dikirim
+type fakeMailer struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (m *fakeMailer) Send(to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, to)
+	return nil
+}
+
+// fakeAuditLogRepo bisa dibuat gagal untuk menguji penyimpanan best-effort
+type fakeAuditLogRepo struct {
+	repository.AuditLogRepository
+	mu      sync.Mutex
+	entries []*model.AuditLog
+	fail    bool
+}
+
+func (r *fakeAuditLogRepo) Insert(entry *model.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail {
+		return errors.New("audit log tidak tersedia")
+	}
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+// fakeVersionRepo meniru unique index (entity, version) dan penomoran Append
+type fakeVersionRepo struct {
+	repository.RecordVersionRepository
+	mu       sync.Mutex
+	versions []*model.RecordVersion
+	failNext bool
+}
+
+func (r *fakeVersionRepo) GetLatest(entityType, entityID string) (*model.RecordVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var latest *model.RecordVersion
+	for _, v := range r.versions {
+		if v.EntityType == entityType && v.EntityID == entityID && (latest == nil || v.Version > latest.Version) {
+			latest = v
+		}
+	}
+	if latest == nil {
+		return nil, errors.New("versi tidak ditemukan")
+	}
+	clone := *latest
+	return &clone, nil
+}
+
+func (r *fakeVersionRepo) Append(v *model.RecordVersion) error {
+	if r.failNext {
+		r.failNext = false
+		return errors.New("record_versions tidak tersedia")
+	}
+	if v.Version == 0 {
+		v.Version = 1
+		if latest, err := r.GetLatest(v.EntityType, v.EntityID); err == nil {
+			v.Version = latest.Version + 1
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.versions {
+		if existing.EntityType == v.EntityType && existing.EntityID == v.EntityID && existing.Version == v.Version {
+			return errors.New("versi sudah tercatat")
+		}
+	}
+	clone := *v
+	r.versions = append(r.versions, &clone)
+	return nil
+}
+
+// numbers mengembalikan nomor versi dan action riwayat sesuai urutan simpan
+func (r *fakeVersionRepo) numbers() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]string, len(r.versions))
+	for i, v := range r.versions {
+		out[i] = fmt.Sprintf("%d:%s", v.Version, v.Action)
+	}
+	return out
+}
+
+// fakeAudit mencatat audit sebagai "action:entity" tanpa menyimpan snapshot
+type fakeAudit struct {
+	AuditService
+	mu      sync.Mutex
+	records []string
+}
+
+func (a *fakeAudit) Record(c *fiber.Ctx, action, entityType, entityID string, before, after interface{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.records = append(a.records, action+":"+entityType)
+}
+
+func (a *fakeAudit) actions() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.records...)
+}
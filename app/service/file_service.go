@@ -35,18 +35,21 @@ type FileService interface {
 	HandleUpload(c *fiber.Ctx) error
 	HandleGetFilesByAlumni(c *fiber.Ctx) error
 	HandleDeleteFile(c *fiber.Ctx) error
+	HiddenFileGuard(c *fiber.Ctx) error
 }
 
 type fileService struct {
 	fileRepo   repository.FileRepository
 	alumniRepo repository.AlumniRepository // Kita butuh ini untuk otorisasi
+	audit      AuditService
 }
 
 // Kita tidak menyimpan uploadPath di service, kita tentukan di handler
-func NewFileService(fileRepo repository.FileRepository, alumniRepo repository.AlumniRepository) FileService {
+func NewFileService(fileRepo repository.FileRepository, alumniRepo repository.AlumniRepository, audit AuditService) FileService {
 	return &fileService{
 		fileRepo:   fileRepo,
 		alumniRepo: alumniRepo,
+		audit:      audit,
 	}
 }
 
@@ -120,13 +123,13 @@ func (s *fileService) HandleUpload(c *fiber.Ctx) error {
 
 	// 4. Otorisasi (sesuai Tugas)
 	userID := c.Locals("user_id").(string)
-	role := c.Locals("role").(string)
+	actor := c.Locals("actor").(*model.Actor)
 
-	// Admin bisa mengupload untuk siapa saja, user hanya untuk diri sendiri
+	// Pemilik files:write_any bisa mengupload untuk siapa saja, user hanya untuk diri sendiri
 	var targetAlumniID primitive.ObjectID
 
-	// Admin HARUS menyertakan 'alumni_id' di form-data
-	if role == "admin" {
+	// Pemilik files:write_any HARUS menyertakan 'alumni_id' di form-data
+	if actor.Can(model.PermFilesWriteAny) {
 		alumniIDStr := c.FormValue("alumni_id")
 		if alumniIDStr == "" {
 			return helper.ErrorResponse(c, fiber.StatusBadRequest, "Admin harus menyertakan 'alumni_id' di form-data")
@@ -135,8 +138,8 @@ func (s *fileService) HandleUpload(c *fiber.Ctx) error {
 		if err != nil {
 			return helper.ErrorResponse(c, fiber.StatusBadRequest, "Format 'alumni_id' tidak valid")
 		}
-		// Pastikan alumni-nya ada
-		if _, err := s.alumniRepo.GetByID(alumniIDStr); err != nil {
+		// Pastikan alumni-nya ada (dan berada dalam scope admin)
+		if !s.alumniInScope(targetAlumniID, actor.Scope) {
 			return helper.ErrorResponse(c, fiber.StatusNotFound, "Alumni dengan ID tersebut tidak ditemukan")
 		}
 	} else {
@@ -181,6 +184,7 @@ func (s *fileService) HandleUpload(c *fiber.Ctx) error {
 		os.Remove(filePath)
 		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal menyimpan metadata file ke database")
 	}
+	s.audit.Record(c, model.AuditActionCreate, model.AuditEntityFile, savedFile.ID.Hex(), nil, savedFile)
 
 	return helper.CreatedResponse(c, "File berhasil di-upload", savedFile)
 }
@@ -210,11 +214,15 @@ func (s *fileService) HandleGetFilesByAlumni(c *fiber.Ctx) error {
 		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Format Alumni ID tidak valid")
 	}
 
-	// Otorisasi: Admin bisa lihat siapa saja, user hanya bisa lihat punya sendiri
+	// Otorisasi: pemilik files:read_any bisa lihat siapa saja, user hanya bisa lihat punya sendiri
 	userID := c.Locals("user_id").(string)
-	role := c.Locals("role").(string)
+	actor := c.Locals("actor").(*model.Actor)
 
-	if role != "admin" {
+	if actor.Can(model.PermFilesReadAny) {
+		if !s.alumniInScope(alumniID, actor.Scope) {
+			return helper.ErrorResponse(c, fiber.StatusNotFound, "Alumni dengan ID tersebut tidak ditemukan")
+		}
+	} else {
 		alumni, err := s.alumniRepo.GetByUserID(userID)
 		if err != nil {
 			return helper.ErrorResponse(c, fiber.StatusForbidden, "Profil alumni Anda tidak ditemukan")
@@ -261,9 +269,13 @@ func (s *fileService) HandleDeleteFile(c *fiber.Ctx) error {
 
 	// 2. Otorisasi
 	userID := c.Locals("user_id").(string)
-	role := c.Locals("role").(string)
+	actor := c.Locals("actor").(*model.Actor)
 
-	if role != "admin" {
+	if actor.Can(model.PermFilesDeleteAny) {
+		if !s.alumniInScope(file.AlumniID, actor.Scope) {
+			return helper.ErrorResponse(c, fiber.StatusNotFound, "File tidak ditemukan di database")
+		}
+	} else {
 		alumni, err := s.alumniRepo.GetByUserID(userID)
 		if err != nil {
 			return helper.ErrorResponse(c, fiber.StatusForbidden, "Profil alumni Anda tidak ditemukan")
@@ -282,6 +294,30 @@ func (s *fileService) HandleDeleteFile(c *fiber.Ctx) error {
 	if err := s.fileRepo.Delete(fileID); err != nil {
 		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal menghapus metadata file dari database")
 	}
+	s.audit.Record(c, model.AuditActionDelete, model.AuditEntityFile, fileID, file, nil)
 
 	return helper.SuccessResponse(c, "File berhasil dihapus", nil)
 }
+
+// HiddenFileGuard dipasang sebelum static "/uploads" agar file milik alumni
+// yang sedang di trash tidak bisa diakses lewat URL langsung
+func (s *fileService) HiddenFileGuard(c *fiber.Ctx) error {
+	hidden, err := s.fileRepo.IsHidden(filepath.Base(c.Path()))
+	if err != nil {
+		log.Printf("[ERROR] Gagal memeriksa status file %s: %v", c.Path(), err)
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil file")
+	}
+	if hidden {
+		return helper.ErrorResponse(c, fiber.StatusNotFound, "File tidak ditemukan")
+	}
+	return c.Next()
+}
+
+// alumniInScope memastikan alumni ada dan jurusannya termasuk scope admin
+func (s *fileService) alumniInScope(alumniID primitive.ObjectID, scope *model.DataScope) bool {
+	alumni, err := s.alumniRepo.GetByID(alumniID.Hex())
+	if err != nil {
+		return false
+	}
+	return scope.Allows(alumni.Jurusan)
+}
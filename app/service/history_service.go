@@ -0,0 +1,438 @@
+package service
+
+import (
+	"alumni-crud-api/app/model"
+	"alumni-crud-api/app/repository"
+	"alumni-crud-api/helper"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// historyIgnoredFields selalu berubah di setiap versi sehingga tidak ikut di diff
+var historyIgnoredFields = map[string]bool{"_id": true, "id": true, "updated_at": true, "version": true}
+
+// HistoryService menampilkan riwayat versi alumni/pekerjaan dan mengembalikan
+// data ke versi lama. Versi baru dicatat oleh AuditService.Record.
+type HistoryService interface {
+	ListVersions(entityType, entityID string, scope *model.DataScope, page, limit int) ([]model.RecordVersion, *model.MetaInfo, error)
+	GetVersion(entityType, entityID string, version int, scope *model.DataScope) (*model.RecordVersion, error)
+	GetVersionAsOf(entityType, entityID string, at time.Time, scope *model.DataScope) (*model.RecordVersion, error)
+	DiffVersions(entityType, entityID string, from, to int, scope *model.DataScope) (*model.VersionDiff, error)
+	RevertAlumni(id string, version int, scope *model.DataScope) (*model.Alumni, *model.Alumni, error)
+	RevertPekerjaan(id string, version int, scope *model.DataScope) (*model.PekerjaanAlumni, *model.PekerjaanAlumni, error)
+
+	HandleListHistory(entityType string) fiber.Handler
+	HandleGetVersion(entityType string) fiber.Handler
+	HandleGetVersionAsOf(entityType string) fiber.Handler
+	HandleDiffVersions(entityType string) fiber.Handler
+	HandleRevert(entityType string) fiber.Handler
+}
+
+type historyService struct {
+	versionRepo   repository.RecordVersionRepository
+	alumniRepo    repository.AlumniRepository
+	pekerjaanRepo repository.PekerjaanRepository
+	audit         AuditService
+}
+
+func NewHistoryService(
+	versionRepo repository.RecordVersionRepository,
+	alumniRepo repository.AlumniRepository,
+	pekerjaanRepo repository.PekerjaanRepository,
+	audit AuditService,
+) HistoryService {
+	return &historyService{
+		versionRepo:   versionRepo,
+		alumniRepo:    alumniRepo,
+		pekerjaanRepo: pekerjaanRepo,
+		audit:         audit,
+	}
+}
+
+// entityJurusan mencari jurusan pemilik data untuk pengecekan scope. Data yang
+// sudah dihapus permanen memakai snapshot versi terakhirnya.
+func (s *historyService) entityJurusan(entityType, entityID string) (string, error) {
+	switch entityType {
+	case model.AuditEntityAlumni:
+		if alumni, err := s.alumniRepo.GetByIDWithDeleted(entityID); err == nil {
+			return alumni.Jurusan, nil
+		}
+		latest, err := s.versionRepo.GetLatest(entityType, entityID)
+		if err != nil {
+			return "", errors.New("riwayat tidak ditemukan")
+		}
+		jurusan, _ := latest.Snapshot["jurusan"].(string)
+		return jurusan, nil
+
+	case model.AuditEntityPekerjaan:
+		if pekerjaan, err := s.pekerjaanRepo.GetByIDWithDeleted(entityID); err == nil {
+			return s.entityJurusan(model.AuditEntityAlumni, pekerjaan.AlumniID.Hex())
+		}
+		latest, err := s.versionRepo.GetLatest(entityType, entityID)
+		if err != nil {
+			return "", errors.New("riwayat tidak ditemukan")
+		}
+		alumniID, ok := latest.Snapshot["alumni_id"].(primitive.ObjectID)
+		if !ok {
+			return "", errors.New("riwayat tidak ditemukan")
+		}
+		return s.entityJurusan(model.AuditEntityAlumni, alumniID.Hex())
+	}
+	return "", fmt.Errorf("jenis data %q tidak dikenal", entityType)
+}
+
+// checkAccess memastikan ID valid dan data berada dalam scope admin.
+// Data di luar scope dilaporkan tidak ditemukan.
+func (s *historyService) checkAccess(entityType, entityID string, scope *model.DataScope) error {
+	if _, err := primitive.ObjectIDFromHex(entityID); err != nil {
+		return fmt.Errorf("ID tidak valid: %v", err)
+	}
+	if scope == nil {
+		return nil
+	}
+	jurusan, err := s.entityJurusan(entityType, entityID)
+	if err != nil {
+		return err
+	}
+	if !scope.Allows(jurusan) {
+		return errors.New("riwayat tidak ditemukan")
+	}
+	return nil
+}
+
+func (s *historyService) ListVersions(entityType, entityID string, scope *model.DataScope, page, limit int) ([]model.RecordVersion, *model.MetaInfo, error) {
+	if err := s.checkAccess(entityType, entityID, scope); err != nil {
+		return nil, nil, err
+	}
+	offset := (page - 1) * limit
+
+	versions, err := s.versionRepo.List(entityType, entityID, limit, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+	total, err := s.versionRepo.Count(entityType, entityID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pages := (total + limit - 1) / limit
+	if total == 0 {
+		pages = 0
+	}
+	return versions, &model.MetaInfo{Page: page, Limit: limit, Total: total, Pages: pages}, nil
+}
+
+func (s *historyService) GetVersion(entityType, entityID string, version int, scope *model.DataScope) (*model.RecordVersion, error) {
+	if err := s.checkAccess(entityType, entityID, scope); err != nil {
+		return nil, err
+	}
+	return s.versionRepo.GetByVersion(entityType, entityID, version)
+}
+
+func (s *historyService) GetVersionAsOf(entityType, entityID string, at time.Time, scope *model.DataScope) (*model.RecordVersion, error) {
+	if err := s.checkAccess(entityType, entityID, scope); err != nil {
+		return nil, err
+	}
+	return s.versionRepo.GetAsOf(entityType, entityID, at)
+}
+
+// DiffVersions membandingkan dua versi. to = 0 berarti versi terakhir, from < 0
+// berarti versi tercatat sebelum to, dan from = 0 berarti dibandingkan dengan data
+// kosong (semua field dianggap baru).
+func (s *historyService) DiffVersions(entityType, entityID string, from, to int, scope *model.DataScope) (*model.VersionDiff, error) {
+	if err := s.checkAccess(entityType, entityID, scope); err != nil {
+		return nil, err
+	}
+
+	var newer *model.RecordVersion
+	var err error
+	if to == 0 {
+		newer, err = s.versionRepo.GetLatest(entityType, entityID)
+	} else {
+		newer, err = s.versionRepo.GetByVersion(entityType, entityID, to)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if from < 0 {
+		from = 0
+		if prev, err := s.versionRepo.GetPrevious(entityType, entityID, newer.Version); err == nil {
+			from = prev.Version
+		} else if err.Error() != "versi tidak ditemukan" {
+			return nil, err
+		}
+	}
+
+	var older bson.M
+	if from > 0 {
+		v, err := s.versionRepo.GetByVersion(entityType, entityID, from)
+		if err != nil {
+			return nil, err
+		}
+		older = v.Snapshot
+	}
+
+	changes, err := diffSnapshots(older, newer.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return &model.VersionDiff{
+		EntityType: entityType,
+		EntityID:   entityID,
+		From:       from,
+		To:         newer.Version,
+		Changes:    changes,
+	}, nil
+}
+
+// diffSnapshots membandingkan dua snapshot per field, termasuk field yang
+// hilang di salah satu sisi (mis. user_id setelah unlink)
+func diffSnapshots(older, newer bson.M) ([]model.FieldChange, error) {
+	oldMap, err := fieldMap(older)
+	if err != nil {
+		return nil, err
+	}
+	newMap, err := fieldMap(newer)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	fields := []string{}
+	for _, m := range []map[string]interface{}{oldMap, newMap} {
+		for field := range m {
+			if !seen[field] && !historyIgnoredFields[field] {
+				seen[field] = true
+				fields = append(fields, field)
+			}
+		}
+	}
+	sort.Strings(fields)
+
+	changes := []model.FieldChange{}
+	for _, field := range fields {
+		if !reflect.DeepEqual(oldMap[field], newMap[field]) {
+			changes = append(changes, model.FieldChange{Field: field, Old: oldMap[field], New: newMap[field]})
+		}
+	}
+	return changes, nil
+}
+
+// decodeSnapshot mengubah snapshot versi kembali menjadi struct model
+func decodeSnapshot(snapshot bson.M, out interface{}) error {
+	raw, err := bson.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, out)
+}
+
+// RevertAlumni mengembalikan field profil alumni ke isi versi tertentu. Link
+// akun (user_id) tidak ikut dikembalikan. Hasilnya dicatat sebagai versi baru.
+func (s *historyService) RevertAlumni(id string, version int, scope *model.DataScope) (*model.Alumni, *model.Alumni, error) {
+	target, err := s.GetVersion(model.AuditEntityAlumni, id, version, scope)
+	if err != nil {
+		return nil, nil, err
+	}
+	before, err := s.alumniRepo.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var old model.Alumni
+	if err := decodeSnapshot(target.Snapshot, &old); err != nil {
+		return nil, nil, err
+	}
+	req := alumniUpdateFrom(&old)
+	if err := helper.ValidateUpdateAlumni(req.Nama, req.Jurusan, req.Email, req.Angkatan, req.TahunLulus); err != nil {
+		return nil, nil, err
+	}
+	if scope != nil && !scope.Allows(req.Jurusan) {
+		return nil, nil, errors.New("access denied: jurusan versi tersebut di luar scope Anda")
+	}
+
+	after, err := s.alumniRepo.Update(id, req, before.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+// RevertPekerjaan mengembalikan isi pekerjaan ke versi tertentu. Status hapus
+// dan status persetujuan tidak ikut dikembalikan.
+func (s *historyService) RevertPekerjaan(id string, version int, scope *model.DataScope) (*model.PekerjaanAlumni, *model.PekerjaanAlumni, error) {
+	target, err := s.GetVersion(model.AuditEntityPekerjaan, id, version, scope)
+	if err != nil {
+		return nil, nil, err
+	}
+	before, err := s.pekerjaanRepo.GetByID(id)
+	if err != nil {
+		return nil, nil, errors.New("pekerjaan tidak ditemukan")
+	}
+
+	var old model.PekerjaanAlumni
+	if err := decodeSnapshot(target.Snapshot, &old); err != nil {
+		return nil, nil, err
+	}
+	req := pekerjaanUpdateFrom(&old)
+	periode, err := validatePekerjaanUpdate(s.pekerjaanRepo, before, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	after, err := s.pekerjaanRepo.Update(id, req, periode, before.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+// --- Handlers ---
+
+func historyErrorResponse(c *fiber.Ctx, err error) error {
+	msg := err.Error()
+	switch {
+	case msg == "riwayat tidak ditemukan", msg == "versi tidak ditemukan",
+		msg == "alumni tidak ditemukan", msg == "pekerjaan tidak ditemukan":
+		return helper.ErrorResponse(c, fiber.StatusNotFound, msg)
+	case strings.HasPrefix(msg, "access denied"):
+		return helper.ErrorResponse(c, fiber.StatusForbidden, msg)
+	case isDuplicateAlumniError(err), strings.HasPrefix(msg, "periode pekerjaan tumpang tindih"):
+		return helper.ErrorResponse(c, fiber.StatusConflict, msg)
+	case msg == "versi data tidak cocok":
+		return helper.ErrorResponse(c, fiber.StatusConflict, "Data berubah saat revert diproses, coba lagi")
+	case strings.HasPrefix(msg, "ID tidak valid"),
+		strings.Contains(msg, "wajib diisi"),
+		strings.Contains(msg, "is required"),
+		strings.Contains(msg, "must be"),
+		strings.Contains(msg, "cannot be"):
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, msg)
+	default:
+		log.Printf("[ERROR] History service error: %v", err)
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memproses riwayat data")
+	}
+}
+
+// versionParam membaca nomor versi; kosong menghasilkan nilai def
+func versionParam(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("nomor versi %q tidak valid", value)
+	}
+	return version, nil
+}
+
+func (s *historyService) HandleListHistory(entityType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := c.Locals("actor").(*model.Actor)
+		page, limit := paginationQuery(c)
+
+		versions, meta, err := s.ListVersions(entityType, c.Params("id"), actor.Scope, page, limit)
+		if err != nil {
+			return historyErrorResponse(c, err)
+		}
+		return c.JSON(fiber.Map{
+			"success": true,
+			"message": "History retrieved successfully",
+			"data":    versions,
+			"meta":    meta,
+		})
+	}
+}
+
+func (s *historyService) HandleGetVersion(entityType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := c.Locals("actor").(*model.Actor)
+		version, err := versionParam(c.Params("version"), 0)
+		if err != nil || version == 0 {
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, "Nomor versi tidak valid")
+		}
+
+		v, err := s.GetVersion(entityType, c.Params("id"), version, actor.Scope)
+		if err != nil {
+			return historyErrorResponse(c, err)
+		}
+		return helper.SuccessResponse(c, "Version retrieved successfully", v)
+	}
+}
+
+// HandleGetVersionAsOf menampilkan kondisi data pada waktu ?at= (RFC3339 atau YYYY-MM-DD)
+func (s *historyService) HandleGetVersionAsOf(entityType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := c.Locals("actor").(*model.Actor)
+		at, err := parseAuditTime(c.Query("at"), true)
+		if err != nil {
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		if at == nil {
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, "Parameter at wajib diisi")
+		}
+
+		v, err := s.GetVersionAsOf(entityType, c.Params("id"), *at, actor.Scope)
+		if err != nil {
+			return historyErrorResponse(c, err)
+		}
+		return helper.SuccessResponse(c, "Version retrieved successfully", v)
+	}
+}
+
+// HandleDiffVersions membandingkan ?from= dengan ?to= (default: versi terakhir
+// dibandingkan dengan versi sebelumnya)
+func (s *historyService) HandleDiffVersions(entityType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := c.Locals("actor").(*model.Actor)
+		to, err := versionParam(c.Query("to"), 0)
+		if err != nil {
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		from, err := versionParam(c.Query("from"), -1)
+		if err != nil {
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		diff, err := s.DiffVersions(entityType, c.Params("id"), from, to, actor.Scope)
+		if err != nil {
+			return historyErrorResponse(c, err)
+		}
+		return helper.SuccessResponse(c, "Version diff retrieved successfully", diff)
+	}
+}
+
+func (s *historyService) HandleRevert(entityType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := c.Locals("actor").(*model.Actor)
+		id := c.Params("id")
+		version, err := versionParam(c.Params("version"), 0)
+		if err != nil || version == 0 {
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, "Nomor versi tidak valid")
+		}
+
+		var before, after interface{}
+		switch entityType {
+		case model.AuditEntityAlumni:
+			before, after, err = s.RevertAlumni(id, version, actor.Scope)
+		case model.AuditEntityPekerjaan:
+			before, after, err = s.RevertPekerjaan(id, version, actor.Scope)
+		default:
+			err = fmt.Errorf("jenis data %q tidak dikenal", entityType)
+		}
+		if err != nil {
+			return historyErrorResponse(c, err)
+		}
+
+		s.audit.Record(c, model.AuditActionRevert, entityType, id, before, after)
+		return helper.SuccessResponse(c, fmt.Sprintf("Data dikembalikan ke versi %d", version), after)
+	}
+}
@@ -0,0 +1,640 @@
+package service
+
+import (
+	"alumni-crud-api/app/model"
+	"alumni-crud-api/app/repository"
+	"alumni-crud-api/helper"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ModerationService mengelola perubahan data yang diajukan alumni sendiri.
+// Perubahan baru diterapkan ke data resmi setelah disetujui admin.
+type ModerationService interface {
+	SubmitAlumniUpdate(actor *model.Actor, alumni *model.Alumni, req *model.UpdateAlumniRequest) (*model.PendingChange, error)
+	SubmitPekerjaanCreate(actor *model.Actor, pekerjaan *model.PekerjaanAlumni) (*model.PendingChange, error)
+	SubmitPekerjaanUpdate(actor *model.Actor, pekerjaan *model.PekerjaanAlumni, req *model.UpdatePekerjaanRequest) (*model.PendingChange, error)
+	ListChanges(filter model.PendingChangeFilter, page, limit int) ([]model.PendingChange, *model.MetaInfo, error)
+	GetChangeDiff(id string, scope *model.DataScope) (*model.PendingChangeDiff, error)
+	ApproveChange(id string, actor *model.Actor) (*model.PendingChange, error)
+	RejectChange(id, reason string, actor *model.Actor) (*model.PendingChange, error)
+
+	HandleListChanges(c *fiber.Ctx) error
+	HandleListMyChanges(c *fiber.Ctx) error
+	HandleGetChangeDiff(c *fiber.Ctx) error
+	HandleApproveChange(c *fiber.Ctx) error
+	HandleRejectChange(c *fiber.Ctx) error
+}
+
+type moderationService struct {
+	changeRepo    repository.PendingChangeRepository
+	alumniRepo    repository.AlumniRepository
+	pekerjaanRepo repository.PekerjaanRepository
+	authRepo      repository.AuthRepository
+	audit         AuditService
+	mailer        helper.Mailer
+}
+
+func NewModerationService(
+	changeRepo repository.PendingChangeRepository,
+	alumniRepo repository.AlumniRepository,
+	pekerjaanRepo repository.PekerjaanRepository,
+	authRepo repository.AuthRepository,
+	audit AuditService,
+	mailer helper.Mailer,
+) ModerationService {
+	return &moderationService{
+		changeRepo:    changeRepo,
+		alumniRepo:    alumniRepo,
+		pekerjaanRepo: pekerjaanRepo,
+		authRepo:      authRepo,
+		audit:         audit,
+		mailer:        mailer,
+	}
+}
+
+// --- Diff ---
+
+// fieldMap mengubah struct request menjadi map nama field JSON -> nilai,
+// sehingga diff dan penerapan perubahan berlaku untuk semua jenis data
+func fieldMap(v interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// diffFields membandingkan data saat ini dengan usulan; hanya field yang
+// berbeda yang dikembalikan, urut nama field
+func diffFields(current, proposed interface{}) ([]model.FieldChange, error) {
+	oldMap, err := fieldMap(current)
+	if err != nil {
+		return nil, err
+	}
+	newMap, err := fieldMap(proposed)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(newMap))
+	for field := range newMap {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	changes := []model.FieldChange{}
+	for _, field := range fields {
+		if !reflect.DeepEqual(oldMap[field], newMap[field]) {
+			changes = append(changes, model.FieldChange{Field: field, Old: oldMap[field], New: newMap[field]})
+		}
+	}
+	return changes, nil
+}
+
+// applyChanges menimpa field pada base dengan nilai usulan lalu mengisi out
+func applyChanges(base interface{}, changes []model.FieldChange, out interface{}) error {
+	m, err := fieldMap(base)
+	if err != nil {
+		return err
+	}
+	for _, ch := range changes {
+		m[ch.Field] = ch.New
+	}
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// sameValue membandingkan nilai setelah normalisasi JSON (nilai dari MongoDB
+// bisa bertipe int32/int64 sementara hasil decode JSON selalu float64)
+func sameValue(a, b interface{}) bool {
+	ra, errA := json.Marshal(a)
+	rb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ra) == string(rb)
+}
+
+// patchRequest menerapkan patch (merge patch atau JSON Patch) ke request
+// update lengkap base dan mengisi out dengan hasilnya. Field di luar request
+// update ditolak.
+func patchRequest(base interface{}, patch []byte, contentType string, out interface{}) error {
+	doc, err := json.Marshal(base)
+	if err != nil {
+		return err
+	}
+	merged, err := helper.ApplyPatch(doc, patch, contentType)
+	if err != nil {
+		return err
+	}
+	return helper.DecodeStrict(merged, out)
+}
+
+// changedFields mengembalikan field request update yang berbeda antara base
+// dan updated beserta nilai barunya (nama JSON sama dengan nama BSON).
+// Pointer nil menghasilkan nilai nil, yaitu field dihapus.
+func changedFields(base, updated interface{}) (bson.M, error) {
+	changes, err := diffFields(base, updated)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	rv := reflect.Indirect(reflect.ValueOf(updated))
+	for i := 0; i < rv.NumField(); i++ {
+		name := strings.Split(rv.Type().Field(i).Tag.Get("json"), ",")[0]
+		field := rv.Field(i)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				values[name] = nil
+				continue
+			}
+			field = field.Elem()
+		}
+		values[name] = field.Interface()
+	}
+
+	fields := bson.M{}
+	for _, ch := range changes {
+		fields[ch.Field] = values[ch.Field]
+	}
+	return fields, nil
+}
+
+func alumniUpdateFrom(a *model.Alumni) *model.UpdateAlumniRequest {
+	return &model.UpdateAlumniRequest{
+		Nama:       a.Nama,
+		Jurusan:    a.Jurusan,
+		Angkatan:   a.Angkatan,
+		TahunLulus: a.TahunLulus,
+		Email:      a.Email,
+		NoTelepon:  a.NoTelepon,
+		Alamat:     a.Alamat,
+	}
+}
+
+// pekerjaanUpdateFrom mengubah pekerjaan ke bentuk request update, dengan tanggal
+// dalam format YYYY-MM-DD
+func pekerjaanUpdateFrom(p *model.PekerjaanAlumni) *model.UpdatePekerjaanRequest {
+	req := &model.UpdatePekerjaanRequest{
+		NamaPerusahaan:     p.NamaPerusahaan,
+		PosisiJabatan:      p.PosisiJabatan,
+		BidangIndustri:     p.BidangIndustri,
+		LokasiKerja:        p.LokasiKerja,
+		GajiRange:          p.GajiRange,
+		TanggalMulaiKerja:  p.TanggalMulaiKerja.String(),
+		StatusPekerjaan:    p.StatusPekerjaan,
+		JenisPekerjaan:     jenisPekerjaan(p),
+		DeskripsiPekerjaan: p.DeskripsiPekerjaan,
+	}
+	if p.TanggalSelesaiKerja != nil {
+		selesai := p.TanggalSelesaiKerja.String()
+		req.TanggalSelesaiKerja = &selesai
+	}
+	return req
+}
+
+// --- Pengajuan ---
+
+func (s *moderationService) submit(actor *model.Actor, change *model.PendingChange) (*model.PendingChange, error) {
+	submitterID, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return nil, errors.New("user ID tidak valid")
+	}
+	change.SubmittedBy = submitterID
+	change.SubmittedByUsername = actor.Username
+	return s.changeRepo.Submit(change)
+}
+
+func (s *moderationService) SubmitAlumniUpdate(actor *model.Actor, alumni *model.Alumni, req *model.UpdateAlumniRequest) (*model.PendingChange, error) {
+	changes, err := diffFields(alumniUpdateFrom(alumni), req)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, errors.New("tidak ada perubahan yang diajukan")
+	}
+
+	return s.submit(actor, &model.PendingChange{
+		EntityType: model.ChangeEntityAlumni,
+		EntityID:   alumni.ID,
+		Action:     model.ChangeActionUpdate,
+		AlumniID:   alumni.ID,
+		Jurusan:    alumni.Jurusan,
+		Changes:    changes,
+	})
+}
+
+// SubmitPekerjaanCreate mencatat pekerjaan baru (berstatus pending) ke antrian.
+// Seluruh field dicatat sebagai perubahan dari nilai kosong.
+func (s *moderationService) SubmitPekerjaanCreate(actor *model.Actor, pekerjaan *model.PekerjaanAlumni) (*model.PendingChange, error) {
+	alumni, err := s.alumniRepo.GetByID(pekerjaan.AlumniID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	changes, err := diffFields(&model.UpdatePekerjaanRequest{}, pekerjaanUpdateFrom(pekerjaan))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.submit(actor, &model.PendingChange{
+		EntityType: model.ChangeEntityPekerjaan,
+		EntityID:   pekerjaan.ID,
+		Action:     model.ChangeActionCreate,
+		AlumniID:   alumni.ID,
+		Jurusan:    alumni.Jurusan,
+		Changes:    changes,
+	})
+}
+
+func (s *moderationService) SubmitPekerjaanUpdate(actor *model.Actor, pekerjaan *model.PekerjaanAlumni, req *model.UpdatePekerjaanRequest) (*model.PendingChange, error) {
+	alumni, err := s.alumniRepo.GetByID(pekerjaan.AlumniID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	changes, err := diffFields(pekerjaanUpdateFrom(pekerjaan), req)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, errors.New("tidak ada perubahan yang diajukan")
+	}
+
+	return s.submit(actor, &model.PendingChange{
+		EntityType: model.ChangeEntityPekerjaan,
+		EntityID:   pekerjaan.ID,
+		Action:     model.ChangeActionUpdate,
+		AlumniID:   alumni.ID,
+		Jurusan:    alumni.Jurusan,
+		Changes:    changes,
+	})
+}
+
+// --- Review ---
+
+func (s *moderationService) ListChanges(filter model.PendingChangeFilter, page, limit int) ([]model.PendingChange, *model.MetaInfo, error) {
+	offset := (page - 1) * limit
+
+	changes, err := s.changeRepo.List(filter, limit, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+	total, err := s.changeRepo.Count(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pages := (total + limit - 1) / limit
+	if total == 0 {
+		pages = 0
+	}
+	return changes, &model.MetaInfo{Page: page, Limit: limit, Total: total, Pages: pages}, nil
+}
+
+// getChange memperlakukan perubahan di luar scope admin sebagai tidak ada
+func (s *moderationService) getChange(id string, scope *model.DataScope) (*model.PendingChange, error) {
+	change, err := s.changeRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.Allows(change.Jurusan) {
+		return nil, errors.New("perubahan tidak ditemukan")
+	}
+	return change, nil
+}
+
+// currentState mengambil data yang dituju perubahan, dalam bentuk request
+// update (untuk diff) dan bentuk aslinya (untuk ditampilkan)
+func (s *moderationService) currentState(change *model.PendingChange) (interface{}, interface{}, error) {
+	switch change.EntityType {
+	case model.ChangeEntityAlumni:
+		alumni, err := s.alumniRepo.GetByID(change.EntityID.Hex())
+		if err != nil {
+			return nil, nil, err
+		}
+		return alumniUpdateFrom(alumni), alumni, nil
+	case model.ChangeEntityPekerjaan:
+		pekerjaan, err := s.pekerjaanRepo.GetByID(change.EntityID.Hex())
+		if err != nil {
+			return nil, nil, errors.New("pekerjaan tidak ditemukan")
+		}
+		return pekerjaanUpdateFrom(pekerjaan), pekerjaan, nil
+	}
+	return nil, nil, fmt.Errorf("jenis data %q tidak dikenal", change.EntityType)
+}
+
+// entitySnapshot mengambil data yang dituju perubahan untuk audit log
+func (s *moderationService) entitySnapshot(change *model.PendingChange) interface{} {
+	_, current, err := s.currentState(change)
+	if err != nil {
+		return nil
+	}
+	return current
+}
+
+func (s *moderationService) GetChangeDiff(id string, scope *model.DataScope) (*model.PendingChangeDiff, error) {
+	change, err := s.getChange(id, scope)
+	if err != nil {
+		return nil, err
+	}
+	state, current, err := s.currentState(change)
+	if err != nil {
+		return nil, err
+	}
+	currentFields, err := fieldMap(state)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &model.PendingChangeDiff{Change: change, Current: current, Fields: []model.FieldDiff{}}
+	for _, ch := range change.Changes {
+		cur := currentFields[ch.Field]
+		diff.Fields = append(diff.Fields, model.FieldDiff{
+			Field:    ch.Field,
+			Old:      ch.Old,
+			New:      ch.New,
+			Current:  cur,
+			Conflict: change.Status == model.ApprovalPending && !sameValue(cur, ch.Old),
+		})
+	}
+	return diff, nil
+}
+
+// apply menerapkan perubahan yang disetujui melalui Update repository.
+// Field yang tidak diajukan memakai nilai terbaru, bukan nilai saat diajukan.
+func (s *moderationService) apply(change *model.PendingChange, reviewerID primitive.ObjectID) error {
+	switch {
+	case change.EntityType == model.ChangeEntityAlumni:
+		alumni, err := s.alumniRepo.GetByID(change.EntityID.Hex())
+		if err != nil {
+			return err
+		}
+		var req model.UpdateAlumniRequest
+		if err := applyChanges(alumniUpdateFrom(alumni), change.Changes, &req); err != nil {
+			return err
+		}
+		if err := helper.ValidateUpdateAlumni(req.Nama, req.Jurusan, req.Email, req.Angkatan, req.TahunLulus); err != nil {
+			return err
+		}
+		_, err = s.alumniRepo.Update(change.EntityID.Hex(), &req, alumni.Version)
+		return err
+
+	case change.EntityType == model.ChangeEntityPekerjaan && change.Action == model.ChangeActionCreate:
+		_, err := s.pekerjaanRepo.SetApprovalStatus(change.EntityID.Hex(), model.ApprovalApproved, &reviewerID, "")
+		return err
+
+	case change.EntityType == model.ChangeEntityPekerjaan:
+		pekerjaan, err := s.pekerjaanRepo.GetByID(change.EntityID.Hex())
+		if err != nil {
+			return errors.New("pekerjaan tidak ditemukan")
+		}
+		var req model.UpdatePekerjaanRequest
+		if err := applyChanges(pekerjaanUpdateFrom(pekerjaan), change.Changes, &req); err != nil {
+			return err
+		}
+		// Periode dicek ulang karena pekerjaan lain bisa berubah sejak diajukan
+		periode, err := validatePekerjaanUpdate(s.pekerjaanRepo, pekerjaan, &req)
+		if err != nil {
+			return err
+		}
+		_, err = s.pekerjaanRepo.Update(change.EntityID.Hex(), &req, periode, pekerjaan.Version)
+		return err
+	}
+	return fmt.Errorf("jenis data %q tidak dikenal", change.EntityType)
+}
+
+func (s *moderationService) ApproveChange(id string, actor *model.Actor) (*model.PendingChange, error) {
+	reviewerID, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return nil, errors.New("user ID tidak valid")
+	}
+	if _, err := s.getChange(id, actor.Scope); err != nil {
+		return nil, err
+	}
+
+	// Perubahan diklaim dulu agar tidak diterapkan dua kali oleh admin lain
+	// atau ditolak di tengah penerapan
+	change, err := s.changeRepo.Claim(id, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(change, reviewerID); err != nil {
+		if relErr := s.changeRepo.Release(id); relErr != nil {
+			log.Printf("[ERROR] ModerationService kembalikan perubahan %s ke pending: %v", id, relErr)
+		}
+		return nil, err
+	}
+	resolved, err := s.changeRepo.Resolve(id, model.ApprovalApproved, reviewerID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(resolved)
+	return resolved, nil
+}
+
+func (s *moderationService) RejectChange(id, reason string, actor *model.Actor) (*model.PendingChange, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("alasan penolakan wajib diisi")
+	}
+	reviewerID, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return nil, errors.New("user ID tidak valid")
+	}
+	if _, err := s.getChange(id, actor.Scope); err != nil {
+		return nil, err
+	}
+
+	resolved, err := s.changeRepo.Resolve(id, model.ApprovalRejected, reviewerID, reason)
+	if err != nil {
+		return nil, err
+	}
+	// Pekerjaan baru yang ditolak tetap tersembunyi dan bisa diperbaiki pemiliknya
+	if resolved.EntityType == model.ChangeEntityPekerjaan && resolved.Action == model.ChangeActionCreate {
+		if _, err := s.pekerjaanRepo.SetApprovalStatus(resolved.EntityID.Hex(), model.ApprovalRejected, &reviewerID, reason); err != nil {
+			log.Printf("[ERROR] ModerationService tandai pekerjaan %s ditolak: %v", resolved.EntityID.Hex(), err)
+		}
+	}
+
+	s.notify(resolved)
+	return resolved, nil
+}
+
+// notify mengirim hasil review ke email pengaju. Kegagalan hanya dicatat di log
+// karena keputusan admin sudah tersimpan.
+func (s *moderationService) notify(change *model.PendingChange) {
+	user, err := s.authRepo.GetUserByID(change.SubmittedBy.Hex())
+	if err != nil || user.Email == "" {
+		log.Printf("[WARN] ModerationService: pengaju perubahan %s tidak punya email", change.ID.Hex())
+		return
+	}
+
+	fields := make([]string, 0, len(change.Changes))
+	for _, ch := range change.Changes {
+		fields = append(fields, ch.Field)
+	}
+	target := "data alumni"
+	if change.EntityType == model.ChangeEntityPekerjaan {
+		target = "data pekerjaan"
+	}
+
+	var subject, body string
+	if change.Status == model.ApprovalApproved {
+		subject = "Perubahan Data Disetujui"
+		body = fmt.Sprintf("Halo %s,\n\nPerubahan %s Anda (%s) telah diverifikasi dan disetujui admin.\n",
+			user.Username, target, strings.Join(fields, ", "))
+	} else {
+		subject = "Perubahan Data Ditolak"
+		body = fmt.Sprintf("Halo %s,\n\nPerubahan %s Anda (%s) ditolak admin dengan alasan:\n\n%s\n\n"+
+			"Silakan perbaiki data lalu ajukan kembali.\n",
+			user.Username, target, strings.Join(fields, ", "), change.RejectReason)
+	}
+	if err := s.mailer.Send(user.Email, subject, body); err != nil {
+		log.Printf("[ERROR] ModerationService kirim notifikasi ke %s: %v", user.Email, err)
+	}
+}
+
+// --- Handlers ---
+
+func moderationErrorResponse(c *fiber.Ctx, err error) error {
+	msg := err.Error()
+	switch {
+	case msg == "perubahan tidak ditemukan", msg == "alumni tidak ditemukan", msg == "pekerjaan tidak ditemukan":
+		return helper.ErrorResponse(c, fiber.StatusNotFound, msg)
+	case msg == "perubahan tidak ditemukan atau sudah diproses", isDuplicateAlumniError(err),
+		strings.HasPrefix(msg, "periode pekerjaan tumpang tindih"):
+		return helper.ErrorResponse(c, fiber.StatusConflict, msg)
+	case msg == "versi data tidak cocok":
+		return helper.ErrorResponse(c, fiber.StatusConflict, "Data berubah saat perubahan diterapkan, coba lagi")
+	case strings.HasPrefix(msg, "ID tidak valid"),
+		strings.Contains(msg, "wajib diisi"),
+		strings.Contains(msg, "is required"),
+		strings.Contains(msg, "must be"),
+		strings.Contains(msg, "cannot be"):
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, msg)
+	default:
+		log.Printf("[ERROR] Moderation service error: %v", err)
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memproses perubahan data")
+	}
+}
+
+func paginationQuery(c *fiber.Ctx) (int, int) {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	return page, limit
+}
+
+func (s *moderationService) HandleListChanges(c *fiber.Ctx) error {
+	actor := c.Locals("actor").(*model.Actor)
+	page, limit := paginationQuery(c)
+
+	filter := model.PendingChangeFilter{
+		Status:     c.Query("status", model.ApprovalPending),
+		EntityType: c.Query("entity_type"),
+		Scope:      actor.Scope,
+	}
+	if filter.Status == "all" {
+		filter.Status = ""
+	}
+
+	changes, meta, err := s.ListChanges(filter, page, limit)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil antrian perubahan")
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Pending changes retrieved successfully",
+		"data":    changes,
+		"meta":    meta,
+	})
+}
+
+// HandleListMyChanges menampilkan riwayat pengajuan milik user yang login
+func (s *moderationService) HandleListMyChanges(c *fiber.Ctx) error {
+	actor := c.Locals("actor").(*model.Actor)
+	page, limit := paginationQuery(c)
+
+	submitterID, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "user ID tidak valid")
+	}
+
+	filter := model.PendingChangeFilter{Status: c.Query("status"), SubmittedBy: &submitterID}
+	changes, meta, err := s.ListChanges(filter, page, limit)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil data pengajuan")
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Submitted changes retrieved successfully",
+		"data":    changes,
+		"meta":    meta,
+	})
+}
+
+func (s *moderationService) HandleGetChangeDiff(c *fiber.Ctx) error {
+	actor := c.Locals("actor").(*model.Actor)
+	diff, err := s.GetChangeDiff(c.Params("id"), actor.Scope)
+	if err != nil {
+		return moderationErrorResponse(c, err)
+	}
+	return helper.SuccessResponse(c, "Pending change retrieved successfully", diff)
+}
+
+func (s *moderationService) HandleApproveChange(c *fiber.Ctx) error {
+	actor := c.Locals("actor").(*model.Actor)
+	var before interface{}
+	if pending, err := s.changeRepo.GetByID(c.Params("id")); err == nil {
+		before = s.entitySnapshot(pending)
+	}
+
+	change, err := s.ApproveChange(c.Params("id"), actor)
+	if err != nil {
+		return moderationErrorResponse(c, err)
+	}
+	s.audit.Record(c, model.AuditActionApprove, model.AuditEntityPendingChange, change.ID.Hex(), nil, change)
+	// Pekerjaan baru tidak berubah isinya, hanya status persetujuannya
+	action := model.AuditActionUpdate
+	if change.Action == model.ChangeActionCreate {
+		action = model.AuditActionApprove
+	}
+	s.audit.Record(c, action, change.EntityType, change.EntityID.Hex(), before, s.entitySnapshot(change))
+	return helper.SuccessResponse(c, "Change approved and applied", change)
+}
+
+func (s *moderationService) HandleRejectChange(c *fiber.Ctx) error {
+	var req model.RejectChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	actor := c.Locals("actor").(*model.Actor)
+	change, err := s.RejectChange(c.Params("id"), req.Reason, actor)
+	if err != nil {
+		return moderationErrorResponse(c, err)
+	}
+	s.audit.Record(c, model.AuditActionReject, model.AuditEntityPendingChange, change.ID.Hex(), nil, change)
+	return helper.SuccessResponse(c, "Change rejected", change)
+}
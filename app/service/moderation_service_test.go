@@ -0,0 +1,286 @@
+package service
+
+import (
+	"alumni-crud-api/app/model"
+	"alumni-crud-api/app/repository"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeChangeRepo meniru transisi status pending -> approving -> approved dan
+// pending -> rejected dari repository asli
+type fakeChangeRepo struct {
+	repository.PendingChangeRepository
+	mu       sync.Mutex
+	changes  map[string]*model.PendingChange
+	released int
+}
+
+func (r *fakeChangeRepo) GetByID(id string) (*model.PendingChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	change, ok := r.changes[id]
+	if !ok {
+		return nil, errors.New("perubahan tidak ditemukan")
+	}
+	clone := *change
+	return &clone, nil
+}
+
+func (r *fakeChangeRepo) transition(id, from, to string) (*model.PendingChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	change, ok := r.changes[id]
+	if !ok || change.Status != from {
+		return nil, errors.New("perubahan tidak ditemukan atau sudah diproses")
+	}
+	change.Status = to
+	clone := *change
+	return &clone, nil
+}
+
+func (r *fakeChangeRepo) Claim(id string, reviewerID primitive.ObjectID) (*model.PendingChange, error) {
+	return r.transition(id, model.ApprovalPending, model.ChangeStatusApproving)
+}
+
+func (r *fakeChangeRepo) Release(id string) error {
+	r.mu.Lock()
+	r.released++
+	r.mu.Unlock()
+	_, err := r.transition(id, model.ChangeStatusApproving, model.ApprovalPending)
+	return err
+}
+
+func (r *fakeChangeRepo) Resolve(id, status string, reviewerID primitive.ObjectID, reason string) (*model.PendingChange, error) {
+	from := model.ApprovalPending
+	if status == model.ApprovalApproved {
+		from = model.ChangeStatusApproving
+	}
+	return r.transition(id, from, status)
+}
+
+func (r *fakeChangeRepo) status(id string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.changes[id].Status
+}
+
+// moderationAlumniRepo menambahkan Update. conflict mensimulasikan data yang
+// diubah admin lain saat perubahan diterapkan.
+type moderationAlumniRepo struct {
+	*fakeAlumniRepo
+	conflict bool
+	updates  int
+}
+
+func (r *moderationAlumniRepo) Update(id string, req *model.UpdateAlumniRequest, version int) (*model.Alumni, error) {
+	if r.conflict {
+		return nil, errors.New("versi data tidak cocok")
+	}
+	for _, a := range r.alumni {
+		if a.ID.Hex() == id {
+			a.Nama, a.Email = req.Nama, req.Email
+			a.Version++
+			r.updates++
+			clone := *a
+			return &clone, nil
+		}
+	}
+	return nil, errors.New("alumni tidak ditemukan")
+}
+
+type moderationPekerjaanRepo struct {
+	repository.PekerjaanRepository
+	pekerjaan *model.PekerjaanAlumni
+}
+
+func (r *moderationPekerjaanRepo) GetByID(id string) (*model.PekerjaanAlumni, error) {
+	clone := *r.pekerjaan
+	return &clone, nil
+}
+
+func (r *moderationPekerjaanRepo) SetApprovalStatus(id, status string, reviewerID *primitive.ObjectID, reason string) (*model.PekerjaanAlumni, error) {
+	r.pekerjaan.ApprovalStatus = status
+	clone := *r.pekerjaan
+	return &clone, nil
+}
+
+type moderationTestEnv struct {
+	svc       ModerationService
+	changes   *fakeChangeRepo
+	alumni    *moderationAlumniRepo
+	pekerjaan *moderationPekerjaanRepo
+	audit     *fakeAudit
+	mailer    *fakeMailer
+	record    *model.Alumni
+	submitter *model.User
+	admin     *model.Actor
+}
+
+func newModerationTestEnv(t *testing.T) *moderationTestEnv {
+	t.Helper()
+	submitter := &model.User{ID: primitive.NewObjectID(), Username: "budi", Email: "budi@kampus.ac.id"}
+	record := &model.Alumni{ID: primitive.NewObjectID(), NIM: "2101001", Nama: "Budi", Jurusan: "Teknik Informatika",
+		Angkatan: 2021, TahunLulus: 2025, Email: "budi@kampus.ac.id", Version: 3}
+	env := &moderationTestEnv{
+		changes:   &fakeChangeRepo{changes: map[string]*model.PendingChange{}},
+		alumni:    &moderationAlumniRepo{fakeAlumniRepo: &fakeAlumniRepo{alumni: []*model.Alumni{record}}},
+		pekerjaan: &moderationPekerjaanRepo{pekerjaan: &model.PekerjaanAlumni{ID: primitive.NewObjectID(), AlumniID: record.ID}},
+		audit:     &fakeAudit{},
+		mailer:    &fakeMailer{},
+		record:    record,
+		submitter: submitter,
+		admin:     &model.Actor{UserID: primitive.NewObjectID().Hex(), Username: "admin"},
+	}
+	env.svc = NewModerationService(env.changes, env.alumni, env.pekerjaan, newFakeAuthRepo(submitter), env.audit, env.mailer)
+	return env
+}
+
+// submitAlumniChange menaruh perubahan nama alumni berstatus pending
+func (e *moderationTestEnv) submitAlumniChange(nama string) string {
+	change := &model.PendingChange{
+		ID:          primitive.NewObjectID(),
+		EntityType:  model.ChangeEntityAlumni,
+		EntityID:    e.record.ID,
+		Action:      model.ChangeActionUpdate,
+		AlumniID:    e.record.ID,
+		Jurusan:     e.record.Jurusan,
+		Changes:     []model.FieldChange{{Field: "nama", Old: e.record.Nama, New: nama}},
+		Status:      model.ApprovalPending,
+		SubmittedBy: e.submitter.ID,
+	}
+	e.changes.changes[change.ID.Hex()] = change
+	return change.ID.Hex()
+}
+
+func TestApproveChangeAppliesOnce(t *testing.T) {
+	env := newModerationTestEnv(t)
+	id := env.submitAlumniChange("Budi Santoso")
+
+	approved, err := env.svc.ApproveChange(id, env.admin)
+	if err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if approved.Status != model.ApprovalApproved || env.record.Nama != "Budi Santoso" {
+		t.Fatalf("status %q, nama %q; ingin approved dan nama baru", approved.Status, env.record.Nama)
+	}
+	if len(env.mailer.sent) != 1 {
+		t.Fatalf("notifikasi terkirim %d kali, ingin 1", len(env.mailer.sent))
+	}
+
+	// Approve kedua (misalnya admin lain menekan tombol yang sama) tidak boleh
+	// menerapkan perubahan lagi
+	if _, err := env.svc.ApproveChange(id, env.admin); err == nil || err.Error() != "perubahan tidak ditemukan atau sudah diproses" {
+		t.Fatalf("approve kedua: err = %v", err)
+	}
+	if env.alumni.updates != 1 {
+		t.Fatalf("perubahan diterapkan %d kali, ingin 1", env.alumni.updates)
+	}
+}
+
+func TestApproveChangeReleasesClaimWhenApplyFails(t *testing.T) {
+	env := newModerationTestEnv(t)
+	id := env.submitAlumniChange("Budi Santoso")
+	env.alumni.conflict = true
+
+	if _, err := env.svc.ApproveChange(id, env.admin); err == nil || err.Error() != "versi data tidak cocok" {
+		t.Fatalf("approve saat data bentrok: err = %v", err)
+	}
+	if got := env.changes.status(id); got != model.ApprovalPending || env.changes.released != 1 {
+		t.Fatalf("status %q setelah gagal (release %d kali), ingin kembali pending", got, env.changes.released)
+	}
+	if len(env.mailer.sent) != 0 {
+		t.Fatal("pengaju tidak boleh diberi notifikasi bila perubahan gagal diterapkan")
+	}
+
+	// Setelah bentrok selesai perubahan yang sama masih bisa disetujui
+	env.alumni.conflict = false
+	if _, err := env.svc.ApproveChange(id, env.admin); err != nil {
+		t.Fatalf("approve ulang: %v", err)
+	}
+}
+
+func TestRejectChangeOnlyWhilePending(t *testing.T) {
+	env := newModerationTestEnv(t)
+	id := env.submitAlumniChange("Budi Santoso")
+
+	if _, err := env.svc.RejectChange(id, "  ", env.admin); err == nil || err.Error() != "alasan penolakan wajib diisi" {
+		t.Fatalf("reject tanpa alasan: err = %v", err)
+	}
+
+	// Perubahan yang sedang diterapkan admin lain tidak bisa ditolak
+	env.changes.changes[id].Status = model.ChangeStatusApproving
+	if _, err := env.svc.RejectChange(id, "data tidak sesuai", env.admin); err == nil || err.Error() != "perubahan tidak ditemukan atau sudah diproses" {
+		t.Fatalf("reject saat approving: err = %v", err)
+	}
+
+	env.changes.changes[id].Status = model.ApprovalPending
+	rejected, err := env.svc.RejectChange(id, "data tidak sesuai", env.admin)
+	if err != nil || rejected.Status != model.ApprovalRejected {
+		t.Fatalf("reject pending: status %v, err %v", rejected, err)
+	}
+	if env.record.Nama != "Budi" {
+		t.Fatalf("perubahan yang ditolak tidak boleh diterapkan, nama %q", env.record.Nama)
+	}
+}
+
+func TestApproveChangeOutsideScopeIsNotClaimed(t *testing.T) {
+	env := newModerationTestEnv(t)
+	id := env.submitAlumniChange("Budi Santoso")
+	env.admin.Scope = &model.DataScope{Jurusan: []string{"Akuntansi"}}
+
+	if _, err := env.svc.ApproveChange(id, env.admin); err == nil || err.Error() != "perubahan tidak ditemukan" {
+		t.Fatalf("approve di luar scope: err = %v", err)
+	}
+	if got := env.changes.status(id); got != model.ApprovalPending {
+		t.Fatalf("status %q, perubahan di luar scope tidak boleh diklaim", got)
+	}
+}
+
+func TestHandleApproveChangeAuditAction(t *testing.T) {
+	env := newModerationTestEnv(t)
+	app := fiber.New()
+	app.Post("/changes/:id/approve", func(c *fiber.Ctx) error {
+		c.Locals("actor", env.admin)
+		return env.svc.HandleApproveChange(c)
+	})
+	approve := func(id string) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("POST", "/changes/"+id+"/approve", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != 200 {
+			t.Fatalf("approve %s: status %d", id, resp.StatusCode)
+		}
+	}
+
+	approve(env.submitAlumniChange("Budi Santoso"))
+
+	created := &model.PendingChange{
+		ID:         primitive.NewObjectID(),
+		EntityType: model.ChangeEntityPekerjaan,
+		EntityID:   env.pekerjaan.pekerjaan.ID,
+		Action:     model.ChangeActionCreate,
+		AlumniID:   env.record.ID,
+		Jurusan:    env.record.Jurusan,
+		Status:     model.ApprovalPending,
+	}
+	env.changes.changes[created.ID.Hex()] = created
+	approve(created.ID.Hex())
+
+	// Diff alumni tercatat sebagai update, pekerjaan baru sebagai approve
+	want := "approve:pending_change,update:alumni,approve:pending_change,approve:pekerjaan"
+	if got := strings.Join(env.audit.actions(), ","); got != want {
+		t.Fatalf("audit %s, ingin %s", got, want)
+	}
+	if env.pekerjaan.pekerjaan.ApprovalStatus != model.ApprovalApproved {
+		t.Fatalf("status pekerjaan %q, ingin approved", env.pekerjaan.pekerjaan.ApprovalStatus)
+	}
+}
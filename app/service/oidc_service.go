@@ -0,0 +1,446 @@
+package service
+
+import (
+	"alumni-crud-api/app/model"
+	"alumni-crud-api/app/repository"
+	"alumni-crud-api/config"
+	"alumni-crud-api/helper"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var usernameUnsafeChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// OIDCService menangani login SSO kampus (authorization code + PKCE).
+// Setelah identitas diverifikasi, login diselesaikan seperti login password
+// (termasuk tantangan 2FA) oleh AuthService.
+type OIDCService struct {
+	auth       *AuthService
+	provider   *helper.OIDCProvider // nil jika SSO tidak dikonfigurasi
+	stateRepo  repository.OIDCStateRepository
+	authRepo   repository.AuthRepository
+	alumniRepo repository.AlumniRepository
+	audit      AuditService
+	cfg        *config.Config
+}
+
+func NewOIDCService(
+	auth *AuthService,
+	provider *helper.OIDCProvider,
+	stateRepo repository.OIDCStateRepository,
+	authRepo repository.AuthRepository,
+	alumniRepo repository.AlumniRepository,
+	audit AuditService,
+	cfg *config.Config,
+) *OIDCService {
+	return &OIDCService{
+		auth:       auth,
+		provider:   provider,
+		stateRepo:  stateRepo,
+		authRepo:   authRepo,
+		alumniRepo: alumniRepo,
+		audit:      audit,
+		cfg:        cfg,
+	}
+}
+
+// StartLogin menyimpan state, nonce dan PKCE verifier, lalu mengembalikan URL login IdP
+func (s *OIDCService) StartLogin(ip string) (string, error) {
+	return s.startFlow(ip, nil)
+}
+
+// StartLink memulai alur SSO untuk menghubungkan akun SSO ke user yang sedang
+// login. Dipakai untuk akun yang tidak boleh dihubungkan otomatis lewat email.
+func (s *OIDCService) StartLink(userID, ip string) (string, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return "", errors.New("user tidak ditemukan")
+	}
+	return s.startFlow(ip, &objID)
+}
+
+func (s *OIDCService) startFlow(ip string, linkUserID *primitive.ObjectID) (string, error) {
+	if s.provider == nil {
+		return "", errors.New("login SSO tidak diaktifkan")
+	}
+
+	state, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := helper.GeneratePKCE()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.stateRepo.Create(&model.OIDCLoginState{
+		StateHash:    helper.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		IP:           ip,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(s.cfg.OIDCStateTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return s.provider.AuthCodeURL(ctx, state, nonce, challenge)
+}
+
+// Callback menukar code dari IdP, memetakan identitas ke model.User
+// (membuat user baru jika diizinkan) dan menyelesaikan login. User baru dan
+// link yang dibuat selama callback dicatat di audit log.
+func (s *OIDCService) Callback(c *fiber.Ctx, code, state string) (*model.LoginResponse, *model.MFAChallenge, error) {
+	if s.provider == nil {
+		return nil, nil, errors.New("login SSO tidak diaktifkan")
+	}
+
+	loginState, err := s.stateRepo.Consume(helper.HashToken(state))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	identity, err := s.provider.Exchange(ctx, code, loginState.CodeVerifier)
+	if err != nil {
+		log.Printf("[ERROR] OIDCService Exchange: %v", err)
+		return nil, nil, errors.New("verifikasi login SSO gagal")
+	}
+	if identity.Nonce != loginState.Nonce {
+		log.Printf("[ERROR] OIDCService: nonce ID token tidak cocok (sub %s)", identity.Subject)
+		return nil, nil, errors.New("verifikasi login SSO gagal")
+	}
+
+	attempt := &model.LoginAttempt{
+		Identifier: "oidc:" + identity.Subject,
+		IP:         c.IP(),
+		UserAgent:  c.Get(fiber.HeaderUserAgent),
+	}
+
+	var user *model.User
+	if loginState.LinkUserID != nil {
+		user, err = s.linkSubject(c, *loginState.LinkUserID, identity)
+	} else {
+		user, err = s.resolveUser(c, identity)
+	}
+	if err != nil {
+		s.auth.recordAttempt(attempt, model.LoginResultUnknownUser)
+		return nil, nil, err
+	}
+	attempt.UserID = &user.ID
+
+	if user.IsDisabled {
+		s.auth.recordAttempt(attempt, model.LoginResultDisabled)
+		return nil, nil, errors.New("akun dinonaktifkan")
+	}
+
+	s.linkAlumni(c, user, identity)
+
+	// 2FA lokal tetap berlaku untuk akun yang mengaktifkannya
+	if user.TOTPEnabled {
+		s.auth.recordAttempt(attempt, model.LoginResultMFAChallenge)
+		challenge, err := s.auth.mfaChallenge(user, model.TokenPurposeMFA)
+		return nil, challenge, err
+	}
+	if s.auth.mfaRequired(user) {
+		s.auth.recordAttempt(attempt, model.LoginResultMFAChallenge)
+		challenge, err := s.auth.mfaChallenge(user, model.TokenPurposeMFASetup)
+		return nil, challenge, err
+	}
+
+	response, err := s.auth.completeLogin(user, "user:"+user.ID.Hex(), attempt)
+	return response, nil, err
+}
+
+// resolveUser mencari user berdasarkan subject, lalu email (hanya user role
+// "user" yang emailnya terverifikasi di IdP dan di akun lokal), dan terakhir
+// membuat user baru dengan role "user"
+func (s *OIDCService) resolveUser(c *fiber.Ctx, identity *model.OIDCIdentity) (*model.User, error) {
+	user, err := s.authRepo.GetUserByOIDCSubject(identity.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		log.Printf("[ERROR] OIDCService GetUserByOIDCSubject: %v", err)
+		return nil, errors.New("error database")
+	}
+
+	if identity.Email == "" {
+		return nil, errors.New("IdP tidak mengirim email untuk akun ini")
+	}
+
+	user, err = s.authRepo.GetUserByEmail(identity.Email)
+	if err == nil {
+		// Akun lokal hanya boleh diambil alih jika IdP menjamin kepemilikan email
+		if !identity.EmailVerified {
+			return nil, errors.New("email akun SSO belum terverifikasi")
+		}
+		// Pendaftaran mandiri tidak memverifikasi email, jadi akun dengan email
+		// yang belum terbukti (atau akun admin) harus dihubungkan sendiri oleh
+		// pemiliknya lewat /auth/oidc/link setelah login
+		if user.Role != model.RoleUser || !user.HasVerifiedEmail() {
+			log.Printf("[WARN] Login SSO %s cocok dengan email user %s, tidak dihubungkan otomatis", identity.Subject, user.Username)
+			return nil, errors.New("email sudah dipakai akun lain, login lalu hubungkan akun SSO dari profil")
+		}
+		before := *user
+		if err := s.authRepo.LinkOIDCSubject(user.ID, identity.Subject); err != nil {
+			return nil, err
+		}
+		user.OIDCSubject = identity.Subject
+		log.Printf("User %s dihubungkan ke akun SSO %s", user.Username, identity.Subject)
+		s.audit.Record(c, model.AuditActionLink, model.AuditEntityUser, user.ID.Hex(), &before, user)
+		return user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		log.Printf("[ERROR] OIDCService GetUserByEmail: %v", err)
+		return nil, errors.New("error database")
+	}
+
+	if !s.cfg.OIDCAutoProvision {
+		return nil, errors.New("akun SSO belum terdaftar")
+	}
+	return s.provisionUser(c, identity)
+}
+
+// linkSubject menghubungkan subject IdP ke user yang memulai alur lewat
+// StartLink (sudah terautentikasi), tanpa syarat email
+func (s *OIDCService) linkSubject(c *fiber.Ctx, userID primitive.ObjectID, identity *model.OIDCIdentity) (*model.User, error) {
+	user, err := s.authRepo.GetUserByID(userID.Hex())
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("user tidak ditemukan")
+		}
+		log.Printf("[ERROR] OIDCService GetUserByID: %v", err)
+		return nil, errors.New("error database")
+	}
+	if user.OIDCSubject == identity.Subject {
+		return user, nil
+	}
+	before := *user
+	if err := s.authRepo.LinkOIDCSubject(user.ID, identity.Subject); err != nil {
+		return nil, err
+	}
+	user.OIDCSubject = identity.Subject
+	log.Printf("User %s menghubungkan akun SSO %s", user.Username, identity.Subject)
+	s.audit.Record(c, model.AuditActionLink, model.AuditEntityUser, user.ID.Hex(), &before, user)
+	return user, nil
+}
+
+// provisionUser membuat user baru untuk login SSO pertama. Password diisi
+// acak sehingga akun hanya bisa login lewat SSO (atau setelah reset password).
+func (s *OIDCService) provisionUser(c *fiber.Ctx, identity *model.OIDCIdentity) (*model.User, error) {
+	randomPassword, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := helper.HashPassword(randomPassword)
+	if err != nil {
+		log.Printf("[ERROR] OIDCService HashPassword: %v", err)
+		return nil, errors.New("gagal memproses password")
+	}
+
+	verifiedEmail := ""
+	if identity.EmailVerified {
+		verifiedEmail = identity.Email
+	}
+
+	base := ssoUsername(identity)
+	username := base
+	for i := 0; i < 3; i++ {
+		user, err := s.authRepo.CreateUser(&model.User{
+			Username:      username,
+			Email:         identity.Email,
+			VerifiedEmail: verifiedEmail,
+			PasswordHash:  hash,
+			Role:          model.RoleUser,
+			OIDCSubject:   identity.Subject,
+		})
+		if err == nil {
+			log.Printf("User %s dibuat otomatis dari login SSO", user.Username)
+			s.audit.Record(c, model.AuditActionRegister, model.AuditEntityUser, user.ID.Hex(), nil, user)
+			return user, nil
+		}
+		if err.Error() != "username atau email sudah digunakan" {
+			return nil, err
+		}
+
+		// Username bentrok, coba lagi dengan akhiran acak
+		suffix := make([]byte, 2)
+		if _, err := rand.Read(suffix); err != nil {
+			return nil, err
+		}
+		username = base + "-" + hex.EncodeToString(suffix)
+	}
+	return nil, errors.New("gagal membuat username unik untuk akun SSO")
+}
+
+// linkAlumni menghubungkan user ke data alumni yang cocok (NIM dari IdP atau
+// email terverifikasi) jika user belum punya data alumni
+func (s *OIDCService) linkAlumni(c *fiber.Ctx, user *model.User, identity *model.OIDCIdentity) {
+	if user.Role != model.RoleUser {
+		return
+	}
+	if _, err := s.alumniRepo.GetByUserID(user.ID.Hex()); err == nil {
+		return
+	}
+
+	email := ""
+	if identity.EmailVerified {
+		email = identity.Email
+	}
+	alumni, err := s.alumniRepo.LinkUserByEmailOrNIM(user.ID, email, identity.NIM)
+	if err != nil {
+		if err.Error() != "alumni tidak ditemukan" {
+			log.Printf("[ERROR] OIDCService LinkUserByEmailOrNIM: %v", err)
+		}
+		return
+	}
+	log.Printf("User %s dihubungkan ke alumni %s (NIM %s)", user.Username, alumni.ID.Hex(), alumni.NIM)
+	s.audit.Record(c, model.AuditActionLink, model.AuditEntityAlumni, alumni.ID.Hex(), nil, alumni)
+}
+
+// ssoUsername menurunkan username dari preferred_username atau email
+func ssoUsername(identity *model.OIDCIdentity) string {
+	name := identity.PreferredUsername
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+	name = usernameUnsafeChars.ReplaceAllString(strings.ToLower(name), "")
+	if len(name) < 3 {
+		name = "sso-" + name
+	}
+	return name
+}
+
+// --- Handlers ---
+
+// HandleOIDCLogin godoc
+// @Summary Mulai Login SSO
+// @Description Redirect ke halaman login IdP kampus (OIDC authorization code + PKCE). Tambahkan ?format=json untuk menerima URL-nya saja.
+// @Tags Auth
+// @Produce json
+// @Param format query string false "Isi 'json' untuk mengembalikan authorization_url tanpa redirect"
+// @Success 200 {object} helper.Response{data=model.OIDCAuthURLResponse} "URL login IdP"
+// @Success 302 "Redirect ke IdP"
+// @Failure 404 {object} helper.Response "Login SSO tidak diaktifkan"
+// @Router /auth/oidc/login [get]
+
+func (s *OIDCService) HandleOIDCLogin(c *fiber.Ctx) error {
+	authURL, err := s.StartLogin(c.IP())
+	if err != nil {
+		if err.Error() == "login SSO tidak diaktifkan" {
+			return helper.ErrorResponse(c, fiber.StatusNotFound, "Login SSO tidak diaktifkan")
+		}
+		log.Printf("[ERROR] OIDCService StartLogin: %v", err)
+		return helper.ErrorResponse(c, fiber.StatusBadGateway, "Gagal menghubungi IdP kampus")
+	}
+
+	if c.Query("format") == "json" {
+		return helper.SuccessResponse(c, "Authorization URL created", model.OIDCAuthURLResponse{AuthorizationURL: authURL})
+	}
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// HandleOIDCLink godoc
+// @Summary Hubungkan Akun SSO
+// @Description Memulai login SSO untuk menghubungkan akun SSO kampus ke akun yang sedang login. Buka authorization_url di browser; setelah callback, login SSO berikutnya masuk ke akun ini.
+// @Tags Auth
+// @Produce json
+// @Security ApiKeyAuth[]
+// @Success 200 {object} helper.Response{data=model.OIDCAuthURLResponse} "URL login IdP"
+// @Failure 401 {object} helper.Response "Token tidak valid"
+// @Failure 404 {object} helper.Response "Login SSO tidak diaktifkan"
+// @Router /auth/oidc/link [post]
+
+func (s *OIDCService) HandleOIDCLink(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	authURL, err := s.StartLink(userID, c.IP())
+	if err != nil {
+		switch err.Error() {
+		case "login SSO tidak diaktifkan":
+			return helper.ErrorResponse(c, fiber.StatusNotFound, "Login SSO tidak diaktifkan")
+		case "user tidak ditemukan":
+			return helper.ErrorResponse(c, fiber.StatusNotFound, "User tidak ditemukan")
+		}
+		log.Printf("[ERROR] OIDCService StartLink: %v", err)
+		return helper.ErrorResponse(c, fiber.StatusBadGateway, "Gagal menghubungi IdP kampus")
+	}
+	return helper.SuccessResponse(c, "Authorization URL created", model.OIDCAuthURLResponse{AuthorizationURL: authURL})
+}
+
+// HandleOIDCCallback godoc
+// @Summary Callback Login SSO
+// @Description Menerima code dari IdP kampus, menghubungkan/membuat user, lalu mengembalikan token seperti /auth/login.
+// @Tags Auth
+// @Produce json
+// @Param code query string true "Authorization code dari IdP"
+// @Param state query string true "State dari /auth/oidc/login"
+// @Success 200 {object} helper.Response{data=model.LoginResponse} "Login berhasil"
+// @Success 202 {object} helper.Response{data=model.MFAChallenge} "Verifikasi 2FA diperlukan"
+// @Failure 400 {object} helper.Response "State tidak valid atau kedaluwarsa"
+// @Failure 401 {object} helper.Response "Login di IdP gagal atau ID token tidak valid"
+// @Failure 403 {object} helper.Response "Akun dinonaktifkan atau belum terdaftar"
+// @Failure 409 {object} helper.Response "Email dipakai akun lain yang harus dihubungkan lewat /auth/oidc/link"
+// @Router /auth/oidc/callback [get]
+
+func (s *OIDCService) HandleOIDCCallback(c *fiber.Ctx) error {
+	if idpErr := c.Query("error"); idpErr != "" {
+		return helper.ErrorResponse(c, fiber.StatusUnauthorized, "Login SSO dibatalkan atau ditolak IdP: "+idpErr)
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Parameter code dan state harus diisi")
+	}
+
+	response, challenge, err := s.Callback(c, code, state)
+	if err != nil {
+		msg := err.Error()
+		switch msg {
+		case "login SSO tidak diaktifkan":
+			return helper.ErrorResponse(c, fiber.StatusNotFound, "Login SSO tidak diaktifkan")
+		case "state login SSO tidak valid atau sudah kedaluwarsa":
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, msg)
+		case "verifikasi login SSO gagal":
+			return helper.ErrorResponse(c, fiber.StatusUnauthorized, msg)
+		case "akun dinonaktifkan":
+			return helper.ErrorResponse(c, fiber.StatusForbidden, "Akun Anda dinonaktifkan")
+		case "user tidak ditemukan":
+			return helper.ErrorResponse(c, fiber.StatusNotFound, "User tidak ditemukan")
+		case "akun SSO belum terdaftar", "email akun SSO belum terverifikasi", "IdP tidak mengirim email untuk akun ini":
+			return helper.ErrorResponse(c, fiber.StatusForbidden, msg)
+		case "akun SSO sudah terhubung ke user lain", "user sudah terhubung ke akun SSO lain",
+			"email sudah dipakai akun lain, login lalu hubungkan akun SSO dari profil":
+			return helper.ErrorResponse(c, fiber.StatusConflict, msg)
+		default:
+			log.Printf("[ERROR] OIDCService Callback: %v", err)
+			return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memproses login SSO")
+		}
+	}
+
+	if challenge != nil {
+		c.Status(fiber.StatusAccepted)
+		if challenge.MFASetupRequired {
+			return helper.SuccessResponse(c, "Admin wajib mengaktifkan 2FA sebelum login", challenge)
+		}
+		return helper.SuccessResponse(c, "Verifikasi 2FA diperlukan", challenge)
+	}
+	return helper.SuccessResponse(c, "Login berhasil", response)
+}
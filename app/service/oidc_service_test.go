@@ -0,0 +1,385 @@
+package service
+
+import (
+	"alumni-crud-api/app/model"
+	"alumni-crud-api/config"
+	"alumni-crud-api/helper"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/valyala/fasthttp"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// mockIdP adalah identity provider OIDC lokal: discovery, JWKS dan token
+// endpoint yang memeriksa PKCE lalu menerbitkan ID token RS256
+type mockIdP struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+
+	mu    sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+type mockAuthorization struct {
+	claims    jwt.MapClaims
+	challenge string
+}
+
+func newMockIdP(t *testing.T, clientID string) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{key: key, clientID: clientID, codes: map[string]mockAuthorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "idp-1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", idp.handleToken)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// authorize mensimulasikan user login di halaman IdP: parameter dari URL
+// authorization disimpan dan code dikembalikan seperti redirect ke callback
+func (idp *mockIdP) authorize(t *testing.T, authURL string, claims jwt.MapClaims) (code, state string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != idp.clientID {
+		t.Fatalf("authorization URL tidak valid: %s", authURL)
+	}
+
+	withNonce := jwt.MapClaims{"nonce": q.Get("nonce")}
+	for k, v := range claims {
+		withNonce[k] = v
+	}
+	code, _ = helper.GenerateOpaqueToken()
+	idp.mu.Lock()
+	idp.codes[code] = mockAuthorization{claims: withNonce, challenge: q.Get("code_challenge")}
+	idp.mu.Unlock()
+	return code, q.Get("state")
+}
+
+func (idp *mockIdP) handleToken(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	idp.mu.Lock()
+	auth, ok := idp.codes[r.Form.Get("code")]
+	delete(idp.codes, r.Form.Get("code"))
+	idp.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss": idp.server.URL,
+		"aud": idp.clientID,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(5 * time.Minute).Unix(),
+	}
+	for k, v := range auth.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "idp-1"
+	signed, _ := token.SignedString(idp.key)
+	json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+}
+
+type oidcTestEnv struct {
+	idp      *mockIdP
+	service  *OIDCService
+	authRepo *fakeAuthRepo
+	alumni   *fakeAlumniRepo
+	attempts *fakeAttemptRepo
+	audit    *fakeAudit
+}
+
+func newOIDCTestEnv(t *testing.T, users ...*model.User) *oidcTestEnv {
+	t.Helper()
+	setupTestJWT(t)
+	idp := newMockIdP(t, "alumni-api")
+	cfg := &config.Config{
+		OIDCIssuerURL:     idp.server.URL,
+		OIDCClientID:      "alumni-api",
+		OIDCRedirectURL:   "http://localhost/alumni-crud-api/auth/oidc/callback",
+		OIDCScopes:        []string{"openid", "email", "profile"},
+		OIDCNIMClaim:      "nim",
+		OIDCAutoProvision: true,
+		OIDCStateTTL:      5 * time.Minute,
+	}
+	env := &oidcTestEnv{
+		idp:      idp,
+		authRepo: newFakeAuthRepo(users...),
+		alumni:   &fakeAlumniRepo{},
+		attempts: newFakeAttemptRepo(),
+		audit:    &fakeAudit{},
+	}
+	auth := NewAuthService(env.authRepo, &fakeTokenRepo{}, nil, env.attempts, nil, nil, cfg)
+	env.service = NewOIDCService(auth, helper.NewOIDCProvider(cfg), newFakeStateRepo(), env.authRepo, env.alumni, env.audit, cfg)
+	return env
+}
+
+// callback memanggil Callback dengan request redirect dari IdP
+func (env *oidcTestEnv) callback(code, state string) (*model.LoginResponse, error) {
+	app := fiber.New()
+	c := app.AcquireCtx(&fasthttp.RequestCtx{})
+	defer app.ReleaseCtx(c)
+	c.Request().Header.SetUserAgent("test")
+	response, _, err := env.service.Callback(c, code, state)
+	return response, err
+}
+
+// login menjalankan seluruh alur: StartLogin, login di IdP, lalu Callback
+func (env *oidcTestEnv) login(t *testing.T, claims jwt.MapClaims) (*model.LoginResponse, error) {
+	t.Helper()
+	authURL, err := env.service.StartLogin("127.0.0.1")
+	if err != nil {
+		t.Fatalf("StartLogin: %v", err)
+	}
+	code, state := env.idp.authorize(t, authURL, claims)
+	return env.callback(code, state)
+}
+
+func TestOIDCCallbackResolveUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       *model.User // User lokal yang sudah ada
+		claims     jwt.MapClaims
+		wantErr    string
+		wantLinked bool // Subject tersimpan di user lokal
+		wantAudit  []string
+	}{
+		{
+			name:   "subject yang sudah terhubung",
+			user:   &model.User{Username: "budi", Email: "budi@kampus.ac.id", Role: model.RoleUser, OIDCSubject: "sub-budi"},
+			claims: jwt.MapClaims{"sub": "sub-budi", "email": "lain@kampus.ac.id", "email_verified": true},
+		},
+		{
+			name:       "email terverifikasi di IdP dan akun lokal",
+			user:       &model.User{Username: "budi", Email: "budi@kampus.ac.id", VerifiedEmail: "budi@kampus.ac.id", Role: model.RoleUser},
+			claims:     jwt.MapClaims{"sub": "sub-budi", "email": "budi@kampus.ac.id", "email_verified": true},
+			wantLinked: true,
+			wantAudit:  []string{"link:user"},
+		},
+		{
+			name:    "email lokal hasil daftar mandiri belum terverifikasi",
+			user:    &model.User{Username: "penyerang", Email: "budi@kampus.ac.id", Role: model.RoleUser},
+			claims:  jwt.MapClaims{"sub": "sub-budi", "email": "budi@kampus.ac.id", "email_verified": true},
+			wantErr: "email sudah dipakai akun lain, login lalu hubungkan akun SSO dari profil",
+		},
+		{
+			name:    "email lokal terverifikasi tapi sudah diganti",
+			user:    &model.User{Username: "budi", Email: "budi@kampus.ac.id", VerifiedEmail: "budi.lama@kampus.ac.id", Role: model.RoleUser},
+			claims:  jwt.MapClaims{"sub": "sub-budi", "email": "budi@kampus.ac.id", "email_verified": true},
+			wantErr: "email sudah dipakai akun lain, login lalu hubungkan akun SSO dari profil",
+		},
+		{
+			name:    "akun admin tidak dihubungkan otomatis",
+			user:    &model.User{Username: "admin", Email: "admin@kampus.ac.id", VerifiedEmail: "admin@kampus.ac.id", Role: model.RoleAdmin},
+			claims:  jwt.MapClaims{"sub": "sub-admin", "email": "admin@kampus.ac.id", "email_verified": true},
+			wantErr: "email sudah dipakai akun lain, login lalu hubungkan akun SSO dari profil",
+		},
+		{
+			name:    "email IdP belum terverifikasi",
+			user:    &model.User{Username: "budi", Email: "budi@kampus.ac.id", VerifiedEmail: "budi@kampus.ac.id", Role: model.RoleUser},
+			claims:  jwt.MapClaims{"sub": "sub-budi", "email": "budi@kampus.ac.id", "email_verified": false},
+			wantErr: "email akun SSO belum terverifikasi",
+		},
+		{
+			name:    "IdP tanpa email",
+			claims:  jwt.MapClaims{"sub": "sub-x"},
+			wantErr: "IdP tidak mengirim email untuk akun ini",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var users []*model.User
+			if tt.user != nil {
+				users = append(users, tt.user)
+			}
+			env := newOIDCTestEnv(t, users...)
+
+			response, err := env.login(t, tt.claims)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				if tt.user != nil && tt.user.OIDCSubject != "" {
+					t.Errorf("user %s terhubung ke %s", tt.user.Username, tt.user.OIDCSubject)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Callback error: %v", err)
+			}
+			if response.User.ID != tt.user.ID || response.Token == "" || response.RefreshToken == "" {
+				t.Errorf("login sebagai %s (%s), want %s", response.User.Username, response.User.ID.Hex(), tt.user.Username)
+			}
+			if tt.wantLinked && tt.user.OIDCSubject != tt.claims["sub"] {
+				t.Errorf("OIDCSubject = %q, want %q", tt.user.OIDCSubject, tt.claims["sub"])
+			}
+			if got := strings.Join(env.audit.actions(), ","); got != strings.Join(tt.wantAudit, ",") {
+				t.Errorf("audit = %v, want %v", got, tt.wantAudit)
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackProvisionsUser(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	response, err := env.login(t, jwt.MapClaims{
+		"sub": "sub-baru", "email": "Siti@Kampus.ac.id", "email_verified": true, "preferred_username": "Siti.A",
+	})
+	if err != nil {
+		t.Fatalf("Callback error: %v", err)
+	}
+	if response.User.Username != "siti.a" || response.User.Role != model.RoleUser || response.User.Email != "siti@kampus.ac.id" {
+		t.Errorf("user baru = %+v", response.User)
+	}
+	stored := env.authRepo.byID(response.User.ID)
+	if stored == nil || stored.OIDCSubject != "sub-baru" || !stored.HasVerifiedEmail() {
+		t.Errorf("user tersimpan = %+v, want subject dan email terverifikasi", stored)
+	}
+
+	// Login berikutnya memakai subject yang sama, bukan membuat user lagi
+	again, err := env.login(t, jwt.MapClaims{"sub": "sub-baru", "email": "siti@kampus.ac.id", "email_verified": true})
+	if err != nil || again.User.ID != response.User.ID {
+		t.Fatalf("login kedua = %v, %v", again, err)
+	}
+	if len(env.authRepo.users) != 1 {
+		t.Errorf("jumlah user = %d, want 1", len(env.authRepo.users))
+	}
+	if got := env.audit.actions(); !reflect.DeepEqual(got, []string{"register:user"}) {
+		t.Errorf("audit = %v, want hanya register:user", got)
+	}
+}
+
+func TestOIDCCallbackLinksAlumniByNIM(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	alumni := &model.Alumni{ID: primitive.NewObjectID(), NIM: "2101001", Email: "budi@mail.com", Version: 3}
+	env.alumni.alumni = []*model.Alumni{alumni}
+
+	response, err := env.login(t, jwt.MapClaims{"sub": "sub-budi", "email": "budi@kampus.ac.id", "email_verified": true, "nim": "2101001"})
+	if err != nil {
+		t.Fatalf("Callback error: %v", err)
+	}
+	if alumni.UserID != response.User.ID {
+		t.Errorf("alumni.UserID = %s, want %s", alumni.UserID.Hex(), response.User.ID.Hex())
+	}
+	want := []string{"register:user", "link:alumni"}
+	if got := env.audit.actions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("audit = %v, want %v", got, want)
+	}
+}
+
+func TestOIDCExplicitLink(t *testing.T) {
+	admin := &model.User{Username: "admin", Email: "admin@kampus.ac.id", Role: model.RoleAdmin}
+	env := newOIDCTestEnv(t, admin)
+	claims := jwt.MapClaims{"sub": "sub-admin", "email": "admin@kampus.ac.id", "email_verified": true}
+
+	if _, err := env.login(t, claims); err == nil {
+		t.Fatal("login SSO tanpa link berhasil, want ditolak")
+	}
+
+	// Admin yang sudah login memulai alur link, lalu login di IdP
+	authURL, err := env.service.StartLink(admin.ID.Hex(), "127.0.0.1")
+	if err != nil {
+		t.Fatalf("StartLink: %v", err)
+	}
+	code, state := env.idp.authorize(t, authURL, claims)
+	response, err := env.callback(code, state)
+	if err != nil {
+		t.Fatalf("Callback link: %v", err)
+	}
+	if response.User.ID != admin.ID || admin.OIDCSubject != "sub-admin" {
+		t.Fatalf("link ke %s, subject %q", response.User.Username, admin.OIDCSubject)
+	}
+	if got := env.audit.actions(); !reflect.DeepEqual(got, []string{"link:user"}) {
+		t.Errorf("audit = %v, want link:user", got)
+	}
+
+	// Setelah dihubungkan, login SSO biasa masuk ke akun admin
+	response, err = env.login(t, claims)
+	if err != nil || response.User.ID != admin.ID {
+		t.Fatalf("login setelah link = %v, %v", response, err)
+	}
+}
+
+func TestOIDCCallbackRejectsTampering(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	claims := jwt.MapClaims{"sub": "sub-x", "email": "x@kampus.ac.id", "email_verified": true}
+
+	t.Run("state dipakai ulang", func(t *testing.T) {
+		authURL, _ := env.service.StartLogin("127.0.0.1")
+		code, state := env.idp.authorize(t, authURL, claims)
+		if _, err := env.callback(code, state); err != nil {
+			t.Fatalf("Callback pertama: %v", err)
+		}
+		code, _ = env.idp.authorize(t, authURL, claims)
+		_, err := env.callback(code, state)
+		if err == nil || err.Error() != "state login SSO tidak valid atau sudah kedaluwarsa" {
+			t.Fatalf("error = %v", err)
+		}
+	})
+
+	t.Run("nonce tidak cocok", func(t *testing.T) {
+		authURL, _ := env.service.StartLogin("127.0.0.1")
+		code, state := env.idp.authorize(t, authURL, jwt.MapClaims{"sub": "sub-x", "nonce": "nonce-lain"})
+		_, err := env.callback(code, state)
+		if err == nil || err.Error() != "verifikasi login SSO gagal" {
+			t.Fatalf("error = %v", err)
+		}
+	})
+
+	t.Run("code dari alur lain (PKCE tidak cocok)", func(t *testing.T) {
+		otherURL, _ := env.service.StartLogin("127.0.0.1")
+		code, _ := env.idp.authorize(t, otherURL, claims)
+		authURL, _ := env.service.StartLogin("127.0.0.1")
+		_, state := env.idp.authorize(t, authURL, claims)
+		_, err := env.callback(code, state)
+		if err == nil || err.Error() != "verifikasi login SSO gagal" {
+			t.Fatalf("error = %v", err)
+		}
+	})
+}
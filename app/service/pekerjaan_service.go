@@ -3,8 +3,10 @@ package service
 import (
 	"alumni-crud-api/app/model"
 	"alumni-crud-api/app/repository"
+	"alumni-crud-api/config"
 	"alumni-crud-api/helper"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -14,47 +16,87 @@ import (
 )
 
 type PekerjaanService interface {
-	GetAllPekerjaan() ([]model.PekerjaanAlumni, error)
-	GetPekerjaanByID(id string) (*model.PekerjaanAlumni, error)
-	GetPekerjaanByAlumniID(alumniID string) ([]model.PekerjaanAlumni, error)
-	CreatePekerjaan(req *model.CreatePekerjaanRequest) (*model.PekerjaanAlumni, error)
-	UpdatePekerjaan(id string, req *model.UpdatePekerjaanRequest) (*model.PekerjaanAlumni, error)
-	DeletePekerjaan(id string) error // Ini adalah hard delete (admin only)
-	GetPekerjaanWithPagination(search, sortBy, order string, page, limit int) (*model.PekerjaanResponse, error)
-	SoftDeletePekerjaan(id string, userID string, role string) error
-	ListTrash(search string, page, limit int, userID string, role string) ([]model.PekerjaanAlumni, error)
-	RestorePekerjaan(id string, userID string, role string) error
-	HardDeletePekerjaan(id string, userID string, role string) error
+	GetAllPekerjaan(scope *model.DataScope) ([]model.PekerjaanAlumni, error)
+	GetPekerjaanByID(id string, scope *model.DataScope) (*model.PekerjaanAlumni, error)
+	GetPekerjaanByAlumniID(alumniID string, scope *model.DataScope) ([]model.PekerjaanAlumni, error)
+	GetMyPekerjaan(actor *model.Actor) ([]model.PekerjaanAlumni, error)
+	CreatePekerjaan(req *model.CreatePekerjaanRequest, actor *model.Actor) (*model.PekerjaanAlumni, error)
+	UpdatePekerjaan(id string, req *model.UpdatePekerjaanRequest, actor *model.Actor, version int) (*model.PekerjaanAlumni, *model.PendingChange, error)
+	PatchPekerjaan(id string, patch []byte, contentType string, actor *model.Actor, version int) (*model.PekerjaanAlumni, *model.PendingChange, error)
+	DeletePekerjaan(id string, scope *model.DataScope, version int) error // Hard delete, membutuhkan permission pekerjaan:hard_delete
+	GetPekerjaanWithPagination(search, sortBy, order string, page, limit int, scope *model.DataScope) (*model.PekerjaanResponse, error)
+	SoftDeletePekerjaan(id string, actor *model.Actor, version int, reason string) error
+	ListTrash(search string, page, limit int, actor *model.Actor) (*model.PekerjaanTrashResponse, error)
+	RestorePekerjaan(id string, actor *model.Actor, version int) error
+	HardDeletePekerjaan(id string, actor *model.Actor, version int) error
+	SetLegalHold(id string, req *model.SetLegalHoldRequest, actor *model.Actor) (*model.PekerjaanAlumni, error)
 
 	HandleGetAllPekerjaan(c *fiber.Ctx) error
 	HandleGetPekerjaanByID(c *fiber.Ctx) error
 	HandleGetPekerjaanByAlumniID(c *fiber.Ctx) error
+	HandleGetMyPekerjaan(c *fiber.Ctx) error
 	HandleCreatePekerjaan(c *fiber.Ctx) error
 	HandleUpdatePekerjaan(c *fiber.Ctx) error
+	HandlePatchPekerjaan(c *fiber.Ctx) error
 	HandleDeletePekerjaan(c *fiber.Ctx) error
 	HandleSoftDeletePekerjaan(c *fiber.Ctx) error
 	HandleListTrash(c *fiber.Ctx) error
 	HandleRestorePekerjaan(c *fiber.Ctx) error
 	HandleHardDeletePekerjaan(c *fiber.Ctx) error
+	HandleSetLegalHold(c *fiber.Ctx) error
+	HandleBulkRestore(c *fiber.Ctx) error
+	HandleBulkPurge(c *fiber.Ctx) error
+	HandleEmptyTrash(c *fiber.Ctx) error
 }
 
+// MaxBulkTrashItems membatasi jumlah item satu operasi massal trash
+const MaxBulkTrashItems = 500
+
 type pekerjaanService struct {
 	pekerjaanRepo repository.PekerjaanRepository
 	alumniRepo    repository.AlumniRepository
+	moderation    ModerationService
+	audit         AuditService
+	cfg           *config.Config
 }
 
-func NewPekerjaanService(pekerjaanRepo repository.PekerjaanRepository, alumniRepo repository.AlumniRepository) PekerjaanService {
+func NewPekerjaanService(
+	pekerjaanRepo repository.PekerjaanRepository,
+	alumniRepo repository.AlumniRepository,
+	moderation ModerationService,
+	audit AuditService,
+	cfg *config.Config,
+) PekerjaanService {
 	return &pekerjaanService{
 		pekerjaanRepo: pekerjaanRepo,
 		alumniRepo:    alumniRepo,
+		moderation:    moderation,
+		audit:         audit,
+		cfg:           cfg,
 	}
 }
 
-func (s *pekerjaanService) GetAllPekerjaan() ([]model.PekerjaanAlumni, error) {
-	return s.pekerjaanRepo.GetAll()
+func (s *pekerjaanService) GetAllPekerjaan(scope *model.DataScope) ([]model.PekerjaanAlumni, error) {
+	return s.pekerjaanRepo.GetAll(scope)
 }
 
-func (s *pekerjaanService) GetPekerjaanByID(id string) (*model.PekerjaanAlumni, error) {
+// inScope memeriksa apakah alumni pemilik pekerjaan berada dalam scope admin
+func (s *pekerjaanService) inScope(alumniID primitive.ObjectID, scope *model.DataScope) (bool, error) {
+	if scope == nil {
+		return true, nil
+	}
+	alumni, err := s.alumniRepo.GetByIDWithDeleted(alumniID.Hex())
+	if err != nil {
+		if err.Error() == "alumni tidak ditemukan" {
+			return false, nil
+		}
+		return false, err
+	}
+	return scope.Allows(alumni.Jurusan), nil
+}
+
+// GetPekerjaanByID memperlakukan pekerjaan di luar scope sebagai tidak ada
+func (s *pekerjaanService) GetPekerjaanByID(id string, scope *model.DataScope) (*model.PekerjaanAlumni, error) {
 	pekerjaan, err := s.pekerjaanRepo.GetByID(id)
 	if err != nil {
 		if err == mongo.ErrNoDocuments || err.Error() == "pekerjaan tidak ditemukan" {
@@ -62,77 +104,346 @@ func (s *pekerjaanService) GetPekerjaanByID(id string) (*model.PekerjaanAlumni,
 		}
 		return nil, err
 	}
+	ok, err := s.inScope(pekerjaan.AlumniID, scope)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("pekerjaan not found")
+	}
 	return pekerjaan, nil
 }
 
-func (s *pekerjaanService) GetPekerjaanByAlumniID(alumniID string) ([]model.PekerjaanAlumni, error) {
-	// Check if alumni exists
-	_, err := s.alumniRepo.GetByID(alumniID)
+// findAlumni mencari alumni dalam scope; alumni di luar scope dianggap tidak ada
+func (s *pekerjaanService) findAlumni(alumniID string, scope *model.DataScope) (*model.Alumni, error) {
+	alumni, err := s.alumniRepo.GetByID(alumniID)
 	if err != nil {
 		if err == mongo.ErrNoDocuments || err.Error() == "alumni tidak ditemukan" {
 			return nil, errors.New("alumni not found")
 		}
 		return nil, err
 	}
+	if !scope.Allows(alumni.Jurusan) {
+		return nil, errors.New("alumni not found")
+	}
+	return alumni, nil
+}
+
+func (s *pekerjaanService) GetPekerjaanByAlumniID(alumniID string, scope *model.DataScope) ([]model.PekerjaanAlumni, error) {
+	// Check if alumni exists
+	if _, err := s.findAlumni(alumniID, scope); err != nil {
+		return nil, err
+	}
 
 	return s.pekerjaanRepo.GetByAlumniID(alumniID)
 }
 
-func (s *pekerjaanService) CreatePekerjaan(req *model.CreatePekerjaanRequest) (*model.PekerjaanAlumni, error) {
-	// Check if alumni exists
-	_, err := s.alumniRepo.GetByID(req.AlumniID)
+// ownAlumni mengambil data alumni yang terhubung ke akun actor
+func (s *pekerjaanService) ownAlumni(actor *model.Actor) (*model.Alumni, error) {
+	alumni, err := s.alumniRepo.GetByUserID(actor.UserID)
+	if err != nil {
+		return nil, errors.New("profil alumni tidak ditemukan untuk user ini")
+	}
+	return alumni, nil
+}
+
+// ownerApprovalStatus adalah status awal perubahan yang diajukan alumni sendiri
+func (s *pekerjaanService) ownerApprovalStatus() string {
+	if s.cfg.PekerjaanRequireApproval {
+		return model.ApprovalPending
+	}
+	return ""
+}
+
+func (s *pekerjaanService) GetMyPekerjaan(actor *model.Actor) ([]model.PekerjaanAlumni, error) {
+	alumni, err := s.ownAlumni(actor)
 	if err != nil {
-		if err == mongo.ErrNoDocuments || err.Error() == "alumni tidak ditemukan" {
-			return nil, errors.New("alumni not found")
-		}
 		return nil, err
 	}
+	return s.pekerjaanRepo.GetByAlumniID(alumni.ID.Hex())
+}
+
+// CreatePekerjaan: actor dengan permission pekerjaan:write boleh membuat
+// pekerjaan untuk alumni mana pun dalam scope-nya; selain itu alumni_id
+// selalu diambil dari akun yang sedang login.
+func (s *pekerjaanService) CreatePekerjaan(req *model.CreatePekerjaanRequest, actor *model.Actor) (*model.PekerjaanAlumni, error) {
+	approvalStatus := ""
+	if actor.Can(model.PermPekerjaanWrite) {
+		// Check if alumni exists
+		if _, err := s.findAlumni(req.AlumniID, actor.Scope); err != nil {
+			return nil, err
+		}
+	} else {
+		alumni, err := s.ownAlumni(actor)
+		if err != nil {
+			return nil, err
+		}
+		if req.AlumniID != "" && req.AlumniID != alumni.ID.Hex() {
+			return nil, errors.New("access denied: you can only create your own pekerjaan")
+		}
+		req.AlumniID = alumni.ID.Hex()
+		approvalStatus = s.ownerApprovalStatus()
+	}
 
 	// Validate input
-	if err := helper.ValidateCreatePekerjaan(req.AlumniID, req.NamaPerusahaan, req.PosisiJabatan, req.BidangIndustri, req.LokasiKerja, req.TanggalMulaiKerja, req.StatusPekerjaan); err != nil {
+	if req.JenisPekerjaan == "" {
+		req.JenisPekerjaan = model.JenisFullTime
+	}
+	if err := helper.ValidateCreatePekerjaan(req.AlumniID, req.NamaPerusahaan, req.PosisiJabatan, req.BidangIndustri, req.LokasiKerja, req.TanggalMulaiKerja, req.StatusPekerjaan, req.JenisPekerjaan); err != nil {
+		return nil, err
+	}
+	periode, err := helper.ValidatePeriodePekerjaan(req.TanggalMulaiKerja, req.TanggalSelesaiKerja, req.StatusPekerjaan)
+	if err != nil {
+		return nil, err
+	}
+	alumniID, err := primitive.ObjectIDFromHex(req.AlumniID)
+	if err != nil {
+		return nil, fmt.Errorf("Alumni ID tidak valid: %v", err)
+	}
+	if err := checkOverlap(s.pekerjaanRepo, alumniID, req.JenisPekerjaan, periode, nil); err != nil {
 		return nil, err
 	}
 
-	return s.pekerjaanRepo.Create(req)
+	pekerjaan, err := s.pekerjaanRepo.Create(req, periode, approvalStatus)
+	if err != nil {
+		return nil, err
+	}
+	if approvalStatus == model.ApprovalPending {
+		if _, err := s.moderation.SubmitPekerjaanCreate(actor, pekerjaan); err != nil {
+			return nil, err
+		}
+	}
+	return pekerjaan, nil
 }
 
-func (s *pekerjaanService) UpdatePekerjaan(id string, req *model.UpdatePekerjaanRequest) (*model.PekerjaanAlumni, error) {
-	// Check if pekerjaan exists
-	_, err := s.pekerjaanRepo.GetByID(id)
-	if err != nil {
-		return nil, errors.New("pekerjaan not found")
+// jenisPekerjaan mengembalikan jenis pekerjaan dengan data lama (tanpa jenis)
+// dianggap full time
+func jenisPekerjaan(p *model.PekerjaanAlumni) string {
+	if p.JenisPekerjaan == "" {
+		return model.JenisFullTime
 	}
+	return p.JenisPekerjaan
+}
 
-	if err := helper.ValidateUpdatePekerjaan(req.NamaPerusahaan, req.PosisiJabatan, req.BidangIndustri, req.LokasiKerja, req.TanggalMulaiKerja, req.StatusPekerjaan); err != nil {
+// validatePekerjaanUpdate memvalidasi isi baru pekerjaan current (PUT, PATCH,
+// persetujuan perubahan dan revert) termasuk periode kerjanya
+func validatePekerjaanUpdate(repo repository.PekerjaanRepository, current *model.PekerjaanAlumni, req *model.UpdatePekerjaanRequest) (*model.PeriodePekerjaan, error) {
+	if err := helper.ValidateUpdatePekerjaan(req.NamaPerusahaan, req.PosisiJabatan, req.BidangIndustri, req.LokasiKerja, req.TanggalMulaiKerja, req.StatusPekerjaan, req.JenisPekerjaan); err != nil {
+		return nil, err
+	}
+	periode, err := helper.ValidatePeriodePekerjaan(req.TanggalMulaiKerja, req.TanggalSelesaiKerja, req.StatusPekerjaan)
+	if err != nil {
 		return nil, err
 	}
+	if err := checkOverlap(repo, current.AlumniID, req.JenisPekerjaan, periode, &current.ID); err != nil {
+		return nil, err
+	}
+	return periode, nil
+}
 
-	return s.pekerjaanRepo.Update(id, req)
+// checkOverlap menolak pekerjaan full time yang periodenya bertumpuk dengan
+// pekerjaan full time lain milik alumni yang sama
+func checkOverlap(repo repository.PekerjaanRepository, alumniID primitive.ObjectID, jenis string, periode *model.PeriodePekerjaan, excludeID *primitive.ObjectID) error {
+	if jenis != "" && jenis != model.JenisFullTime {
+		return nil
+	}
+	others, err := repo.FindOverlappingFullTime(alumniID, periode, excludeID)
+	if err != nil {
+		return err
+	}
+
+	// Hasil query disaring ulang dengan Overlaps, acuan aturan tumpang tindih
+	var other *model.PekerjaanAlumni
+	for i := range others {
+		if periode.Overlaps(model.PeriodePekerjaan{Mulai: others[i].TanggalMulaiKerja, Selesai: others[i].TanggalSelesaiKerja}) {
+			other = &others[i]
+			break
+		}
+	}
+	if other == nil {
+		return nil
+	}
+
+	selesai := "sekarang"
+	if other.TanggalSelesaiKerja != nil {
+		selesai = other.TanggalSelesaiKerja.String()
+	}
+	return fmt.Errorf("periode pekerjaan tumpang tindih dengan pekerjaan full time lain: %s di %s (%s s.d. %s)",
+		other.PosisiJabatan, other.NamaPerusahaan, other.TanggalMulaiKerja, selesai)
 }
 
-func (s *pekerjaanService) DeletePekerjaan(id string) error {
-	// Check if pekerjaan exists
-	_, err := s.pekerjaanRepo.GetByID(id)
+// updatable mengambil pekerjaan yang boleh diubah actor: admin dengan
+// pekerjaan:write dalam scope-nya, atau pemilik (owner = true)
+func (s *pekerjaanService) updatable(id string, actor *model.Actor, version int) (*model.PekerjaanAlumni, bool, error) {
+	owner := !actor.Can(model.PermPekerjaanWrite)
+	var current *model.PekerjaanAlumni
+	if owner {
+		pekerjaan, err := s.pekerjaanRepo.GetByID(id)
+		if err != nil {
+			return nil, owner, errors.New("pekerjaan not found")
+		}
+		alumni, err := s.ownAlumni(actor)
+		if err != nil {
+			return nil, owner, err
+		}
+		if pekerjaan.AlumniID != alumni.ID {
+			return nil, owner, errors.New("access denied: you can only update your own pekerjaan")
+		}
+		current = pekerjaan
+	} else {
+		pekerjaan, err := s.GetPekerjaanByID(id, actor.Scope)
+		if err != nil {
+			return nil, owner, err
+		}
+		current = pekerjaan
+	}
+	if !helper.VersionMatches(current.Version, version) {
+		return nil, owner, errors.New("versi data tidak cocok")
+	}
+	return current, owner, nil
+}
+
+// save menulis perubahan lewat write. Untuk pemilik dengan persetujuan admin
+// aktif, perubahan pada pekerjaan yang sudah tampil diajukan sebagai pending
+// change dan data resmi tidak berubah sampai disetujui.
+func (s *pekerjaanService) save(current *model.PekerjaanAlumni, req *model.UpdatePekerjaanRequest, actor *model.Actor, owner bool, write func() (*model.PekerjaanAlumni, error)) (*model.PekerjaanAlumni, *model.PendingChange, error) {
+	if !owner || !s.cfg.PekerjaanRequireApproval {
+		updated, err := write()
+		return updated, nil, err
+	}
+
+	if current.ApprovalStatus != model.ApprovalPending && current.ApprovalStatus != model.ApprovalRejected {
+		change, err := s.moderation.SubmitPekerjaanUpdate(actor, current, req)
+		return nil, change, err
+	}
+
+	// Pekerjaan baru yang belum/tidak disetujui belum tampil, jadi langsung
+	// diperbarui lalu diajukan ulang
+	if _, err := write(); err != nil {
+		return nil, nil, err
+	}
+	updated, err := s.pekerjaanRepo.SetApprovalStatus(current.ID.Hex(), model.ApprovalPending, nil, "")
 	if err != nil {
-		return errors.New("pekerjaan not found")
+		return nil, nil, err
+	}
+	if _, err := s.moderation.SubmitPekerjaanCreate(actor, updated); err != nil {
+		return nil, nil, err
 	}
-	// Ini adalah hard delete, hanya untuk admin
-	return s.pekerjaanRepo.Delete(id)
+	return updated, nil, nil
 }
 
-func (s *pekerjaanService) SoftDeletePekerjaan(id string, userID string, role string) error {
-	deleterObjID, err := primitive.ObjectIDFromHex(userID)
+func (s *pekerjaanService) UpdatePekerjaan(id string, req *model.UpdatePekerjaanRequest, actor *model.Actor, version int) (*model.PekerjaanAlumni, *model.PendingChange, error) {
+	current, owner, err := s.updatable(id, actor, version)
 	if err != nil {
-		return errors.New("user ID tidak valid")
+		return nil, nil, err
 	}
 
-	pekerjaan, err := s.pekerjaanRepo.GetByID(id)
+	// Klien lama yang belum mengirim jenis_pekerjaan tidak mengubah jenisnya
+	if req.JenisPekerjaan == "" {
+		req.JenisPekerjaan = jenisPekerjaan(current)
+	}
+	periode, err := validatePekerjaanUpdate(s.pekerjaanRepo, current, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.save(current, req, actor, owner, func() (*model.PekerjaanAlumni, error) {
+		return s.pekerjaanRepo.Update(id, req, periode, current.Version)
+	})
+}
+
+// PatchPekerjaan menerapkan merge patch / JSON Patch ke field yang bisa diubah
+// lewat PUT. Validasi dilakukan pada hasil gabungan dan hanya field yang
+// berubah yang ditulis.
+func (s *pekerjaanService) PatchPekerjaan(id string, patch []byte, contentType string, actor *model.Actor, version int) (*model.PekerjaanAlumni, *model.PendingChange, error) {
+	current, owner, err := s.updatable(id, actor, version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	base := pekerjaanUpdateFrom(current)
+	var req model.UpdatePekerjaanRequest
+	if err := patchRequest(base, patch, contentType, &req); err != nil {
+		return nil, nil, err
+	}
+	periode, err := validatePekerjaanUpdate(s.pekerjaanRepo, current, &req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fields, err := changedFields(base, &req)
 	if err != nil {
-		return errors.New("pekerjaan not found")
+		return nil, nil, err
+	}
+	if len(fields) == 0 {
+		return current, nil, nil
+	}
+	// Tanggal ditulis sebagai tanggal hasil parse, bukan string dari request
+	if _, ok := fields["tanggal_mulai_kerja"]; ok {
+		fields["tanggal_mulai_kerja"] = periode.Mulai
+	}
+	if _, ok := fields["tanggal_selesai_kerja"]; ok {
+		fields["tanggal_selesai_kerja"] = nil
+		if periode.Selesai != nil {
+			fields["tanggal_selesai_kerja"] = *periode.Selesai
+		}
 	}
 
-	if role != "admin" {
-		alumni, err := s.alumniRepo.GetByUserID(userID)
+	return s.save(current, &req, actor, owner, func() (*model.PekerjaanAlumni, error) {
+		return s.pekerjaanRepo.Patch(id, fields, current.Version)
+	})
+}
+
+// canView menyembunyikan pekerjaan yang belum disetujui dari selain pemilik
+// dan admin yang berwenang
+func (s *pekerjaanService) canView(pekerjaan *model.PekerjaanAlumni, actor *model.Actor) bool {
+	if pekerjaan.ApprovalStatus != model.ApprovalPending && pekerjaan.ApprovalStatus != model.ApprovalRejected {
+		return true
+	}
+	if actor.Can(model.PermModerationReview) || actor.Can(model.PermPekerjaanWrite) {
+		return true
+	}
+	alumni, err := s.ownAlumni(actor)
+	return err == nil && alumni.ID == pekerjaan.AlumniID
+}
+
+func (s *pekerjaanService) DeletePekerjaan(id string, scope *model.DataScope, version int) error {
+	// Check if pekerjaan exists
+	pekerjaan, err := s.GetPekerjaanByID(id, scope)
+	if err != nil {
+		return err
+	}
+	if !helper.VersionMatches(pekerjaan.Version, version) {
+		return errors.New("versi data tidak cocok")
+	}
+	if pekerjaan.LegalHold {
+		return errors.New("pekerjaan dalam legal hold")
+	}
+	// Ini adalah hard delete, dijaga permission pekerjaan:hard_delete di route
+	return s.pekerjaanRepo.Delete(id, pekerjaan.Version)
+}
+
+func (s *pekerjaanService) SoftDeletePekerjaan(id string, actor *model.Actor, version int, reason string) error {
+	deleterObjID, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return errors.New("user ID tidak valid")
+	}
+	reason = strings.TrimSpace(reason)
+	if len([]rune(reason)) > model.MaxDeleteReasonLength {
+		return fmt.Errorf("alasan penghapusan maksimal %d karakter", model.MaxDeleteReasonLength)
+	}
+
+	var pekerjaan *model.PekerjaanAlumni
+	if actor.Can(model.PermPekerjaanManageAny) {
+		if pekerjaan, err = s.GetPekerjaanByID(id, actor.Scope); err != nil {
+			return err
+		}
+	} else {
+		pekerjaan, err = s.pekerjaanRepo.GetByID(id)
+		if err != nil {
+			return errors.New("pekerjaan not found")
+		}
+		alumni, err := s.alumniRepo.GetByUserID(actor.UserID)
 		if err != nil {
 			return errors.New("profil alumni tidak ditemukan untuk user ini")
 		}
@@ -140,19 +451,22 @@ func (s *pekerjaanService) SoftDeletePekerjaan(id string, userID string, role st
 			return errors.New("access denied: you can only delete your own pekerjaan")
 		}
 	}
+	if !helper.VersionMatches(pekerjaan.Version, version) {
+		return errors.New("versi data tidak cocok")
+	}
 
-	return s.pekerjaanRepo.SoftDelete(id, deleterObjID)
+	return s.pekerjaanRepo.SoftDelete(id, deleterObjID, reason, pekerjaan.Version)
 }
 
-func (s *pekerjaanService) GetPekerjaanWithPagination(search, sortBy, order string, page, limit int) (*model.PekerjaanResponse, error) {
+func (s *pekerjaanService) GetPekerjaanWithPagination(search, sortBy, order string, page, limit int, scope *model.DataScope) (*model.PekerjaanResponse, error) {
 	offset := (page - 1) * limit
 
-	pekerjaan, err := s.pekerjaanRepo.GetAllWithPagination(search, sortBy, order, limit, offset)
+	pekerjaan, err := s.pekerjaanRepo.GetAllWithPagination(search, sortBy, order, limit, offset, scope)
 	if err != nil {
 		return nil, err
 	}
 
-	total, err := s.pekerjaanRepo.CountWithSearch(search)
+	total, err := s.pekerjaanRepo.CountWithSearch(search, scope)
 	if err != nil {
 		return nil, err
 	}
@@ -178,7 +492,7 @@ func (s *pekerjaanService) GetPekerjaanWithPagination(search, sortBy, order stri
 	return response, nil
 }
 
-func (s *pekerjaanService) ListTrash(search string, page, limit int, userID string, role string) ([]model.PekerjaanAlumni, error) {
+func (s *pekerjaanService) ListTrash(search string, page, limit int, actor *model.Actor) (*model.PekerjaanTrashResponse, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -187,66 +501,358 @@ func (s *pekerjaanService) ListTrash(search string, page, limit int, userID stri
 	}
 	offset := (page - 1) * limit
 
-	if role == "admin" {
-		return s.pekerjaanRepo.ListTrashAdmin(search, limit, offset)
+	response := &model.PekerjaanTrashResponse{
+		Data: []model.PekerjaanTrashItem{},
+		Meta: model.MetaInfo{
+			Page:   page,
+			Limit:  limit,
+			SortBy: "deleted_at",
+			Order:  "desc",
+			Search: search,
+		},
 	}
 
-	alumni, err := s.alumniRepo.GetByUserID(userID)
-	if err != nil {
-		return []model.PekerjaanAlumni{}, nil // Kembalikan trash kosong
+	var (
+		items []model.PekerjaanTrashItem
+		total int
+		err   error
+	)
+	if actor.Can(model.PermPekerjaanManageAny) {
+		if items, err = s.pekerjaanRepo.ListTrashAdmin(search, limit, offset, actor.Scope); err != nil {
+			return nil, err
+		}
+		if total, err = s.pekerjaanRepo.CountTrashAdmin(search, actor.Scope); err != nil {
+			return nil, err
+		}
+	} else {
+		alumni, err := s.alumniRepo.GetByUserID(actor.UserID)
+		if err != nil {
+			return response, nil // Kembalikan trash kosong
+		}
+		if items, err = s.pekerjaanRepo.ListTrashUser(alumni.ID, search, limit, offset); err != nil {
+			return nil, err
+		}
+		if total, err = s.pekerjaanRepo.CountTrashUser(alumni.ID, search); err != nil {
+			return nil, err
+		}
 	}
-	return s.pekerjaanRepo.ListTrashUser(alumni.ID, search, limit, offset)
+
+	if items != nil {
+		response.Data = items
+	}
+	response.Meta.Total = total
+	response.Meta.Pages = (total + limit - 1) / limit
+	return response, nil
 }
 
-func (s *pekerjaanService) RestorePekerjaan(id string, userID string, role string) error {
+// getTrashed mengambil pekerjaan di trash; untuk admin, pekerjaan di luar
+// scope dianggap tidak ada
+func (s *pekerjaanService) getTrashed(id string, scope *model.DataScope) (*model.PekerjaanAlumni, error) {
 	pekerjaan, err := s.pekerjaanRepo.GetByIDWithDeleted(id)
 	if err != nil {
-		return errors.New("pekerjaan not found")
+		return nil, errors.New("pekerjaan not found")
+	}
+	ok, err := s.inScope(pekerjaan.AlumniID, scope)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("pekerjaan not found")
 	}
 
 	if !pekerjaan.IsDeleted {
-		return errors.New("pekerjaan not found in trash")
+		return nil, errors.New("pekerjaan not found in trash")
 	}
+	return pekerjaan, nil
+}
 
-	if role != "admin" {
-		alumni, err := s.alumniRepo.GetByUserID(userID)
+func (s *pekerjaanService) RestorePekerjaan(id string, actor *model.Actor, version int) error {
+	if actor.Can(model.PermPekerjaanManageAny) {
+		pekerjaan, err := s.getTrashed(id, actor.Scope)
 		if err != nil {
-			return errors.New("profil alumni tidak ditemukan untuk user ini")
+			return err
 		}
-		if pekerjaan.AlumniID != alumni.ID {
-			return errors.New("access denied: you can only restore your own pekerjaan")
+		if !helper.VersionMatches(pekerjaan.Version, version) {
+			return errors.New("versi data tidak cocok")
 		}
+		return s.restore(pekerjaan, actor)
 	}
-	return s.pekerjaanRepo.Restore(id)
-}
 
-func (s *pekerjaanService) HardDeletePekerjaan(id string, userID string, role string) error {
-	pekerjaan, err := s.pekerjaanRepo.GetByIDWithDeleted(id)
+	pekerjaan, err := s.getTrashed(id, nil)
+	if err != nil {
+		return err
+	}
+	alumni, err := s.alumniRepo.GetByUserID(actor.UserID)
 	if err != nil {
-		return errors.New("pekerjaan not found")
+		return errors.New("profil alumni tidak ditemukan untuk user ini")
+	}
+	if pekerjaan.AlumniID != alumni.ID {
+		return errors.New("access denied: you can only restore your own pekerjaan")
+	}
+	if !helper.VersionMatches(pekerjaan.Version, version) {
+		return errors.New("versi data tidak cocok")
 	}
+	return s.restore(pekerjaan, actor)
+}
 
-	if !pekerjaan.IsDeleted {
-		return errors.New("pekerjaan not found in trash")
+// restore menolak pekerjaan yang alumninya masih di trash; pekerjaan tersebut
+// ikut kembali saat alumninya direstore
+func (s *pekerjaanService) restore(pekerjaan *model.PekerjaanAlumni, actor *model.Actor) error {
+	restorerObjID, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return errors.New("user ID tidak valid")
+	}
+	if alumni, err := s.alumniRepo.GetByIDWithDeleted(pekerjaan.AlumniID.Hex()); err == nil && alumni.IsDeleted {
+		return errors.New("alumni pemilik pekerjaan masih di trash")
 	}
+	return s.pekerjaanRepo.Restore(pekerjaan.ID.Hex(), restorerObjID, pekerjaan.Version)
+}
 
-	if role == "admin" {
-		return s.pekerjaanRepo.HardDeleteAdmin(id)
+func (s *pekerjaanService) HardDeletePekerjaan(id string, actor *model.Actor, version int) error {
+	if actor.Can(model.PermPekerjaanHardDelete) {
+		pekerjaan, err := s.getTrashed(id, actor.Scope)
+		if err != nil {
+			return err
+		}
+		if !helper.VersionMatches(pekerjaan.Version, version) {
+			return errors.New("versi data tidak cocok")
+		}
+		if pekerjaan.LegalHold {
+			return errors.New("pekerjaan dalam legal hold")
+		}
+		if pekerjaan.DeletedWithAlumni {
+			return errors.New("pekerjaan terhapus bersama alumni")
+		}
+		return s.pekerjaanRepo.HardDeleteAdmin(id, pekerjaan.Version)
 	}
 
-	alumni, err := s.alumniRepo.GetByUserID(userID)
+	pekerjaan, err := s.getTrashed(id, nil)
+	if err != nil {
+		return err
+	}
+	alumni, err := s.alumniRepo.GetByUserID(actor.UserID)
 	if err != nil {
 		return errors.New("profil alumni tidak ditemukan untuk user ini")
 	}
 	if pekerjaan.AlumniID != alumni.ID {
 		return errors.New("access denied: you can only delete your own pekerjaan")
 	}
+	if !helper.VersionMatches(pekerjaan.Version, version) {
+		return errors.New("versi data tidak cocok")
+	}
+	if pekerjaan.LegalHold {
+		return errors.New("pekerjaan dalam legal hold")
+	}
+	if pekerjaan.DeletedWithAlumni {
+		return errors.New("pekerjaan terhapus bersama alumni")
+	}
+
+	return s.pekerjaanRepo.HardDeleteUser(id, alumni.ID, pekerjaan.Version)
+}
+
+// SetLegalHold memasang atau melepas legal hold. Pekerjaan dalam legal hold
+// tidak bisa dihapus permanen, baik manual maupun oleh auto-purge trash.
+func (s *pekerjaanService) SetLegalHold(id string, req *model.SetLegalHoldRequest, actor *model.Actor) (*model.PekerjaanAlumni, error) {
+	byObjID, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return nil, errors.New("user ID tidak valid")
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Hold && req.Reason == "" {
+		return nil, errors.New("alasan legal hold wajib diisi")
+	}
 
-	return s.pekerjaanRepo.HardDeleteUser(id, alumni.ID)
+	pekerjaan, err := s.pekerjaanRepo.GetByIDWithDeleted(id)
+	if err != nil {
+		return nil, errors.New("pekerjaan not found")
+	}
+	ok, err := s.inScope(pekerjaan.AlumniID, actor.Scope)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("pekerjaan not found")
+	}
+	if pekerjaan.LegalHold == req.Hold && (!req.Hold || pekerjaan.LegalHoldReason == req.Reason) {
+		return pekerjaan, nil
+	}
+	return s.pekerjaanRepo.SetLegalHold(id, req.Hold, byObjID, req.Reason)
+}
+
+// bulkTrashIDs menentukan ID yang diproses operasi massal. Tanpa daftar ID,
+// item diambil dari trash seperti ListTrash: semua trash dalam scope jika
+// actor punya anyPermission, selain itu hanya trash milik sendiri. Untuk hard
+// delete (hardDelete = true) hanya item yang memang bisa dihapus permanen yang
+// diambil, agar item legal hold atau yang ikut terhapus bersama alumninya
+// tidak memenuhi batch dan membuat has_more tidak pernah false.
+func (s *pekerjaanService) bulkTrashIDs(req *model.BulkTrashRequest, actor *model.Actor, anyPermission string, hardDelete bool) ([]string, bool, error) {
+	if len(req.IDs) > 0 {
+		if len(req.IDs) > MaxBulkTrashItems {
+			return nil, false, fmt.Errorf("maksimal %d id per request", MaxBulkTrashItems)
+		}
+		ids := make([]string, 0, len(req.IDs))
+		seen := make(map[string]bool, len(req.IDs))
+		for _, id := range req.IDs {
+			if id = strings.TrimSpace(id); id != "" && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return ids, false, nil
+	}
+	if req.Search == nil {
+		return nil, false, errors.New("ids atau search wajib diisi")
+	}
+
+	var (
+		list []primitive.ObjectID
+		err  error
+	)
+	limit := MaxBulkTrashItems + 1
+	if actor.Can(anyPermission) {
+		if hardDelete {
+			list, err = s.pekerjaanRepo.ListHardDeletableAdmin(*req.Search, limit, actor.Scope)
+		} else {
+			list, err = trashItemIDs(s.pekerjaanRepo.ListTrashAdmin(*req.Search, limit, 0, actor.Scope))
+		}
+	} else {
+		alumni, aErr := s.alumniRepo.GetByUserID(actor.UserID)
+		if aErr != nil {
+			return []string{}, false, nil // Trash kosong
+		}
+		if hardDelete {
+			list, err = s.pekerjaanRepo.ListHardDeletableUser(alumni.ID, *req.Search, limit)
+		} else {
+			list, err = trashItemIDs(s.pekerjaanRepo.ListTrashUser(alumni.ID, *req.Search, limit, 0))
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(list) > MaxBulkTrashItems
+	if hasMore {
+		list = list[:MaxBulkTrashItems]
+	}
+	ids := make([]string, len(list))
+	for i, id := range list {
+		ids[i] = id.Hex()
+	}
+	return ids, hasMore, nil
+}
+
+func trashItemIDs(items []model.PekerjaanTrashItem, err error) ([]primitive.ObjectID, error) {
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(items))
+	for i, p := range items {
+		ids[i] = p.ID
+	}
+	return ids, nil
 }
 
 // --- Handlers ---
 
+// bulkTrashErrorStatus memetakan error restore/hard delete per item ke status
+// HTTP yang sama dengan endpoint per item
+func bulkTrashErrorStatus(err error) int {
+	switch {
+	case err.Error() == "versi wajib diisi":
+		return fiber.StatusPreconditionRequired
+	case helper.IsPreconditionError(err):
+		return fiber.StatusPreconditionFailed
+	case err.Error() == "pekerjaan not found", err.Error() == "pekerjaan not found in trash":
+		return fiber.StatusNotFound
+	case strings.HasPrefix(err.Error(), "access denied"):
+		return fiber.StatusForbidden
+	case err.Error() == "pekerjaan dalam legal hold", err.Error() == "alumni pemilik pekerjaan masih di trash":
+		return fiber.StatusConflict
+	}
+	return fiber.StatusInternalServerError
+}
+
+// runBulkTrash menjalankan op untuk setiap ID dan mencatat audit per item yang
+// berhasil. Kegagalan satu item tidak menghentikan item lainnya.
+//
+// Versi yang diharapkan diambil dari req.Versions. Jika REQUIRE_IF_MATCH aktif,
+// setiap ID di req.IDs wajib punya versi (item tanpa versi gagal dengan 428),
+// sama seperti endpoint per item. Mode search/empty trash memakai AnyVersion
+// karena itemnya dipilih server dan klien tidak mungkin mengetahui versinya.
+func (s *pekerjaanService) runBulkTrash(c *fiber.Ctx, req *model.BulkTrashRequest, anyPermission, action, message string, op func(id string, actor *model.Actor, version int) error) error {
+	actor := c.Locals("actor").(*model.Actor)
+
+	byID := len(req.IDs) > 0
+	ids, hasMore, err := s.bulkTrashIDs(req, actor, anyPermission, action == model.AuditActionHardDelete)
+	if err != nil {
+		if err.Error() == "ids atau search wajib diisi" || strings.HasPrefix(err.Error(), "maksimal") {
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil data trash")
+	}
+
+	report := &model.BulkTrashReport{
+		Requested: len(ids),
+		HasMore:   hasMore,
+		Results:   make([]model.BulkTrashResult, 0, len(ids)),
+	}
+	for _, id := range ids {
+		version, ok := req.Versions[id]
+		if !ok {
+			version = helper.AnyVersion
+		}
+
+		var err error
+		before, _ := s.pekerjaanRepo.GetByIDWithDeleted(id)
+		if !ok && byID && s.cfg.RequireIfMatch {
+			err = errors.New("versi wajib diisi")
+		} else {
+			err = op(id, actor, version)
+		}
+		if err != nil {
+			report.Failed++
+			report.Results = append(report.Results, model.BulkTrashResult{
+				ID: id, Status: bulkTrashErrorStatus(err), Error: err.Error(),
+			})
+			continue
+		}
+
+		var after *model.PekerjaanAlumni
+		if action != model.AuditActionHardDelete {
+			after, _ = s.pekerjaanRepo.GetByIDWithDeleted(id)
+		}
+		s.audit.Record(c, action, model.AuditEntityPekerjaan, id, before, after)
+		report.Succeeded++
+		report.Results = append(report.Results, model.BulkTrashResult{ID: id, Success: true, Status: fiber.StatusOK})
+	}
+
+	return helper.SuccessResponse(c, message, report)
+}
+
+// pekerjaanWriteErrorResponse memetakan error create/update ke status HTTP
+func pekerjaanWriteErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case err.Error() == "pekerjaan not found":
+		return helper.ErrorResponse(c, fiber.StatusNotFound, "Pekerjaan not found")
+	case err.Error() == "alumni not found":
+		return helper.ErrorResponse(c, fiber.StatusNotFound, "Alumni not found")
+	case err.Error() == "profil alumni tidak ditemukan untuk user ini":
+		return helper.ErrorResponse(c, fiber.StatusForbidden, "Akun Anda belum terhubung ke data alumni")
+	case strings.Contains(err.Error(), "access denied"):
+		return helper.ErrorResponse(c, fiber.StatusForbidden, err.Error())
+	case helper.IsPreconditionError(err):
+		return helper.PreconditionErrorResponse(c, err)
+	case strings.HasPrefix(err.Error(), "content type"):
+		c.Set("Accept-Patch", helper.AcceptPatch)
+		return helper.ErrorResponse(c, fiber.StatusUnsupportedMediaType, err.Error())
+	case strings.HasPrefix(err.Error(), "operasi test gagal"),
+		strings.HasPrefix(err.Error(), "periode pekerjaan tumpang tindih"):
+		return helper.ErrorResponse(c, fiber.StatusConflict, err.Error())
+	default:
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+}
+
 func (s *pekerjaanService) HandleGetAllPekerjaan(c *fiber.Ctx) error {
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "10"))
@@ -261,8 +867,9 @@ func (s *pekerjaanService) HandleGetAllPekerjaan(c *fiber.Ctx) error {
 		limit = 10
 	}
 
+	actor := c.Locals("actor").(*model.Actor)
 	if c.Query("page") != "" || c.Query("limit") != "" || c.Query("search") != "" || c.Query("sortBy") != "" {
-		response, err := s.GetPekerjaanWithPagination(search, sortBy, order, page, limit)
+		response, err := s.GetPekerjaanWithPagination(search, sortBy, order, page, limit, actor.Scope)
 		if err != nil {
 			return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil data pekerjaan")
 		}
@@ -274,7 +881,7 @@ func (s *pekerjaanService) HandleGetAllPekerjaan(c *fiber.Ctx) error {
 		})
 	}
 
-	pekerjaan, err := s.GetAllPekerjaan()
+	pekerjaan, err := s.GetAllPekerjaan(actor.Scope)
 	if err != nil {
 		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil data pekerjaan")
 	}
@@ -282,21 +889,27 @@ func (s *pekerjaanService) HandleGetAllPekerjaan(c *fiber.Ctx) error {
 }
 
 func (s *pekerjaanService) HandleGetPekerjaanByID(c *fiber.Ctx) error {
+	actor := c.Locals("actor").(*model.Actor)
 	id := c.Params("id")
-	pekerjaan, err := s.GetPekerjaanByID(id)
+	pekerjaan, err := s.GetPekerjaanByID(id, actor.Scope)
 	if err != nil {
 		if err.Error() == "pekerjaan not found" {
 			return helper.ErrorResponse(c, fiber.StatusNotFound, "Pekerjaan not found")
 		}
 		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil data pekerjaan")
 	}
+	if !s.canView(pekerjaan, actor) {
+		return helper.ErrorResponse(c, fiber.StatusNotFound, "Pekerjaan not found")
+	}
 
+	helper.SetETag(c, pekerjaan.Version)
 	return helper.SuccessResponse(c, "Pekerjaan data retrieved successfully", pekerjaan)
 }
 
 func (s *pekerjaanService) HandleGetPekerjaanByAlumniID(c *fiber.Ctx) error {
+	actor := c.Locals("actor").(*model.Actor)
 	alumniID := c.Params("alumni_id")
-	pekerjaan, err := s.GetPekerjaanByAlumniID(alumniID)
+	pekerjaan, err := s.GetPekerjaanByAlumniID(alumniID, actor.Scope)
 	if err != nil {
 		if err.Error() == "alumni not found" {
 			return helper.ErrorResponse(c, fiber.StatusNotFound, "Alumni not found")
@@ -307,20 +920,35 @@ func (s *pekerjaanService) HandleGetPekerjaanByAlumniID(c *fiber.Ctx) error {
 	return helper.SuccessResponse(c, "Pekerjaan data retrieved successfully", pekerjaan)
 }
 
+func (s *pekerjaanService) HandleGetMyPekerjaan(c *fiber.Ctx) error {
+	actor := c.Locals("actor").(*model.Actor)
+	pekerjaan, err := s.GetMyPekerjaan(actor)
+	if err != nil {
+		if err.Error() == "profil alumni tidak ditemukan untuk user ini" {
+			return helper.ErrorResponse(c, fiber.StatusNotFound, "Akun Anda belum terhubung ke data alumni")
+		}
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil data pekerjaan")
+	}
+
+	return helper.SuccessResponse(c, "Pekerjaan data retrieved successfully", pekerjaan)
+}
+
 func (s *pekerjaanService) HandleCreatePekerjaan(c *fiber.Ctx) error {
 	var req model.CreatePekerjaanRequest
 	if err := c.BodyParser(&req); err != nil {
 		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	pekerjaan, err := s.CreatePekerjaan(&req)
+	actor := c.Locals("actor").(*model.Actor)
+	pekerjaan, err := s.CreatePekerjaan(&req, actor)
 	if err != nil {
-		if err.Error() == "alumni not found" {
-			return helper.ErrorResponse(c, fiber.StatusNotFound, "Alumni not found")
-		}
-		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return pekerjaanWriteErrorResponse(c, err)
 	}
+	s.audit.Record(c, model.AuditActionCreate, model.AuditEntityPekerjaan, pekerjaan.ID.Hex(), nil, pekerjaan)
 
+	if pekerjaan.ApprovalStatus == model.ApprovalPending {
+		return helper.CreatedResponse(c, "Pekerjaan submitted and waiting for admin approval", pekerjaan)
+	}
 	return helper.CreatedResponse(c, "Pekerjaan created successfully", pekerjaan)
 }
 
@@ -331,58 +959,141 @@ func (s *pekerjaanService) HandleUpdatePekerjaan(c *fiber.Ctx) error {
 		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	pekerjaan, err := s.UpdatePekerjaan(id, &req)
+	version, err := helper.IfMatchVersion(c, s.cfg.RequireIfMatch)
 	if err != nil {
-		if err.Error() == "pekerjaan not found" {
-			return helper.ErrorResponse(c, fiber.StatusNotFound, "Pekerjaan not found")
-		}
-		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return helper.PreconditionErrorResponse(c, err)
+	}
+
+	actor := c.Locals("actor").(*model.Actor)
+	before, _ := s.pekerjaanRepo.GetByID(id)
+	pekerjaan, change, err := s.UpdatePekerjaan(id, &req, actor, version)
+	if err != nil {
+		return pekerjaanWriteErrorResponse(c, err)
+	}
+
+	if change != nil {
+		s.audit.Record(c, model.AuditActionSubmit, model.AuditEntityPendingChange, change.ID.Hex(), nil, change)
+		return c.Status(fiber.StatusAccepted).JSON(helper.Response{
+			Success: true,
+			Message: "Perubahan pekerjaan menunggu persetujuan admin",
+			Data:    change,
+		})
+	}
+	s.audit.Record(c, model.AuditActionUpdate, model.AuditEntityPekerjaan, id, before, pekerjaan)
+	helper.SetETag(c, pekerjaan.Version)
+	if pekerjaan.ApprovalStatus == model.ApprovalPending {
+		return helper.SuccessResponse(c, "Pekerjaan updated and waiting for admin approval", pekerjaan)
+	}
+	return helper.SuccessResponse(c, "Pekerjaan updated successfully", pekerjaan)
+}
+
+func (s *pekerjaanService) HandlePatchPekerjaan(c *fiber.Ctx) error {
+	id := c.Params("id")
+	version, err := helper.IfMatchVersion(c, s.cfg.RequireIfMatch)
+	if err != nil {
+		return helper.PreconditionErrorResponse(c, err)
 	}
 
+	actor := c.Locals("actor").(*model.Actor)
+	before, _ := s.pekerjaanRepo.GetByID(id)
+	pekerjaan, change, err := s.PatchPekerjaan(id, c.Body(), c.Get(fiber.HeaderContentType), actor, version)
+	if err != nil {
+		return pekerjaanWriteErrorResponse(c, err)
+	}
+
+	if change != nil {
+		s.audit.Record(c, model.AuditActionSubmit, model.AuditEntityPendingChange, change.ID.Hex(), nil, change)
+		return c.Status(fiber.StatusAccepted).JSON(helper.Response{
+			Success: true,
+			Message: "Perubahan pekerjaan menunggu persetujuan admin",
+			Data:    change,
+		})
+	}
+	if before == nil || pekerjaan.Version != before.Version {
+		s.audit.Record(c, model.AuditActionUpdate, model.AuditEntityPekerjaan, id, before, pekerjaan)
+	}
+	helper.SetETag(c, pekerjaan.Version)
+	if pekerjaan.ApprovalStatus == model.ApprovalPending {
+		return helper.SuccessResponse(c, "Pekerjaan updated and waiting for admin approval", pekerjaan)
+	}
 	return helper.SuccessResponse(c, "Pekerjaan updated successfully", pekerjaan)
 }
 
 func (s *pekerjaanService) HandleDeletePekerjaan(c *fiber.Ctx) error {
+	actor := c.Locals("actor").(*model.Actor)
 	id := c.Params("id")
-	err := s.DeletePekerjaan(id)
+	version, err := helper.IfMatchVersion(c, s.cfg.RequireIfMatch)
+	if err != nil {
+		return helper.PreconditionErrorResponse(c, err)
+	}
+
+	before, _ := s.pekerjaanRepo.GetByIDWithDeleted(id)
+	err = s.DeletePekerjaan(id, actor.Scope, version)
 	if err != nil {
 		if err.Error() == "pekerjaan not found" {
 			return helper.ErrorResponse(c, fiber.StatusNotFound, "Pekerjaan not found")
 		}
+		if helper.IsPreconditionError(err) {
+			return helper.PreconditionErrorResponse(c, err)
+		}
+		if err.Error() == "pekerjaan dalam legal hold" {
+			return helper.ErrorResponse(c, fiber.StatusConflict, "Pekerjaan dalam legal hold dan tidak bisa dihapus permanen")
+		}
 		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal menghapus pekerjaan")
 	}
+	s.audit.Record(c, model.AuditActionDelete, model.AuditEntityPekerjaan, id, before, nil)
 
 	return helper.SuccessResponse(c, "Pekerjaan deleted successfully", nil)
 }
 
 func (s *pekerjaanService) HandleSoftDeletePekerjaan(c *fiber.Ctx) error {
-	userID := c.Locals("user_id").(string)
-	role := c.Locals("role").(string)
+	actor := c.Locals("actor").(*model.Actor)
 	id := c.Params("id")
 
-	err := s.SoftDeletePekerjaan(id, userID, role)
+	version, err := helper.IfMatchVersion(c, s.cfg.RequireIfMatch)
+	if err != nil {
+		return helper.PreconditionErrorResponse(c, err)
+	}
+
+	// Body opsional: {"reason": "..."}
+	var req model.SoftDeletePekerjaanRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+
+	before, _ := s.pekerjaanRepo.GetByIDWithDeleted(id)
+	err = s.SoftDeletePekerjaan(id, actor, version, req.Reason)
 	if err != nil {
 		if err.Error() == "pekerjaan not found" {
 			return helper.ErrorResponse(c, fiber.StatusNotFound, "Pekerjaan not found")
 		}
+		if strings.HasPrefix(err.Error(), "alasan penghapusan") {
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		if helper.IsPreconditionError(err) {
+			return helper.PreconditionErrorResponse(c, err)
+		}
 		if strings.Contains(err.Error(), "access denied") {
 			return helper.ErrorResponse(c, fiber.StatusForbidden, err.Error())
 		}
 		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal menghapus pekerjaan: "+err.Error())
 	}
+	after, _ := s.pekerjaanRepo.GetByIDWithDeleted(id)
+	s.audit.Record(c, model.AuditActionSoftDelete, model.AuditEntityPekerjaan, id, before, after)
 
 	return helper.SuccessResponse(c, "Pekerjaan soft deleted successfully", nil)
 }
 
 func (s *pekerjaanService) HandleListTrash(c *fiber.Ctx) error {
-	role := c.Locals("role").(string)
-	userID := c.Locals("user_id").(string)
+	actor := c.Locals("actor").(*model.Actor)
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "10"))
 	search := c.Query("search", "")
 
-	list, err := s.ListTrash(search, page, limit, userID, role)
+	response, err := s.ListTrash(search, page, limit, actor)
 	if err != nil {
 		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil data trash")
 	}
@@ -390,16 +1101,25 @@ func (s *pekerjaanService) HandleListTrash(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "Trash data retrieved successfully",
-		"data":    list,
+		"data":    response.Data,
+		"meta":    response.Meta,
 	})
 }
 
 func (s *pekerjaanService) HandleRestorePekerjaan(c *fiber.Ctx) error {
-	role := c.Locals("role").(string)
-	userID := c.Locals("user_id").(string)
+	actor := c.Locals("actor").(*model.Actor)
 	id := c.Params("id")
 
-	if err := s.RestorePekerjaan(id, userID, role); err != nil {
+	version, err := helper.IfMatchVersion(c, s.cfg.RequireIfMatch)
+	if err != nil {
+		return helper.PreconditionErrorResponse(c, err)
+	}
+
+	before, _ := s.pekerjaanRepo.GetByIDWithDeleted(id)
+	if err := s.RestorePekerjaan(id, actor, version); err != nil {
+		if helper.IsPreconditionError(err) {
+			return helper.PreconditionErrorResponse(c, err)
+		}
 		switch err.Error() {
 		case "pekerjaan not found":
 			return helper.ErrorResponse(c, fiber.StatusNotFound, "Pekerjaan not found")
@@ -407,20 +1127,32 @@ func (s *pekerjaanService) HandleRestorePekerjaan(c *fiber.Ctx) error {
 			return helper.ErrorResponse(c, fiber.StatusNotFound, "Pekerjaan not found in trash")
 		case "access denied: you can only restore your own pekerjaan":
 			return helper.ErrorResponse(c, fiber.StatusForbidden, "Access denied: you can only restore your own pekerjaan")
+		case "alumni pemilik pekerjaan masih di trash":
+			return helper.ErrorResponse(c, fiber.StatusConflict, "Alumni pemilik pekerjaan masih di trash, restore alumni terlebih dahulu")
 		default:
 			return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal restore pekerjaan: "+err.Error())
 		}
 	}
+	after, _ := s.pekerjaanRepo.GetByIDWithDeleted(id)
+	s.audit.Record(c, model.AuditActionRestore, model.AuditEntityPekerjaan, id, before, after)
 
 	return helper.SuccessResponse(c, "Pekerjaan restored successfully", nil)
 }
 
 func (s *pekerjaanService) HandleHardDeletePekerjaan(c *fiber.Ctx) error {
-	role := c.Locals("role").(string)
-	userID := c.Locals("user_id").(string)
+	actor := c.Locals("actor").(*model.Actor)
 	id := c.Params("id")
 
-	if err := s.HardDeletePekerjaan(id, userID, role); err != nil {
+	version, err := helper.IfMatchVersion(c, s.cfg.RequireIfMatch)
+	if err != nil {
+		return helper.PreconditionErrorResponse(c, err)
+	}
+
+	before, _ := s.pekerjaanRepo.GetByIDWithDeleted(id)
+	if err := s.HardDeletePekerjaan(id, actor, version); err != nil {
+		if helper.IsPreconditionError(err) {
+			return helper.PreconditionErrorResponse(c, err)
+		}
 		switch err.Error() {
 		case "pekerjaan not found":
 			return helper.ErrorResponse(c, fiber.StatusNotFound, "Pekerjaan not found")
@@ -428,10 +1160,76 @@ func (s *pekerjaanService) HandleHardDeletePekerjaan(c *fiber.Ctx) error {
 			return helper.ErrorResponse(c, fiber.StatusNotFound, "Pekerjaan not found in trash")
 		case "access denied: you can only delete your own pekerjaan":
 			return helper.ErrorResponse(c, fiber.StatusForbidden, "Access denied: you can only delete your own pekerjaan")
+		case "pekerjaan dalam legal hold":
+			return helper.ErrorResponse(c, fiber.StatusConflict, "Pekerjaan dalam legal hold dan tidak bisa dihapus permanen")
+		case "pekerjaan terhapus bersama alumni":
+			return helper.ErrorResponse(c, fiber.StatusConflict, "Pekerjaan ikut terhapus bersama alumninya, pulihkan atau hapus permanen lewat alumni")
 		default:
 			return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal hard delete pekerjaan: "+err.Error())
 		}
 	}
+	s.audit.Record(c, model.AuditActionHardDelete, model.AuditEntityPekerjaan, id, before, nil)
 
 	return helper.SuccessResponse(c, "Pekerjaan permanently deleted", nil)
 }
+
+func (s *pekerjaanService) HandleSetLegalHold(c *fiber.Ctx) error {
+	actor := c.Locals("actor").(*model.Actor)
+	id := c.Params("id")
+
+	var req model.SetLegalHoldRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	before, _ := s.pekerjaanRepo.GetByIDWithDeleted(id)
+	pekerjaan, err := s.SetLegalHold(id, &req, actor)
+	if err != nil {
+		switch err.Error() {
+		case "pekerjaan not found", "pekerjaan tidak ditemukan":
+			return helper.ErrorResponse(c, fiber.StatusNotFound, "Pekerjaan not found")
+		case "alasan legal hold wajib diisi":
+			return helper.ErrorResponse(c, fiber.StatusBadRequest, "Alasan legal hold wajib diisi")
+		default:
+			return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengubah legal hold: "+err.Error())
+		}
+	}
+	if before == nil || pekerjaan.Version != before.Version {
+		action := model.AuditActionLegalHold
+		if !req.Hold {
+			action = model.AuditActionLegalRelease
+		}
+		s.audit.Record(c, action, model.AuditEntityPekerjaan, id, before, pekerjaan)
+	}
+
+	helper.SetETag(c, pekerjaan.Version)
+	return helper.SuccessResponse(c, "Legal hold updated successfully", pekerjaan)
+}
+
+func (s *pekerjaanService) HandleBulkRestore(c *fiber.Ctx) error {
+	var req model.BulkTrashRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	return s.runBulkTrash(c, &req, model.PermPekerjaanManageAny, model.AuditActionRestore,
+		"Bulk restore completed", s.RestorePekerjaan)
+}
+
+func (s *pekerjaanService) HandleBulkPurge(c *fiber.Ctx) error {
+	var req model.BulkTrashRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	return s.runBulkTrash(c, &req, model.PermPekerjaanHardDelete, model.AuditActionHardDelete,
+		"Bulk purge completed", s.HardDeletePekerjaan)
+}
+
+// HandleEmptyTrash menghapus permanen semua item di trash milik pemanggil
+// (untuk admin: trash yang terlihat di GET /pekerjaan/trash), maksimal
+// MaxBulkTrashItems per request; ulangi selama has_more bernilai true
+func (s *pekerjaanService) HandleEmptyTrash(c *fiber.Ctx) error {
+	search := ""
+	req := model.BulkTrashRequest{Search: &search}
+	return s.runBulkTrash(c, &req, model.PermPekerjaanHardDelete, model.AuditActionHardDelete,
+		"Trash emptied", s.HardDeletePekerjaan)
+}
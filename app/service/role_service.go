@@ -0,0 +1,257 @@
+package service
+
+import (
+	"alumni-crud-api/app/model"
+	"alumni-crud-api/app/repository"
+	"alumni-crud-api/helper"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// permissionCacheTTL membatasi berapa lama perubahan role di database
+// (misalnya dari instance lain) belum terlihat oleh instance ini
+const permissionCacheTTL = 30 * time.Second
+
+type RoleService interface {
+	GetAllRoles() ([]model.Role, error)
+	GetRole(name string) (*model.Role, error)
+	CreateRole(req *model.CreateRoleRequest) (*model.Role, error)
+	UpdateRole(name string, req *model.UpdateRoleRequest) (*model.Role, error)
+	DeleteRole(name string) error
+	PermissionsForRole(role string) (model.PermissionSet, error)
+	ResolveScope(scope *model.UserScope) *model.DataScope
+
+	HandleGetAllRoles(c *fiber.Ctx) error
+	HandleGetPermissions(c *fiber.Ctx) error
+	HandleGetRole(c *fiber.Ctx) error
+	HandleCreateRole(c *fiber.Ctx) error
+	HandleUpdateRole(c *fiber.Ctx) error
+	HandleDeleteRole(c *fiber.Ctx) error
+}
+
+type cachedPermissions struct {
+	set       model.PermissionSet
+	expiresAt time.Time
+}
+
+type roleService struct {
+	roleRepo  repository.RoleRepository
+	authRepo  repository.AuthRepository
+	audit     AuditService
+	faculties map[string][]string
+
+	mu    sync.RWMutex
+	cache map[string]cachedPermissions
+}
+
+func NewRoleService(roleRepo repository.RoleRepository, authRepo repository.AuthRepository, audit AuditService, faculties map[string][]string) RoleService {
+	return &roleService{
+		roleRepo:  roleRepo,
+		authRepo:  authRepo,
+		audit:     audit,
+		faculties: faculties,
+		cache:     map[string]cachedPermissions{},
+	}
+}
+
+func (s *roleService) GetAllRoles() ([]model.Role, error) {
+	return s.roleRepo.GetAll()
+}
+
+func (s *roleService) GetRole(name string) (*model.Role, error) {
+	return s.roleRepo.GetByName(name)
+}
+
+func (s *roleService) CreateRole(req *model.CreateRoleRequest) (*model.Role, error) {
+	req.Name = strings.ToLower(strings.TrimSpace(req.Name))
+	if err := helper.ValidateCreateRole(req.Name, req.Permissions); err != nil {
+		return nil, err
+	}
+	if req.Permissions == nil {
+		req.Permissions = []string{}
+	}
+
+	return s.roleRepo.Create(&model.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+}
+
+func (s *roleService) UpdateRole(name string, req *model.UpdateRoleRequest) (*model.Role, error) {
+	// Admin selalu memiliki semua permission (lihat RoleRepository.SeedDefaults)
+	if name == model.RoleAdmin {
+		return nil, errors.New("role admin tidak dapat diubah")
+	}
+	if err := helper.ValidatePermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+	if req.Permissions == nil {
+		req.Permissions = []string{}
+	}
+
+	role, err := s.roleRepo.Update(name, req)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(name)
+	return role, nil
+}
+
+func (s *roleService) DeleteRole(name string) error {
+	role, err := s.roleRepo.GetByName(name)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return errors.New("role bawaan tidak dapat dihapus")
+	}
+
+	count, err := s.authRepo.CountUsersByRole(name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("role masih dipakai oleh %d user", count)
+	}
+
+	if err := s.roleRepo.Delete(name); err != nil {
+		return err
+	}
+	s.invalidate(name)
+	return nil
+}
+
+// PermissionsForRole mengembalikan permission milik role (dengan cache singkat).
+// Role yang tidak ada di database tidak memiliki permission apa pun.
+func (s *roleService) PermissionsForRole(role string) (model.PermissionSet, error) {
+	s.mu.RLock()
+	cached, ok := s.cache[role]
+	s.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.set, nil
+	}
+
+	set := model.PermissionSet{}
+	r, err := s.roleRepo.GetByName(role)
+	if err != nil && err.Error() != "role tidak ditemukan" {
+		return nil, err
+	}
+	if r != nil {
+		set = model.NewPermissionSet(r.Permissions)
+	}
+
+	s.mu.Lock()
+	s.cache[role] = cachedPermissions{set: set, expiresAt: time.Now().Add(permissionCacheTTL)}
+	s.mu.Unlock()
+	return set, nil
+}
+
+// ResolveScope menerjemahkan scope user (dari token) menjadi daftar jurusan
+func (s *roleService) ResolveScope(scope *model.UserScope) *model.DataScope {
+	return scope.Resolve(s.faculties)
+}
+
+func (s *roleService) invalidate(role string) {
+	s.mu.Lock()
+	delete(s.cache, role)
+	s.mu.Unlock()
+}
+
+// --- Handlers ---
+
+func (s *roleService) HandleGetAllRoles(c *fiber.Ctx) error {
+	roles, err := s.GetAllRoles()
+	if err != nil {
+		log.Printf("[ERROR] Role service error: %v", err)
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil data role")
+	}
+	return helper.SuccessResponse(c, "Role data retrieved successfully", roles)
+}
+
+func (s *roleService) HandleGetPermissions(c *fiber.Ctx) error {
+	return helper.SuccessResponse(c, "Permission list retrieved successfully", model.AllPermissions)
+}
+
+func (s *roleService) HandleGetRole(c *fiber.Ctx) error {
+	role, err := s.GetRole(c.Params("name"))
+	if err != nil {
+		return s.roleErrorResponse(c, err)
+	}
+	return helper.SuccessResponse(c, "Role data retrieved successfully", role)
+}
+
+func (s *roleService) HandleCreateRole(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+
+	var req model.CreateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	log.Printf("Admin %s creating role %s", username, req.Name)
+
+	role, err := s.CreateRole(&req)
+	if err != nil {
+		return s.roleErrorResponse(c, err)
+	}
+	s.audit.Record(c, model.AuditActionCreate, model.AuditEntityRole, role.Name, nil, role)
+	return helper.CreatedResponse(c, "Role created successfully", role)
+}
+
+func (s *roleService) HandleUpdateRole(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+	name := c.Params("name")
+
+	var req model.UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	log.Printf("Admin %s updating role %s: %v", username, name, req.Permissions)
+
+	before, _ := s.roleRepo.GetByName(name)
+	role, err := s.UpdateRole(name, &req)
+	if err != nil {
+		return s.roleErrorResponse(c, err)
+	}
+	s.audit.Record(c, model.AuditActionUpdate, model.AuditEntityRole, name, before, role)
+	return helper.SuccessResponse(c, "Role updated successfully", role)
+}
+
+func (s *roleService) HandleDeleteRole(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+	name := c.Params("name")
+	log.Printf("Admin %s deleting role %s", username, name)
+
+	before, _ := s.roleRepo.GetByName(name)
+	if err := s.DeleteRole(name); err != nil {
+		return s.roleErrorResponse(c, err)
+	}
+	s.audit.Record(c, model.AuditActionDelete, model.AuditEntityRole, name, before, nil)
+	return helper.SuccessResponse(c, "Role deleted successfully", nil)
+}
+
+// roleErrorResponse memetakan error service role ke status HTTP
+func (s *roleService) roleErrorResponse(c *fiber.Ctx, err error) error {
+	msg := err.Error()
+	switch {
+	case msg == "role tidak ditemukan":
+		return helper.ErrorResponse(c, fiber.StatusNotFound, "Role tidak ditemukan")
+	case msg == "role sudah ada":
+		return helper.ErrorResponse(c, fiber.StatusConflict, "Role sudah ada")
+	case msg == "role admin tidak dapat diubah", msg == "role bawaan tidak dapat dihapus":
+		return helper.ErrorResponse(c, fiber.StatusForbidden, msg)
+	case strings.HasPrefix(msg, "role masih dipakai"):
+		return helper.ErrorResponse(c, fiber.StatusConflict, msg)
+	case strings.Contains(msg, "Role name") || strings.Contains(msg, "permission"):
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, msg)
+	default:
+		log.Printf("[ERROR] Role service error: %v", err)
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal memproses role")
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"alumni-crud-api/app/model"
+	"alumni-crud-api/app/repository"
+	"alumni-crud-api/config"
+	"alumni-crud-api/helper"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// trashPurgeJob adalah nama job auto-purge di audit log
+const trashPurgeJob = "trash-purge"
+
+// trashPurgeBatch membatasi jumlah item yang dihapus per putaran agar satu
+// putaran tidak menahan koneksi database terlalu lama
+const trashPurgeBatch = 500
+
+// TrashPurgeService menghapus permanen pekerjaan yang sudah di trash lebih lama
+// dari masa simpan (TRASH_RETENTION). Pekerjaan dengan legal hold dan pekerjaan
+// yang ikut terhapus bersama alumninya tidak ikut dihapus.
+type TrashPurgeService interface {
+	Start()
+	PurgeExpired() (int, error)
+	Preview(scope *model.DataScope, limit int) (*model.TrashPurgePreview, error)
+
+	HandlePreviewPurge(c *fiber.Ctx) error
+}
+
+type trashPurgeService struct {
+	pekerjaanRepo repository.PekerjaanRepository
+	audit         AuditService
+	cfg           *config.Config
+
+	mu      sync.Mutex
+	nextRun *time.Time
+}
+
+func NewTrashPurgeService(pekerjaanRepo repository.PekerjaanRepository, audit AuditService, cfg *config.Config) TrashPurgeService {
+	return &trashPurgeService{
+		pekerjaanRepo: pekerjaanRepo,
+		audit:         audit,
+		cfg:           cfg,
+	}
+}
+
+func (s *trashPurgeService) enabled() bool {
+	return s.cfg.TrashRetention > 0 && s.cfg.TrashPurgeInterval > 0
+}
+
+func (s *trashPurgeService) cutoff() time.Time {
+	return time.Now().Add(-s.cfg.TrashRetention)
+}
+
+// Start menjalankan auto-purge di goroutine terpisah: sekali saat start, lalu
+// setiap TRASH_PURGE_INTERVAL
+func (s *trashPurgeService) Start() {
+	if !s.enabled() {
+		log.Println("Auto-purge trash dinonaktifkan (TRASH_RETENTION=0)")
+		return
+	}
+	log.Printf("Auto-purge trash aktif: retensi %s, dicek setiap %s", s.cfg.TrashRetention, s.cfg.TrashPurgeInterval)
+
+	go func() {
+		ticker := time.NewTicker(s.cfg.TrashPurgeInterval)
+		defer ticker.Stop()
+		for {
+			s.run()
+			s.setNextRun(time.Now().Add(s.cfg.TrashPurgeInterval))
+			<-ticker.C
+		}
+	}()
+}
+
+func (s *trashPurgeService) run() {
+	purged, err := s.PurgeExpired()
+	if err != nil {
+		log.Printf("[ERROR] Auto-purge trash: %v (%d item terhapus sebelumnya)", err, purged)
+		return
+	}
+	if purged > 0 {
+		log.Printf("Auto-purge trash: %d pekerjaan dihapus permanen", purged)
+	}
+}
+
+func (s *trashPurgeService) setNextRun(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextRun = &t
+}
+
+// PurgeExpired menghapus semua pekerjaan yang masa simpannya di trash sudah
+// habis. Setiap item dicek ulang saat dihapus, jadi item yang direstore atau
+// diberi legal hold di tengah putaran tetap aman.
+func (s *trashPurgeService) PurgeExpired() (int, error) {
+	cutoff := s.cutoff()
+	purged := 0
+	for {
+		batch, err := s.pekerjaanRepo.ListPurgeable(cutoff, trashPurgeBatch, nil)
+		if err != nil {
+			return purged, err
+		}
+
+		deletedInBatch := 0
+		for i := range batch {
+			p := &batch[i]
+			deleted, err := s.pekerjaanRepo.Purge(p.ID, cutoff)
+			if err != nil {
+				return purged, err
+			}
+			if deleted {
+				deletedInBatch++
+				s.audit.RecordSystem(trashPurgeJob, model.AuditActionPurge, model.AuditEntityPekerjaan, p.ID.Hex(), p, nil)
+			}
+		}
+		purged += deletedInBatch
+
+		// Berhenti jika batch terakhir tidak penuh atau tidak ada yang bisa
+		// dihapus lagi (mencegah loop tanpa akhir)
+		if len(batch) < trashPurgeBatch || deletedInBatch == 0 {
+			return purged, nil
+		}
+	}
+}
+
+// Preview menampilkan item yang akan dihapus pada putaran berikutnya dengan
+// memakai cutoff saat putaran itu berjalan
+func (s *trashPurgeService) Preview(scope *model.DataScope, limit int) (*model.TrashPurgePreview, error) {
+	preview := &model.TrashPurgePreview{
+		Enabled:   s.enabled(),
+		Retention: s.cfg.TrashRetention.String(),
+		Data:      []model.PekerjaanAlumni{},
+	}
+
+	s.mu.Lock()
+	if s.nextRun != nil {
+		next := *s.nextRun
+		preview.NextRunAt = &next
+	}
+	s.mu.Unlock()
+
+	preview.Cutoff = time.Now().Add(-s.cfg.TrashRetention)
+	if preview.NextRunAt != nil {
+		preview.Cutoff = preview.NextRunAt.Add(-s.cfg.TrashRetention)
+	}
+	if !preview.Enabled {
+		return preview, nil
+	}
+
+	items, err := s.pekerjaanRepo.ListPurgeable(preview.Cutoff, limit, scope)
+	if err != nil {
+		return nil, err
+	}
+	if items != nil {
+		preview.Data = items
+	}
+	if preview.Total, err = s.pekerjaanRepo.CountPurgeable(preview.Cutoff, scope); err != nil {
+		return nil, err
+	}
+	if preview.OnHold, err = s.pekerjaanRepo.CountHeldExpired(preview.Cutoff, scope); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+func (s *trashPurgeService) HandlePreviewPurge(c *fiber.Ctx) error {
+	actor := c.Locals("actor").(*model.Actor)
+
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if limit < 1 || limit > trashPurgeBatch {
+		limit = 50
+	}
+
+	preview, err := s.Preview(actor.Scope, limit)
+	if err != nil {
+		log.Printf("[ERROR] Preview auto-purge trash: %v", err)
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil preview auto-purge")
+	}
+	return helper.SuccessResponse(c, "Trash purge preview retrieved successfully", preview)
+}
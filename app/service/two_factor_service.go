@@ -0,0 +1,414 @@
+package service
+
+import (
+	"alumni-crud-api/app/model"
+	"alumni-crud-api/helper"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const recoveryCodeCount = 10
+
+// mfaRequired bernilai true jika user wajib 2FA tetapi belum mengaktifkannya
+func (s *AuthService) mfaRequired(user *model.User) bool {
+	return s.cfg.MFARequiredForAdmin && user.Role == model.RoleAdmin && !user.TOTPEnabled
+}
+
+func (s *AuthService) mfaChallenge(user *model.User, purpose string) (*model.MFAChallenge, error) {
+	token, claims, err := helper.GenerateMFAToken(*user, purpose, s.cfg.MFAChallengeTTL)
+	if err != nil {
+		log.Printf("[ERROR] AuthService GenerateMFAToken: %v", err)
+		return nil, errors.New("gagal generate token")
+	}
+	return &model.MFAChallenge{
+		MFARequired:      purpose == model.TokenPurposeMFA,
+		MFASetupRequired: purpose == model.TokenPurposeMFASetup,
+		MFAToken:         token,
+		ExpiresAt:        claims.ExpiresAt.Time,
+	}, nil
+}
+
+// ValidateMFAToken memvalidasi token challenge 2FA dengan purpose tertentu.
+// Dipakai oleh LoginMFA dan middleware.AuthOrMFASetup.
+func (s *AuthService) ValidateMFAToken(tokenString string, purpose string) (*model.JWTClaims, error) {
+	claims, err := helper.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ID == "" || claims.Purpose != purpose {
+		return nil, errors.New("token challenge tidak valid")
+	}
+
+	revoked, err := s.tokenRepo.IsAccessTokenRevoked(claims.ID)
+	if err != nil {
+		log.Printf("[ERROR] AuthService IsAccessTokenRevoked: %v", err)
+		return nil, errors.New("gagal memeriksa status token")
+	}
+	if revoked {
+		return nil, errors.New("token sudah dicabut")
+	}
+	return claims, nil
+}
+
+// revokeChallenge membuat token challenge sekali pakai
+func (s *AuthService) revokeChallenge(user *model.User, jti string, expiresAt time.Time) {
+	if err := s.tokenRepo.RevokeAccessToken(jti, user.ID, expiresAt, "mfa_completed"); err != nil {
+		log.Printf("[ERROR] AuthService RevokeAccessToken: %v", err)
+	}
+}
+
+// checkTOTP memvalidasi kode terhadap secret dan mencatat step-nya agar
+// kode yang sama tidak bisa dipakai dua kali
+func (s *AuthService) checkTOTP(userID, secret, code string) (bool, error) {
+	ok, step := helper.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	used, err := s.authRepo.UseTOTPStep(userID, step)
+	if err != nil {
+		log.Printf("[ERROR] AuthService UseTOTPStep: %v", err)
+		return false, errors.New("error database")
+	}
+	return used, nil
+}
+
+// LoginMFA menyelesaikan login dua langkah memakai kode TOTP atau kode pemulihan.
+// Kegagalan dihitung ke penghitung lockout yang sama dengan login password.
+func (s *AuthService) LoginMFA(req model.MFALoginRequest, ip, userAgent string) (*model.LoginResponse, error) {
+	claims, err := s.ValidateMFAToken(req.MFAToken, model.TokenPurposeMFA)
+	if err != nil {
+		return nil, errors.New("token challenge tidak valid atau sudah kedaluwarsa")
+	}
+
+	user, err := s.authRepo.GetUserByID(claims.UserID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("token challenge tidak valid atau sudah kedaluwarsa")
+		}
+		log.Printf("[ERROR] AuthService LoginMFA: %v", err)
+		return nil, errors.New("error database")
+	}
+
+	attempt := &model.LoginAttempt{
+		UserID:     &user.ID,
+		Identifier: strings.ToLower(user.Username),
+		IP:         ip,
+		UserAgent:  userAgent,
+	}
+	userKey := "user:" + user.ID.Hex()
+	ipKey := "ip:" + ip
+
+	if until := s.lockedUntil(userKey, ipKey); until != nil {
+		s.recordAttempt(attempt, model.LoginResultLocked)
+		return nil, &LoginLockedError{Until: *until}
+	}
+	if user.IsDisabled {
+		s.recordAttempt(attempt, model.LoginResultDisabled)
+		return nil, errors.New("akun dinonaktifkan")
+	}
+	if !user.TOTPEnabled {
+		return nil, errors.New("token challenge tidak valid atau sudah kedaluwarsa")
+	}
+
+	var valid bool
+	switch {
+	case req.Code != "":
+		valid, err = s.checkTOTP(claims.UserID, user.TOTPSecret, req.Code)
+	case req.RecoveryCode != "":
+		codeHash := helper.HashToken(helper.NormalizeRecoveryCode(req.RecoveryCode))
+		valid, err = s.authRepo.ConsumeRecoveryCode(claims.UserID, codeHash)
+		if valid {
+			log.Printf("[WARN] User %s login memakai kode pemulihan", user.ID.Hex())
+		}
+	default:
+		return nil, errors.New("kode 2FA atau kode pemulihan harus diisi")
+	}
+	if err != nil {
+		log.Printf("[ERROR] AuthService LoginMFA: %v", err)
+		return nil, errors.New("error database")
+	}
+	if !valid {
+		s.registerLoginFailure(userKey, ipKey)
+		s.recordAttempt(attempt, model.LoginResultInvalidMFA)
+		return nil, errors.New("kode 2FA tidak valid")
+	}
+
+	s.revokeChallenge(user, claims.ID, claims.ExpiresAt.Time)
+	return s.completeLogin(user, userKey, attempt)
+}
+
+// SetupTOTP membuat secret baru yang belum aktif sampai diverifikasi lewat VerifyTOTP
+func (s *AuthService) SetupTOTP(userID string) (*model.TOTPSetupResponse, error) {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("2FA sudah aktif")
+	}
+
+	secret, err := helper.GenerateTOTPSecret()
+	if err != nil {
+		log.Printf("[ERROR] AuthService GenerateTOTPSecret: %v", err)
+		return nil, errors.New("gagal membuat secret 2FA")
+	}
+	if err := s.authRepo.SetPendingTOTPSecret(userID, secret); err != nil {
+		log.Printf("[ERROR] AuthService SetPendingTOTPSecret: %v", err)
+		return nil, errors.New("error database")
+	}
+
+	return &model.TOTPSetupResponse{
+		Secret:     secret,
+		OTPAuthURL: helper.TOTPURI(s.cfg.TOTPIssuer, user.Username, secret),
+	}, nil
+}
+
+// VerifyTOTP mengaktifkan 2FA setelah kode pertama dari authenticator cocok.
+// Kode pemulihan hanya ditampilkan sekali di sini; yang disimpan hanya hash-nya.
+func (s *AuthService) VerifyTOTP(userID, code string) ([]string, error) {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("2FA sudah aktif")
+	}
+	if user.TOTPPendingSecret == "" {
+		return nil, errors.New("setup 2FA belum dimulai")
+	}
+
+	ok, step := helper.ValidateTOTP(user.TOTPPendingSecret, code, time.Now())
+	if !ok {
+		return nil, errors.New("kode 2FA tidak valid")
+	}
+
+	codes, err := helper.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		log.Printf("[ERROR] AuthService GenerateRecoveryCodes: %v", err)
+		return nil, errors.New("gagal membuat kode pemulihan")
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = helper.HashToken(c)
+	}
+
+	if err := s.authRepo.EnableTOTP(userID, user.TOTPPendingSecret, hashes, step); err != nil {
+		log.Printf("[ERROR] AuthService EnableTOTP: %v", err)
+		return nil, errors.New("error database")
+	}
+	return codes, nil
+}
+
+// DisableTOTP mematikan 2FA setelah memverifikasi password dan kode TOTP saat ini
+func (s *AuthService) DisableTOTP(userID string, req model.TOTPDisableRequest) error {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return errors.New("2FA belum aktif")
+	}
+	if s.cfg.MFARequiredForAdmin && user.Role == model.RoleAdmin {
+		return errors.New("2FA wajib untuk admin")
+	}
+
+	passwordHash, err := s.authRepo.GetPasswordHash(userID)
+	if err != nil {
+		log.Printf("[ERROR] AuthService GetPasswordHash: %v", err)
+		return errors.New("error database")
+	}
+	if !helper.CheckPassword(req.Password, passwordHash) {
+		return errors.New("password atau kode 2FA salah")
+	}
+	valid, err := s.checkTOTP(userID, user.TOTPSecret, req.Code)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errors.New("password atau kode 2FA salah")
+	}
+
+	if err := s.authRepo.DisableTOTP(userID); err != nil {
+		log.Printf("[ERROR] AuthService DisableTOTP: %v", err)
+		return errors.New("error database")
+	}
+	return nil
+}
+
+// HandleLoginMFA godoc
+// @Summary Login Langkah Kedua (2FA)
+// @Description Menukar mfa_token dari /auth/login dan kode TOTP (atau kode pemulihan) dengan token JWT.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body model.MFALoginRequest true "Token challenge dan kode 2FA"
+// @Success 200 {object} helper.Response{data=model.LoginResponse} "Login Berhasil"
+// @Failure 400 {object} helper.Response "Request body tidak valid"
+// @Failure 401 {object} helper.Response "Token challenge atau kode 2FA tidak valid"
+// @Failure 429 {object} helper.Response "Terlalu banyak percobaan gagal (lihat header Retry-After)"
+// @Router /auth/2fa/login [post]
+
+func (s *AuthService) HandleLoginMFA(c *fiber.Ctx) error {
+	var req model.MFALoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, 400, "Request body tidak valid")
+	}
+	if req.MFAToken == "" {
+		return helper.ErrorResponse(c, 400, "mfa_token harus diisi")
+	}
+
+	response, err := s.LoginMFA(req, c.IP(), c.Get("User-Agent"))
+	if err != nil {
+		var lockedErr *LoginLockedError
+		if errors.As(err, &lockedErr) {
+			retryAfter := int(time.Until(lockedErr.Until).Seconds()) + 1
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			return helper.ErrorResponse(c, 429, err.Error())
+		}
+		switch err.Error() {
+		case "error database", "gagal generate token":
+			return helper.ErrorResponse(c, 500, "Gagal memproses login")
+		case "akun dinonaktifkan":
+			return helper.ErrorResponse(c, 403, "Akun Anda dinonaktifkan")
+		case "kode 2FA atau kode pemulihan harus diisi":
+			return helper.ErrorResponse(c, 400, "Kode 2FA atau kode pemulihan harus diisi")
+		default:
+			return helper.ErrorResponse(c, 401, err.Error())
+		}
+	}
+
+	return helper.SuccessResponse(c, "Login berhasil", response)
+}
+
+// HandleSetupTOTP godoc
+// @Summary Mulai Pendaftaran 2FA
+// @Description Membuat secret TOTP baru. Tampilkan otpauth_url sebagai QR code lalu konfirmasi lewat /auth/2fa/verify.
+// @Description Admin yang wajib 2FA dapat memakai mfa_token dari login sebagai Bearer token.
+// @Tags Auth
+// @Produce json
+// @Security ApiKeyAuth[]
+// @Success 200 {object} helper.Response{data=model.TOTPSetupResponse} "Secret 2FA dibuat"
+// @Failure 401 {object} helper.Response "Token tidak valid"
+// @Failure 409 {object} helper.Response "2FA sudah aktif"
+// @Router /auth/2fa/setup [post]
+
+func (s *AuthService) HandleSetupTOTP(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	response, err := s.SetupTOTP(userID)
+	if err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	return helper.SuccessResponse(c, "Scan QR code lalu verifikasi kode pertama untuk mengaktifkan 2FA", response)
+}
+
+// HandleVerifyTOTP godoc
+// @Summary Aktifkan 2FA
+// @Description Memverifikasi kode pertama dari authenticator dan mengaktifkan 2FA. Kode pemulihan hanya ditampilkan sekali.
+// @Description Jika dipanggil dengan mfa_token (admin wajib 2FA), response juga berisi token login.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth[]
+// @Param body body model.TOTPVerifyRequest true "Kode TOTP"
+// @Success 200 {object} helper.Response{data=model.TOTPVerifyResponse} "2FA aktif"
+// @Failure 400 {object} helper.Response "Kode 2FA tidak valid"
+// @Failure 401 {object} helper.Response "Token tidak valid"
+// @Failure 409 {object} helper.Response "2FA sudah aktif"
+// @Router /auth/2fa/verify [post]
+
+func (s *AuthService) HandleVerifyTOTP(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req model.TOTPVerifyRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return helper.ErrorResponse(c, 400, "Kode 2FA harus diisi")
+	}
+
+	codes, err := s.VerifyTOTP(userID, req.Code)
+	if err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+	s.audit.Record(c, model.AuditActionMFAEnable, model.AuditEntityUser, userID, nil, nil)
+	response := &model.TOTPVerifyResponse{RecoveryCodes: codes}
+
+	// Pendaftaran saat login wajib-2FA: token challenge ditukar dengan login penuh
+	if mfaSetup, _ := c.Locals("mfa_setup").(bool); mfaSetup {
+		user, err := s.GetProfile(userID)
+		if err != nil {
+			return helper.ErrorResponse(c, 500, "2FA aktif, tetapi gagal memproses login")
+		}
+		s.revokeChallenge(user, c.Locals("jti").(string), c.Locals("token_expires_at").(time.Time))
+
+		attempt := &model.LoginAttempt{
+			UserID:     &user.ID,
+			Identifier: strings.ToLower(user.Username),
+			IP:         c.IP(),
+			UserAgent:  c.Get("User-Agent"),
+		}
+		response.Login, err = s.completeLogin(user, "user:"+user.ID.Hex(), attempt)
+		if err != nil {
+			return helper.ErrorResponse(c, 500, "2FA aktif, tetapi gagal memproses login")
+		}
+	}
+
+	return helper.SuccessResponse(c, "2FA berhasil diaktifkan, simpan kode pemulihan di tempat aman", response)
+}
+
+// HandleDisableTOTP godoc
+// @Summary Nonaktifkan 2FA
+// @Description Mematikan 2FA. Membutuhkan password dan kode TOTP saat ini. Tidak tersedia untuk admin jika 2FA diwajibkan.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth[]
+// @Param body body model.TOTPDisableRequest true "Password dan kode TOTP"
+// @Success 200 {object} helper.Response "2FA dinonaktifkan"
+// @Failure 400 {object} helper.Response "2FA belum aktif"
+// @Failure 401 {object} helper.Response "Password atau kode 2FA salah"
+// @Failure 403 {object} helper.Response "2FA wajib untuk admin"
+// @Router /auth/2fa/disable [post]
+
+func (s *AuthService) HandleDisableTOTP(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req model.TOTPDisableRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, 400, "Request body tidak valid")
+	}
+	if req.Password == "" || req.Code == "" {
+		return helper.ErrorResponse(c, 400, "Password dan kode 2FA harus diisi")
+	}
+
+	if err := s.DisableTOTP(userID, req); err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+	s.audit.Record(c, model.AuditActionMFADisable, model.AuditEntityUser, userID, nil, nil)
+
+	return helper.SuccessResponse(c, "2FA berhasil dinonaktifkan", nil)
+}
+
+func twoFactorErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "user tidak ditemukan":
+		return helper.ErrorResponse(c, 404, "User tidak ditemukan")
+	case "2FA sudah aktif":
+		return helper.ErrorResponse(c, 409, err.Error())
+	case "2FA wajib untuk admin":
+		return helper.ErrorResponse(c, 403, err.Error())
+	case "password atau kode 2FA salah":
+		return helper.ErrorResponse(c, 401, err.Error())
+	case "2FA belum aktif", "setup 2FA belum dimulai", "kode 2FA tidak valid":
+		return helper.ErrorResponse(c, 400, err.Error())
+	default:
+		log.Printf("[ERROR] 2FA: %v", err)
+		return helper.ErrorResponse(c, 500, "Gagal memproses 2FA")
+	}
+}
@@ -0,0 +1,573 @@
+package service
+
+import (
+	"alumni-crud-api/app/model"
+	"alumni-crud-api/app/repository"
+	"alumni-crud-api/config"
+	"alumni-crud-api/helper"
+	"errors"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type UserService interface {
+	GetUsersWithPagination(search, sortBy, order string, page, limit int) (*model.UserResponse, error)
+	GetUserByID(id string) (*model.User, error)
+	CreateUser(req *model.CreateUserRequest) (*model.User, error)
+	UpdateUser(id string, req *model.UpdateUserRequest) (*model.User, error)
+	DisableUser(id string, requesterID string) error
+	EnableUser(id string) error
+	DeleteUser(id string, requesterID string) error
+	UnlockUser(id string) error
+	UnlockIP(ip string) error
+	GetLoginAttempts(filter model.LoginAttemptFilter, page, limit int) (*model.LoginAttemptResponse, error)
+
+	HandleGetAllUsers(c *fiber.Ctx) error
+	HandleGetUserByID(c *fiber.Ctx) error
+	HandleCreateUser(c *fiber.Ctx) error
+	HandleUpdateUser(c *fiber.Ctx) error
+	HandleDisableUser(c *fiber.Ctx) error
+	HandleEnableUser(c *fiber.Ctx) error
+	HandleDeleteUser(c *fiber.Ctx) error
+	HandleUnlockUser(c *fiber.Ctx) error
+	HandleUnlockIP(c *fiber.Ctx) error
+	HandleGetUserLoginAttempts(c *fiber.Ctx) error
+	HandleGetLoginAttempts(c *fiber.Ctx) error
+}
+
+type userService struct {
+	authRepo    repository.AuthRepository
+	tokenRepo   repository.TokenRepository
+	attemptRepo repository.LoginAttemptRepository
+	roleRepo    repository.RoleRepository
+	audit       AuditService
+	cfg         *config.Config
+}
+
+func NewUserService(authRepo repository.AuthRepository, tokenRepo repository.TokenRepository, attemptRepo repository.LoginAttemptRepository, roleRepo repository.RoleRepository, audit AuditService, cfg *config.Config) UserService {
+	return &userService{
+		authRepo:    authRepo,
+		tokenRepo:   tokenRepo,
+		attemptRepo: attemptRepo,
+		roleRepo:    roleRepo,
+		audit:       audit,
+		cfg:         cfg,
+	}
+}
+
+func (s *userService) GetUsersWithPagination(search, sortBy, order string, page, limit int) (*model.UserResponse, error) {
+	offset := (page - 1) * limit
+
+	users, err := s.authRepo.GetAllUsersWithPagination(search, sortBy, order, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.authRepo.CountUsersWithSearch(search)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := (total + limit - 1) / limit
+	if total == 0 {
+		pages = 0
+	}
+
+	response := &model.UserResponse{
+		Data: users,
+		Meta: model.MetaInfo{
+			Page:   page,
+			Limit:  limit,
+			Total:  total,
+			Pages:  pages,
+			SortBy: sortBy,
+			Order:  order,
+			Search: search,
+		},
+	}
+
+	return response, nil
+}
+
+func (s *userService) GetUserByID(id string) (*model.User, error) {
+	user, err := s.authRepo.GetUserByID(id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("user tidak ditemukan")
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *userService) CreateUser(req *model.CreateUserRequest) (*model.User, error) {
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+
+	if err := helper.ValidateCreateUser(req.Username, req.Email, req.Password, req.Role); err != nil {
+		return nil, err
+	}
+	if err := s.ensureRoleExists(req.Role); err != nil {
+		return nil, err
+	}
+	scope, err := s.normalizeScope(req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := helper.HashPassword(req.Password)
+	if err != nil {
+		log.Printf("[ERROR] UserService HashPassword: %v", err)
+		return nil, errors.New("gagal memproses password")
+	}
+
+	return s.authRepo.CreateUser(&model.User{
+		Username:     req.Username,
+		Email:        req.Email,
+		PasswordHash: hash,
+		Role:         req.Role,
+		Scope:        scope,
+	})
+}
+
+func (s *userService) UpdateUser(id string, req *model.UpdateUserRequest) (*model.User, error) {
+	existing, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+
+	if err := helper.ValidateUpdateUser(req.Username, req.Email, req.Role, req.Password); err != nil {
+		return nil, err
+	}
+	if err := s.ensureRoleExists(req.Role); err != nil {
+		return nil, err
+	}
+	if req.Scope, err = s.normalizeScope(req.Scope); err != nil {
+		return nil, err
+	}
+
+	passwordHash := ""
+	if req.Password != nil {
+		hash, err := helper.HashPassword(*req.Password)
+		if err != nil {
+			log.Printf("[ERROR] UserService HashPassword: %v", err)
+			return nil, errors.New("gagal memproses password")
+		}
+		passwordHash = hash
+	}
+
+	user, err := s.authRepo.UpdateUser(id, req, passwordHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// Role dan scope ada di dalam token; sesi lama dicabut agar perubahan langsung berlaku.
+	// Password yang direset admin juga mencabut semua sesi (akun mungkin dibobol).
+	switch {
+	case req.Password != nil:
+		if err := s.revokeSessions(user.ID, "password_reset_by_admin"); err != nil {
+			return nil, err
+		}
+	case existing.Role != user.Role || !reflect.DeepEqual(existing.Scope, user.Scope):
+		if err := s.revokeSessions(user.ID, "access_changed"); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// normalizeScope merapikan isi scope dan memastikan fakultas terdaftar di config FACULTIES.
+// Scope kosong dikembalikan sebagai nil (tanpa batasan).
+func (s *userService) normalizeScope(scope *model.UserScope) (*model.UserScope, error) {
+	if scope.IsEmpty() {
+		return nil, nil
+	}
+
+	normalized := &model.UserScope{}
+	for _, j := range scope.Jurusan {
+		if j = strings.TrimSpace(j); j != "" {
+			normalized.Jurusan = append(normalized.Jurusan, j)
+		}
+	}
+	for _, f := range scope.Fakultas {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" {
+			continue
+		}
+		if _, ok := s.cfg.Faculties[f]; !ok {
+			return nil, errors.New("fakultas '" + f + "' tidak terdaftar")
+		}
+		normalized.Fakultas = append(normalized.Fakultas, f)
+	}
+
+	if normalized.IsEmpty() {
+		return nil, nil
+	}
+	return normalized, nil
+}
+
+func (s *userService) ensureRoleExists(role string) error {
+	if _, err := s.roleRepo.GetByName(role); err != nil {
+		if err.Error() == "role tidak ditemukan" {
+			return errors.New("role '" + role + "' tidak ditemukan")
+		}
+		log.Printf("[ERROR] UserService GetRoleByName: %v", err)
+		return errors.New("gagal memeriksa role")
+	}
+	return nil
+}
+
+func (s *userService) DisableUser(id string, requesterID string) error {
+	if id == requesterID {
+		return errors.New("tidak dapat menonaktifkan akun sendiri")
+	}
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.authRepo.DisableUser(id); err != nil {
+		return err
+	}
+	return s.revokeSessions(user.ID, "user_disabled")
+}
+
+func (s *userService) EnableUser(id string) error {
+	if _, err := s.GetUserByID(id); err != nil {
+		return err
+	}
+	return s.authRepo.EnableUser(id)
+}
+
+func (s *userService) DeleteUser(id string, requesterID string) error {
+	if id == requesterID {
+		return errors.New("tidak dapat menghapus akun sendiri")
+	}
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.authRepo.DeleteUser(id); err != nil {
+		return err
+	}
+	return s.revokeSessions(user.ID, "user_deleted")
+}
+
+// UnlockUser menghapus kunci login sementara dan penghitung kegagalan akun
+func (s *userService) UnlockUser(id string) error {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.attemptRepo.ResetThrottle("user:" + user.ID.Hex()); err != nil {
+		log.Printf("[ERROR] UserService ResetThrottle: %v", err)
+		return errors.New("gagal membuka kunci akun")
+	}
+	return nil
+}
+
+func (s *userService) UnlockIP(ip string) error {
+	ip = strings.TrimSpace(ip)
+	if ip == "" {
+		return errors.New("IP harus diisi")
+	}
+	if err := s.attemptRepo.ResetThrottle("ip:" + ip); err != nil {
+		log.Printf("[ERROR] UserService ResetThrottle: %v", err)
+		return errors.New("gagal membuka kunci IP")
+	}
+	return nil
+}
+
+func (s *userService) GetLoginAttempts(filter model.LoginAttemptFilter, page, limit int) (*model.LoginAttemptResponse, error) {
+	offset := (page - 1) * limit
+
+	attempts, err := s.attemptRepo.ListAttempts(filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.attemptRepo.CountAttempts(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := (total + limit - 1) / limit
+	if total == 0 {
+		pages = 0
+	}
+
+	return &model.LoginAttemptResponse{
+		Data: attempts,
+		Meta: model.MetaInfo{
+			Page:   page,
+			Limit:  limit,
+			Total:  total,
+			Pages:  pages,
+			SortBy: "created_at",
+			Order:  "desc",
+		},
+	}, nil
+}
+
+// revokeSessions mencabut semua token aktif agar perubahan status akun langsung berlaku
+func (s *userService) revokeSessions(userID primitive.ObjectID, reason string) error {
+	if err := s.tokenRepo.RevokeAllForUser(userID, reason); err != nil {
+		log.Printf("[ERROR] UserService RevokeAllForUser: %v", err)
+		return errors.New("gagal mencabut sesi user")
+	}
+	return nil
+}
+
+// --- Handlers ---
+
+func (s *userService) HandleGetAllUsers(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	sortBy := c.Query("sortBy", "created_at")
+	order := c.Query("order", "desc")
+	search := c.Query("search", "")
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	response, err := s.GetUsersWithPagination(search, sortBy, order, page, limit)
+	if err != nil {
+		log.Printf("[ERROR] User pagination service error: %v", err)
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil data user")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "User data retrieved successfully",
+		"data":    response.Data,
+		"meta":    response.Meta,
+	})
+}
+
+func (s *userService) HandleGetUserByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		if err.Error() == "user tidak ditemukan" {
+			return helper.ErrorResponse(c, fiber.StatusNotFound, "User tidak ditemukan")
+		}
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return helper.SuccessResponse(c, "User data retrieved successfully", user)
+}
+
+func (s *userService) HandleCreateUser(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+	log.Printf("Admin %s creating new user", username)
+
+	var req model.CreateUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	user, err := s.CreateUser(&req)
+	if err != nil {
+		return s.userErrorResponse(c, err)
+	}
+	s.audit.Record(c, model.AuditActionCreate, model.AuditEntityUser, user.ID.Hex(), nil, user)
+
+	return helper.CreatedResponse(c, "User created successfully", user)
+}
+
+func (s *userService) HandleUpdateUser(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+	id := c.Params("id")
+	log.Printf("Admin %s updating user ID %s", username, id)
+
+	var req model.UpdateUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	before, _ := s.authRepo.GetUserByID(id)
+	user, err := s.UpdateUser(id, &req)
+	if err != nil {
+		return s.userErrorResponse(c, err)
+	}
+	s.audit.Record(c, model.AuditActionUpdate, model.AuditEntityUser, id, before, user)
+
+	return helper.SuccessResponse(c, "User updated successfully", user)
+}
+
+func (s *userService) HandleDisableUser(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+	requesterID := c.Locals("user_id").(string)
+	id := c.Params("id")
+	log.Printf("Admin %s disabling user ID %s", username, id)
+
+	before, _ := s.authRepo.GetUserByID(id)
+	if err := s.DisableUser(id, requesterID); err != nil {
+		return s.userErrorResponse(c, err)
+	}
+	after, _ := s.authRepo.GetUserByID(id)
+	s.audit.Record(c, model.AuditActionDisable, model.AuditEntityUser, id, before, after)
+
+	return helper.SuccessResponse(c, "User disabled successfully", nil)
+}
+
+func (s *userService) HandleEnableUser(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+	id := c.Params("id")
+	log.Printf("Admin %s enabling user ID %s", username, id)
+
+	before, _ := s.authRepo.GetUserByID(id)
+	if err := s.EnableUser(id); err != nil {
+		return s.userErrorResponse(c, err)
+	}
+	after, _ := s.authRepo.GetUserByID(id)
+	s.audit.Record(c, model.AuditActionEnable, model.AuditEntityUser, id, before, after)
+
+	return helper.SuccessResponse(c, "User enabled successfully", nil)
+}
+
+func (s *userService) HandleDeleteUser(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+	requesterID := c.Locals("user_id").(string)
+	id := c.Params("id")
+	log.Printf("Admin %s deleting user ID %s", username, id)
+
+	before, _ := s.authRepo.GetUserByID(id)
+	if err := s.DeleteUser(id, requesterID); err != nil {
+		return s.userErrorResponse(c, err)
+	}
+	s.audit.Record(c, model.AuditActionDelete, model.AuditEntityUser, id, before, nil)
+
+	return helper.SuccessResponse(c, "User deleted successfully", nil)
+}
+
+func (s *userService) HandleUnlockUser(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+	id := c.Params("id")
+	log.Printf("Admin %s unlocking login for user ID %s", username, id)
+
+	if err := s.UnlockUser(id); err != nil {
+		return s.userErrorResponse(c, err)
+	}
+	s.audit.Record(c, model.AuditActionUnlock, model.AuditEntityUser, id, nil, nil)
+
+	return helper.SuccessResponse(c, "User unlocked successfully", nil)
+}
+
+func (s *userService) HandleUnlockIP(c *fiber.Ctx) error {
+	username := c.Locals("username").(string)
+
+	var req model.UnlockIPRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	log.Printf("Admin %s unlocking login for IP %s", username, req.IP)
+
+	if err := s.UnlockIP(req.IP); err != nil {
+		return s.userErrorResponse(c, err)
+	}
+	s.audit.Record(c, model.AuditActionUnlock, model.AuditEntityIP, strings.TrimSpace(req.IP), nil, nil)
+
+	return helper.SuccessResponse(c, "IP unlocked successfully", nil)
+}
+
+func (s *userService) HandleGetUserLoginAttempts(c *fiber.Ctx) error {
+	user, err := s.GetUserByID(c.Params("id"))
+	if err != nil {
+		return s.userErrorResponse(c, err)
+	}
+
+	filter, err := parseLoginAttemptFilter(c)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	filter.UserID = &user.ID
+
+	return s.respondLoginAttempts(c, filter)
+}
+
+func (s *userService) HandleGetLoginAttempts(c *fiber.Ctx) error {
+	filter, err := parseLoginAttemptFilter(c)
+	if err != nil {
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	return s.respondLoginAttempts(c, filter)
+}
+
+func (s *userService) respondLoginAttempts(c *fiber.Ctx, filter model.LoginAttemptFilter) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	response, err := s.GetLoginAttempts(filter, page, limit)
+	if err != nil {
+		log.Printf("[ERROR] Login attempts service error: %v", err)
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, "Gagal mengambil data percobaan login")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Login attempts retrieved successfully",
+		"data":    response.Data,
+		"meta":    response.Meta,
+	})
+}
+
+// parseLoginAttemptFilter membaca query identifier, ip, result, from, to (RFC3339)
+func parseLoginAttemptFilter(c *fiber.Ctx) (model.LoginAttemptFilter, error) {
+	filter := model.LoginAttemptFilter{
+		Identifier: strings.ToLower(c.Query("identifier")),
+		IP:         c.Query("ip"),
+		Result:     c.Query("result"),
+	}
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return filter, errors.New("format 'from' harus RFC3339")
+		}
+		filter.From = &t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return filter, errors.New("format 'to' harus RFC3339")
+		}
+		filter.To = &t
+	}
+	return filter, nil
+}
+
+// userErrorResponse memetakan error service user ke status HTTP
+func (s *userService) userErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "user tidak ditemukan":
+		return helper.ErrorResponse(c, fiber.StatusNotFound, "User tidak ditemukan")
+	case "username atau email sudah digunakan":
+		return helper.ErrorResponse(c, fiber.StatusConflict, "Username atau email sudah digunakan")
+	case "tidak dapat menonaktifkan akun sendiri", "tidak dapat menghapus akun sendiri":
+		return helper.ErrorResponse(c, fiber.StatusForbidden, err.Error())
+	case "gagal memproses password", "gagal mencabut sesi user", "gagal membuka kunci akun", "gagal membuka kunci IP", "gagal memeriksa role":
+		return helper.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	default:
+		return helper.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+}
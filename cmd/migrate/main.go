@@ -0,0 +1,172 @@
+// Command migrate menjalankan migrasi skema/data MongoDB bernomor yang
+// tercatat di koleksi schema_migrations.
+//
+// Penggunaan:
+//
+//	go run ./cmd/migrate status
+//	go run ./cmd/migrate up [-to N] [-with nama1,nama2]
+//	go run ./cmd/migrate down [-steps N | -to N]
+//	go run ./cmd/migrate sync-legacy [-dry-run] [-restart] [-json]
+//
+// Migrasi opsional (mis. import_legacy_postgres) hanya dijalankan jika
+// disebut di -with. sync-legacy bisa dijalankan berulang kali selama masa
+// transisi untuk menarik perubahan terbaru dari PostgreSQL sistem lama.
+package main
+
+import (
+	"alumni-crud-api/config"
+	"alumni-crud-api/database"
+	"alumni-crud-api/database/legacy"
+	"alumni-crud-api/database/migration"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `Penggunaan: migrate <perintah> [flag]
+
+Perintah:
+  status   tampilkan migrasi yang sudah/belum dijalankan
+  up       jalankan migrasi yang belum diterapkan
+           -to N          berhenti di versi N
+           -with a,b      ikut jalankan migrasi opsional a dan b
+  down     rollback migrasi terakhir
+           -steps N       jumlah migrasi yang di-rollback (default 1)
+           -to N          rollback sampai versi N (versi N tetap diterapkan)
+  sync-legacy  sinkronkan data terbaru dari PostgreSQL sistem lama
+           -dry-run       hanya tampilkan perbedaan, tanpa menulis apa pun
+           -restart       abaikan checkpoint sinkronisasi yang gagal sebelumnya
+           -json          tulis laporan lengkap sebagai JSON`)
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	to := flags.Int("to", -1, "versi target")
+	steps := flags.Int("steps", 0, "jumlah migrasi yang di-rollback")
+	with := flags.String("with", "", "migrasi opsional yang ikut dijalankan, dipisah koma")
+	dryRun := flags.Bool("dry-run", false, "sync-legacy: hanya tampilkan perbedaan")
+	restart := flags.Bool("restart", false, "sync-legacy: abaikan checkpoint sebelumnya")
+	asJSON := flags.Bool("json", false, "sync-legacy: tulis laporan sebagai JSON")
+	flags.Usage = usage
+	flags.Parse(os.Args[2:])
+
+	cfg := config.LoadConfig()
+	db := database.ConnectMongo()
+	migrator, err := migration.NewMigrator(db, migration.All(cfg))
+	if err != nil {
+		log.Fatalf("Daftar migrasi tidak valid: %v", err)
+	}
+
+	ctx := context.Background()
+	switch command {
+	case "status":
+		printStatus(ctx, migrator)
+
+	case "up":
+		include := make(map[string]bool)
+		for _, name := range strings.Split(*with, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if _, ok := migrator.Find(name); !ok {
+				log.Fatalf("Migrasi %q tidak dikenal", name)
+			}
+			include[name] = true
+		}
+		target := *to
+		if target < 0 {
+			target = 0
+		}
+		count, err := migrator.Up(ctx, target, include)
+		if err != nil {
+			log.Fatalf("%v (%d migrasi berhasil sebelumnya)", err, count)
+		}
+		log.Printf("%d migrasi diterapkan", count)
+
+	case "down":
+		if *steps <= 0 && *to < 0 {
+			*steps = 1
+		}
+		count, err := migrator.Down(ctx, *steps, *to)
+		if err != nil {
+			log.Fatalf("%v (%d migrasi berhasil di-rollback sebelumnya)", err, count)
+		}
+		log.Printf("%d migrasi di-rollback", count)
+
+	case "sync-legacy":
+		syncLegacy(ctx, cfg, db, legacy.Options{DryRun: *dryRun, Restart: *restart}, *asJSON)
+
+	default:
+		usage()
+	}
+}
+
+func syncLegacy(ctx context.Context, cfg *config.Config, db *mongo.Database, opts legacy.Options, asJSON bool) {
+	pg, err := legacy.Open(ctx, cfg.PostgresDSN())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer pg.Close()
+
+	if opts.DryRun {
+		log.Println("Dry-run: tidak ada data yang ditulis")
+	}
+	report, syncErr := legacy.Sync(ctx, pg, db, opts)
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else if opts.DryRun {
+		legacy.WriteChanges(os.Stdout, report)
+	} else {
+		// Baris yang disisipkan/diperbarui sudah tercermin di ringkasan;
+		// konflik dan baris yang dilewati perlu ditindaklanjuti manual
+		for _, c := range report.Changes {
+			if c.Action == legacy.ActionConflict || c.Action == legacy.ActionSkip {
+				legacy.WriteChanges(os.Stdout, &legacy.Report{Changes: []legacy.Change{c}})
+			}
+		}
+	}
+	legacy.LogSummary(report)
+
+	if syncErr != nil {
+		log.Fatalf("Sinkronisasi berhenti: %v (jalankan ulang untuk melanjutkan dari checkpoint)", syncErr)
+	}
+}
+
+func printStatus(ctx context.Context, migrator *migration.Migrator) {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		log.Fatalf("Gagal membaca %s: %v", migration.Collection, err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSI\tNAMA\tSTATUS\tDITERAPKAN")
+	for _, s := range statuses {
+		status, appliedAt := "belum", "-"
+		if s.Migration.Optional {
+			status = "belum (opsional)"
+		}
+		if s.Applied != nil {
+			status = "diterapkan"
+			appliedAt = s.Applied.AppliedAt.Local().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Migration.Version, s.Migration.Name, status, appliedAt)
+	}
+	w.Flush()
+}
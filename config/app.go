@@ -4,6 +4,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 )
 
 func SetupApp() *fiber.App {
@@ -24,8 +25,13 @@ func SetupApp() *fiber.App {
 	})
 
 	// Middleware
-	app.Use(cors.New())
-	app.Use(logger.New())
+	app.Use(cors.New(cors.Config{
+		ExposeHeaders: "ETag", // Dibaca client untuk header If-Match
+	}))
+	app.Use(requestid.New()) // Header X-Request-ID, juga dicatat di audit log
+	app.Use(logger.New(logger.Config{
+		Format: "[${time}] ${locals:requestid} ${status} - ${latency} ${method} ${path}\n",
+	}))
 
 	return app
 }
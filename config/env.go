@@ -1,8 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -11,6 +15,88 @@ type Config struct {
 	ServerPort   string
 	MongoURI     string
 	DatabaseName string
+
+	// JWT
+	JWTSecret          string            // Secret HS256 (boleh kosong jika memakai JWT_KEYS)
+	JWTSecretKID       string            // kid untuk JWT_SECRET
+	JWTPreviousSecrets map[string]string // kid -> secret lama, hanya untuk verifikasi
+	JWTKeyFiles        map[string]string // kid -> path file PEM (RSA / Ed25519)
+	JWTKeyOrder        []string          // Urutan kid sesuai JWT_KEYS
+	JWTActiveKID       string            // kid yang dipakai untuk menandatangani token baru
+	JWTIssuer          string
+	JWTAccessTTL       time.Duration
+	JWTRefreshTTL      time.Duration
+
+	// Mail
+	MailDriver       string // "log" (default) atau "smtp"
+	MailFrom         string
+	MailLogPath      string
+	SMTPHost         string
+	SMTPPort         string
+	SMTPUsername     string
+	SMTPPassword     string
+	PasswordResetURL string // Link di email reset, token ditambahkan sebagai ?token=
+	PasswordResetTTL time.Duration
+
+	// Proteksi brute-force login
+	LoginMaxFailures   int           // Gagal per akun sebelum dikunci
+	LoginIPMaxFailures int           // Gagal per IP sebelum dikunci
+	LoginFailureWindow time.Duration // Penghitung direset jika tidak ada kegagalan selama window
+	LoginLockoutBase   time.Duration // Durasi kunci pertama, berlipat dua tiap kegagalan berikutnya
+	LoginLockoutMax    time.Duration
+
+	// Two-factor authentication (TOTP)
+	MFARequiredForAdmin bool
+	MFAChallengeTTL     time.Duration
+	TOTPIssuer          string
+
+	// Pemetaan fakultas -> daftar jurusan untuk scope admin
+	Faculties map[string][]string
+
+	// Login SSO kampus (OpenID Connect, authorization code + PKCE)
+	OIDCIssuerURL     string
+	OIDCClientID      string
+	OIDCClientSecret  string // Kosong untuk public client (cukup PKCE)
+	OIDCRedirectURL   string
+	OIDCScopes        []string
+	OIDCNIMClaim      string // Nama claim ID token yang berisi NIM
+	OIDCAutoProvision bool   // Buat user baru (role "user") saat login SSO pertama
+	OIDCStateTTL      time.Duration
+
+	// Masa berlaku kode verifikasi klaim data alumni
+	AlumniClaimTTL time.Duration
+
+	// Perubahan data yang diajukan alumni sendiri menunggu persetujuan admin
+	AlumniRequireApproval    bool // Profil alumni (PUT /alumni/me)
+	PekerjaanRequireApproval bool
+
+	// Optimistic concurrency: PUT/PATCH/DELETE alumni & pekerjaan wajib
+	// mengirim If-Match berisi ETag dari GET (428 jika tidak ada)
+	RequireIfMatch bool
+
+	// Pekerjaan di trash lebih lama dari TrashRetention dihapus permanen oleh
+	// auto-purge setiap TrashPurgeInterval (kecuali legal hold). 0 = nonaktif.
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+
+	// PostgreSQL sistem lama, hanya dipakai migrasi impor data (cmd/migrate)
+	PGHost     string
+	PGPort     string
+	PGUser     string
+	PGPassword string
+	PGName     string
+	PGSSLMode  string
+}
+
+// PostgresDSN menyusun connection string PostgreSQL sistem lama
+func (c *Config) PostgresDSN() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		c.PGHost, c.PGPort, c.PGUser, c.PGPassword, c.PGName, c.PGSSLMode)
+}
+
+// OIDCEnabled bernilai true jika login SSO dikonfigurasi
+func (c *Config) OIDCEnabled() bool {
+	return c.OIDCIssuerURL != "" && c.OIDCClientID != ""
 }
 
 func LoadConfig() *Config {
@@ -19,10 +105,82 @@ func LoadConfig() *Config {
 		log.Println("Warning: .env file not found, using environment variables")
 	}
 
+	keyFiles, keyOrder := getEnvMap("JWT_KEYS")
+	previousSecrets, _ := getEnvMap("JWT_PREVIOUS_SECRETS")
+
+	// FACULTIES=teknik=Teknik Informatika|Teknik Sipil,ekonomi=Manajemen|Akuntansi
+	facultyMap, _ := getEnvMap("FACULTIES")
+	faculties := make(map[string][]string, len(facultyMap))
+	for faculty, jurusan := range facultyMap {
+		for _, j := range strings.Split(jurusan, "|") {
+			if j = strings.TrimSpace(j); j != "" {
+				faculties[strings.ToLower(faculty)] = append(faculties[strings.ToLower(faculty)], j)
+			}
+		}
+	}
+
 	return &Config{
 		ServerPort:   getEnv("SERVER_PORT", "3000"),
 		MongoURI:     getEnv("MONGODB_URI", "mongodb://localhost:27017"),
 		DatabaseName: getEnv("DATABASE_NAME", "alumnidb"),
+
+		JWTSecret:          os.Getenv("JWT_SECRET"),
+		JWTSecretKID:       getEnv("JWT_SECRET_KID", "hs-default"),
+		JWTPreviousSecrets: previousSecrets,
+		JWTKeyFiles:        keyFiles,
+		JWTKeyOrder:        keyOrder,
+		JWTActiveKID:       os.Getenv("JWT_ACTIVE_KID"),
+		JWTIssuer:          getEnv("JWT_ISSUER", "alumni-crud-api"),
+		JWTAccessTTL:       getEnvDuration("JWT_EXPIRES_IN", 15*time.Minute),
+		JWTRefreshTTL:      getEnvDuration("JWT_REFRESH_EXPIRES_IN", 7*24*time.Hour),
+
+		MailDriver:       getEnv("MAIL_DRIVER", "log"),
+		MailFrom:         getEnv("MAIL_FROM", "no-reply@alumni-crud-api.local"),
+		MailLogPath:      getEnv("MAIL_LOG_PATH", "logs/mail.log"),
+		SMTPHost:         os.Getenv("SMTP_HOST"),
+		SMTPPort:         getEnv("SMTP_PORT", "587"),
+		SMTPUsername:     os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:     os.Getenv("SMTP_PASSWORD"),
+		PasswordResetURL: getEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+		PasswordResetTTL: getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+
+		LoginMaxFailures:   getEnvInt("LOGIN_MAX_FAILURES", 5),
+		LoginIPMaxFailures: getEnvInt("LOGIN_IP_MAX_FAILURES", 20),
+		LoginFailureWindow: getEnvDuration("LOGIN_FAILURE_WINDOW", 24*time.Hour),
+		LoginLockoutBase:   getEnvDuration("LOGIN_LOCKOUT_BASE", time.Minute),
+		LoginLockoutMax:    getEnvDuration("LOGIN_LOCKOUT_MAX", time.Hour),
+
+		MFARequiredForAdmin: getEnvBool("MFA_REQUIRED_FOR_ADMIN", false),
+		MFAChallengeTTL:     getEnvDuration("MFA_CHALLENGE_TTL", 5*time.Minute),
+		TOTPIssuer:          getEnv("TOTP_ISSUER", "Alumni CRUD API"),
+
+		Faculties: faculties,
+
+		OIDCIssuerURL:     os.Getenv("OIDC_ISSUER_URL"),
+		OIDCClientID:      os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret:  os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:   getEnv("OIDC_REDIRECT_URL", "http://localhost:3000/alumni-crud-api/auth/oidc/callback"),
+		OIDCScopes:        strings.Fields(getEnv("OIDC_SCOPES", "openid email profile")),
+		OIDCNIMClaim:      getEnv("OIDC_NIM_CLAIM", "nim"),
+		OIDCAutoProvision: getEnvBool("OIDC_AUTO_PROVISION", true),
+		OIDCStateTTL:      getEnvDuration("OIDC_STATE_TTL", 10*time.Minute),
+
+		AlumniClaimTTL: getEnvDuration("ALUMNI_CLAIM_TTL", 30*time.Minute),
+
+		AlumniRequireApproval:    getEnvBool("ALUMNI_REQUIRE_APPROVAL", false),
+		PekerjaanRequireApproval: getEnvBool("PEKERJAAN_REQUIRE_APPROVAL", false),
+
+		RequireIfMatch: getEnvBool("REQUIRE_IF_MATCH", true),
+
+		TrashRetention:     getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+		TrashPurgeInterval: getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
+
+		PGHost:     getEnv("DB_HOST", "localhost"),
+		PGPort:     getEnv("DB_PORT", "5432"),
+		PGUser:     getEnv("DB_USER", "postgres"),
+		PGPassword: getEnv("DB_PASSWORD", ""),
+		PGName:     getEnv("DB_NAME", "alumnidb"),
+		PGSSLMode:  getEnv("DB_SSLMODE", "disable"),
 	}
 }
 
@@ -32,3 +190,65 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: %s=%q bukan angka yang valid, memakai default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: %s=%q bukan boolean yang valid, memakai default %t", key, value, defaultValue)
+		return defaultValue
+	}
+	return b
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: %s=%q bukan durasi yang valid, memakai default %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
+// getEnvMap membaca format "a=x,b=y" dan mengembalikan map beserta urutan key-nya
+func getEnvMap(key string) (map[string]string, []string) {
+	result := make(map[string]string)
+	var order []string
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || k == "" || v == "" {
+			log.Printf("Warning: entri %s tidak valid: %q (format: key=nilai)", key, pair)
+			continue
+		}
+		k = strings.TrimSpace(k)
+		if _, exists := result[k]; !exists {
+			order = append(order, k)
+		}
+		result[k] = strings.TrimSpace(v)
+	}
+	return result, order
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"alumni-crud-api/app/model"
+	"alumni-crud-api/app/repository"
+	"fmt"
+	"log"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxReportedIDs membatasi jumlah ID yang ditampilkan per nilai duplikat
+const maxReportedIDs = 10
+
+// IndexTarget adalah satu koleksi yang index-nya dibuat saat startup
+type IndexTarget struct {
+	Name       string
+	Ensure     func() error
+	Duplicates func() ([]model.DuplicateGroup, error) // Pre-flight unique index (opsional)
+}
+
+// IndexTargets mengembalikan index semua koleksi aplikasi, dipakai saat startup
+// server maupun oleh migrasi create_indexes
+func IndexTargets(db *mongo.Database) []IndexTarget {
+	authRepo := repository.NewAuthRepository(db)
+	alumniRepo := repository.NewAlumniRepository(db)
+	return []IndexTarget{
+		{Name: "users", Ensure: authRepo.EnsureIndexes, Duplicates: authRepo.FindDuplicates},
+		{Name: "alumni", Ensure: alumniRepo.EnsureIndexes, Duplicates: alumniRepo.FindDuplicates},
+		{Name: "pekerjaan", Ensure: repository.NewPekerjaanRepository(db).EnsureIndexes},
+		{Name: "files", Ensure: repository.NewFileRepository(db).EnsureIndexes},
+		{Name: "token", Ensure: repository.NewTokenRepository(db).EnsureIndexes},
+		{Name: "password reset", Ensure: repository.NewPasswordResetRepository(db).EnsureIndexes},
+		{Name: "login attempts", Ensure: repository.NewLoginAttemptRepository(db).EnsureIndexes},
+		{Name: "roles", Ensure: repository.NewRoleRepository(db).EnsureIndexes},
+		{Name: "API key", Ensure: repository.NewAPIKeyRepository(db).EnsureIndexes},
+		{Name: "OIDC state", Ensure: repository.NewOIDCStateRepository(db).EnsureIndexes},
+		{Name: "klaim alumni", Ensure: repository.NewAlumniClaimRepository(db).EnsureIndexes},
+		{Name: "antrian moderasi", Ensure: repository.NewPendingChangeRepository(db).EnsureIndexes},
+		{Name: "audit log", Ensure: repository.NewAuditLogRepository(db).EnsureIndexes},
+		{Name: "riwayat versi", Ensure: repository.NewRecordVersionRepository(db).EnsureIndexes},
+	}
+}
+
+// EnsureIndexes membuat index semua koleksi. Untuk koleksi dengan unique index,
+// data duplikat dilaporkan lebih dulu karena akan menggagalkan pembuatan index.
+// Kegagalan hanya dicatat agar server tetap bisa berjalan.
+func EnsureIndexes(targets []IndexTarget) {
+	for _, target := range targets {
+		if target.Duplicates != nil {
+			groups, err := target.Duplicates()
+			if err != nil {
+				log.Printf("Peringatan: gagal memeriksa data duplikat %s: %v", target.Name, err)
+			} else if len(groups) > 0 {
+				ReportDuplicates(target.Name, groups)
+			}
+		}
+
+		if err := target.Ensure(); err != nil {
+			log.Printf("Peringatan: gagal membuat index %s: %v", target.Name, err)
+		}
+	}
+}
+
+// EnsureIndexesStrict sama seperti EnsureIndexes tetapi berhenti di kegagalan
+// pertama, termasuk jika masih ada data duplikat. Dipakai oleh cmd/migrate.
+func EnsureIndexesStrict(targets []IndexTarget) error {
+	for _, target := range targets {
+		if target.Duplicates != nil {
+			groups, err := target.Duplicates()
+			if err != nil {
+				return fmt.Errorf("gagal memeriksa data duplikat %s: %v", target.Name, err)
+			}
+			if len(groups) > 0 {
+				ReportDuplicates(target.Name, groups)
+				return fmt.Errorf("%d nilai duplikat di %s harus dibereskan dulu", len(groups), target.Name)
+			}
+		}
+
+		if err := target.Ensure(); err != nil {
+			return fmt.Errorf("gagal membuat index %s: %v", target.Name, err)
+		}
+	}
+	return nil
+}
+
+// ReportDuplicates mencatat nilai duplikat yang harus dibereskan manual
+// sebelum unique index bisa dibuat
+func ReportDuplicates(name string, groups []model.DuplicateGroup) {
+	log.Printf("Peringatan: %d nilai duplikat di %s, unique index tidak bisa dibuat sampai data ini dibereskan:", len(groups), name)
+	for _, g := range groups {
+		ids := make([]string, 0, maxReportedIDs)
+		for i, id := range g.IDs {
+			if i == maxReportedIDs {
+				ids = append(ids, "...")
+				break
+			}
+			ids = append(ids, id.Hex())
+		}
+		log.Printf("  %s.%s = %v (%d dokumen): %s", g.Collection, g.Field, g.Value, g.Count, strings.Join(ids, ", "))
+	}
+}
@@ -0,0 +1,42 @@
+package legacy
+
+import (
+	"fmt"
+	"io"
+	"log"
+)
+
+var reportActions = []string{ActionInsert, ActionUpdate, ActionLink, ActionUnchanged, ActionConflict, ActionSkip}
+
+// LogSummary mencatat jumlah baris per aksi untuk setiap tabel
+func LogSummary(r *Report) {
+	for _, t := range tables {
+		counts := r.Counts[t.name]
+		line := ""
+		for _, action := range reportActions {
+			line += fmt.Sprintf(" %s=%d", action, counts[action])
+		}
+		if lastID, ok := r.Resumed[t.name]; ok {
+			line += fmt.Sprintf(" (lanjut setelah id %d)", lastID)
+		}
+		log.Printf("  -> %s:%s", t.name, line)
+	}
+}
+
+// WriteChanges menulis rincian setiap baris yang (akan) berubah, termasuk
+// perbedaan per field, dalam format yang mudah dibaca
+func WriteChanges(w io.Writer, r *Report) {
+	for _, c := range r.Changes {
+		fmt.Fprintf(w, "%-9s %s #%d", c.Action, c.Table, c.LegacyID)
+		if !c.ID.IsZero() {
+			fmt.Fprintf(w, " -> %s", c.ID.Hex())
+		}
+		if c.Reason != "" {
+			fmt.Fprintf(w, " (%s)", c.Reason)
+		}
+		fmt.Fprintln(w)
+		for _, d := range c.Diff {
+			fmt.Fprintf(w, "    %s: %v -> %v\n", d.Field, d.Mongo, d.Legacy)
+		}
+	}
+}
@@ -0,0 +1,398 @@
+// Package legacy menyinkronkan data dari PostgreSQL sistem lama ke MongoDB
+// selama masa transisi. Sinkronisasi bersifat incremental dan aman diulang:
+//   - setiap dokumen hasil impor menyimpan legacy_id (id baris Postgres),
+//     sehingga ObjectID dan semua relasi (alumni_id, file, riwayat) tetap;
+//   - baris baru disisipkan, baris yang berubah di Postgres diperbarui, tidak
+//     ada data yang dihapus;
+//   - dokumen yang juga diubah lewat API sejak sinkronisasi terakhir tidak
+//     ditimpa, melainkan dilaporkan sebagai konflik;
+//   - posisi terakhir disimpan di legacy_sync_state agar sinkronisasi yang
+//     gagal bisa dilanjutkan.
+package legacy
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StateCollection menyimpan checkpoint sinkronisasi per tabel
+const StateCollection = "legacy_sync_state"
+
+// checkpointEvery adalah jumlah baris di antara penyimpanan checkpoint
+const checkpointEvery = 100
+
+// Aksi per baris pada laporan sinkronisasi
+const (
+	ActionInsert    = "insert"    // Baris baru, disisipkan
+	ActionUpdate    = "update"    // Berubah di Postgres, diperbarui
+	ActionLink      = "link"      // Data lama tanpa legacy_id, ditautkan lewat kunci alami
+	ActionUnchanged = "unchanged" // Tidak ada perubahan dari Postgres
+	ActionConflict  = "conflict"  // Berubah di Postgres dan di MongoDB, tidak ditimpa
+	ActionSkip      = "skip"      // Tidak bisa disinkronkan (relasi hilang, duplikat, ...)
+)
+
+// Options mengatur satu kali sinkronisasi
+type Options struct {
+	DryRun  bool // Hanya laporkan perbedaan, tanpa menulis apa pun
+	Restart bool // Abaikan checkpoint sinkronisasi sebelumnya yang gagal
+}
+
+// FieldDiff adalah perbedaan satu field antara MongoDB dan Postgres
+type FieldDiff struct {
+	Field  string      `json:"field"`
+	Mongo  interface{} `json:"mongo"`
+	Legacy interface{} `json:"legacy"`
+}
+
+// Change adalah hasil sinkronisasi satu baris (selain unchanged)
+type Change struct {
+	Table    string             `json:"table"`
+	LegacyID int                `json:"legacy_id"`
+	ID       primitive.ObjectID `json:"id,omitempty"`
+	Action   string             `json:"action"`
+	Reason   string             `json:"reason,omitempty"`
+	Diff     []FieldDiff        `json:"diff,omitempty"`
+}
+
+// Report merangkum hasil sinkronisasi
+type Report struct {
+	DryRun  bool                      `json:"dry_run"`
+	Counts  map[string]map[string]int `json:"counts"` // tabel -> aksi -> jumlah
+	Changes []Change                  `json:"changes"`
+	Resumed map[string]int            `json:"resumed,omitempty"` // tabel -> id terakhir checkpoint
+}
+
+func (r *Report) add(table, action string, change *Change) {
+	if r.Counts[table] == nil {
+		r.Counts[table] = make(map[string]int)
+	}
+	r.Counts[table][action]++
+	if change != nil {
+		r.Changes = append(r.Changes, *change)
+	}
+}
+
+// syncState adalah checkpoint satu tabel
+type syncState struct {
+	Table       string     `bson:"_id"`
+	Status      string     `bson:"status"` // running | completed
+	LastID      int        `bson:"last_id"`
+	StartedAt   time.Time  `bson:"started_at"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty"`
+}
+
+// Syncer menjalankan sinkronisasi dari satu koneksi Postgres ke satu database
+type Syncer struct {
+	pg     *sql.DB
+	db     *mongo.Database
+	opts   Options
+	report *Report
+	// refs memetakan legacy_id -> ObjectID per koleksi untuk relasi antar
+	// tabel; saat dry-run juga berisi ObjectID sementara untuk baris baru
+	refs map[string]map[int]primitive.ObjectID
+}
+
+// Open membuka koneksi Postgres dari DSN
+func Open(ctx context.Context, dsn string) (*sql.DB, error) {
+	pg, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("gagal konek ke Postgres: %v", err)
+	}
+	if err := pg.PingContext(ctx); err != nil {
+		pg.Close()
+		return nil, fmt.Errorf("gagal ping Postgres: %v", err)
+	}
+	return pg, nil
+}
+
+// Sync menyinkronkan users, alumni lalu pekerjaan. Jika gagal di tengah jalan,
+// laporan sampai titik kegagalan tetap dikembalikan bersama error.
+func Sync(ctx context.Context, pg *sql.DB, db *mongo.Database, opts Options) (*Report, error) {
+	s := &Syncer{
+		pg:     pg,
+		db:     db,
+		opts:   opts,
+		report: &Report{DryRun: opts.DryRun, Counts: map[string]map[string]int{}, Resumed: map[string]int{}},
+		refs:   map[string]map[int]primitive.ObjectID{},
+	}
+
+	for _, t := range tables {
+		if !opts.DryRun {
+			if err := s.ensureIndex(ctx, t.collection); err != nil {
+				return s.report, fmt.Errorf("%s: gagal membuat index legacy_id: %v", t.name, err)
+			}
+		}
+		if err := s.loadRefs(ctx, t.collection); err != nil {
+			return s.report, fmt.Errorf("%s: %v", t.name, err)
+		}
+	}
+	for _, t := range tables {
+		if err := s.syncTable(ctx, t); err != nil {
+			return s.report, fmt.Errorf("%s: %v", t.name, err)
+		}
+	}
+	return s.report, nil
+}
+
+func (s *Syncer) ensureIndex(ctx context.Context, collection string) error {
+	_, err := s.db.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "legacy_id", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("uniq_legacy_id").
+			SetPartialFilterExpression(bson.M{"legacy_id": bson.M{"$exists": true}}),
+	})
+	return err
+}
+
+// loadRefs memuat pemetaan legacy_id -> ObjectID yang sudah tersimpan
+func (s *Syncer) loadRefs(ctx context.Context, collection string) error {
+	cursor, err := s.db.Collection(collection).Find(ctx,
+		bson.M{"legacy_id": bson.M{"$exists": true}},
+		options.Find().SetProjection(bson.M{"_id": 1, "legacy_id": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	refs := make(map[int]primitive.ObjectID)
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID       primitive.ObjectID `bson:"_id"`
+			LegacyID int                `bson:"legacy_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		refs[doc.LegacyID] = doc.ID
+	}
+	s.refs[collection] = refs
+	return cursor.Err()
+}
+
+// ref mengembalikan ObjectID dokumen dengan legacy_id tertentu
+func (s *Syncer) ref(collection string, legacyID int) (primitive.ObjectID, bool) {
+	id, ok := s.refs[collection][legacyID]
+	return id, ok
+}
+
+func (s *Syncer) checkpoint(ctx context.Context, table string, update bson.M) error {
+	if s.opts.DryRun {
+		return nil
+	}
+	_, err := s.db.Collection(StateCollection).UpdateOne(ctx, bson.M{"_id": table},
+		bson.M{"$set": update}, options.Update().SetUpsert(true))
+	return err
+}
+
+// startID menentukan baris awal: lanjut dari checkpoint jika sinkronisasi
+// sebelumnya untuk tabel ini berhenti di tengah jalan
+func (s *Syncer) startID(ctx context.Context, table string) (int, error) {
+	if s.opts.DryRun || s.opts.Restart {
+		return 0, nil
+	}
+	var state syncState
+	err := s.db.Collection(StateCollection).FindOne(ctx, bson.M{"_id": table}).Decode(&state)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if state.Status == "running" {
+		s.report.Resumed[table] = state.LastID
+		return state.LastID, nil
+	}
+	return 0, nil
+}
+
+func (s *Syncer) syncTable(ctx context.Context, t table) error {
+	lastID, err := s.startID(ctx, t.name)
+	if err != nil {
+		return err
+	}
+	if lastID > 0 {
+		log.Printf("Sinkronisasi %s dilanjutkan setelah id %d", t.name, lastID)
+	} else if err := s.checkpoint(ctx, t.name, bson.M{"status": "running", "last_id": 0, "started_at": time.Now(), "completed_at": nil}); err != nil {
+		return err
+	}
+
+	rows, err := s.pg.QueryContext(ctx, t.query+" WHERE id > $1 ORDER BY id", lastID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	processed := 0
+	for rows.Next() {
+		rec, err := t.scan(rows, s)
+		if err != nil {
+			return err
+		}
+		if err := s.syncRecord(ctx, t, rec); err != nil {
+			return fmt.Errorf("id %d: %v", rec.legacyID, err)
+		}
+
+		processed++
+		if processed%checkpointEvery == 0 {
+			if err := s.checkpoint(ctx, t.name, bson.M{"last_id": rec.legacyID}); err != nil {
+				return err
+			}
+		}
+		lastID = rec.legacyID
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return s.checkpoint(ctx, t.name, bson.M{"status": "completed", "last_id": lastID, "completed_at": now})
+}
+
+// syncRecord mencocokkan satu baris Postgres dengan dokumen MongoDB lalu
+// menyisipkan, menautkan, atau memperbaruinya
+func (s *Syncer) syncRecord(ctx context.Context, t table, rec *record) error {
+	change := &Change{Table: t.name, LegacyID: rec.legacyID, Reason: rec.note}
+	if rec.skip != "" {
+		change.Action, change.Reason = ActionSkip, rec.skip
+		s.report.add(t.name, ActionSkip, change)
+		return nil
+	}
+
+	coll := s.db.Collection(t.collection)
+	var existing bson.M
+	err := coll.FindOne(ctx, bson.M{"legacy_id": rec.legacyID}).Decode(&existing)
+	if err == mongo.ErrNoDocuments && rec.natural != nil {
+		// Data hasil impor lama (sebelum ada legacy_id) dicocokkan lewat kunci alami
+		filter := bson.M{"legacy_id": bson.M{"$exists": false}}
+		for k, v := range rec.natural {
+			filter[k] = v
+		}
+		err = coll.FindOne(ctx, filter).Decode(&existing)
+	}
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	now := time.Now()
+	if existing == nil {
+		id := primitive.NewObjectID()
+		change.ID, change.Action = id, ActionInsert
+		if !s.opts.DryRun {
+			doc := bson.M{
+				"_id":               id,
+				"legacy_id":         rec.legacyID,
+				"legacy_updated_at": rec.updatedAt,
+				"legacy_synced_at":  now,
+				"created_at":        rec.createdAt,
+				"updated_at":        rec.updatedAt,
+				"version":           1,
+			}
+			for k, v := range t.defaults {
+				doc[k] = v
+			}
+			for k, v := range rec.fields {
+				if v != nil {
+					doc[k] = v
+				}
+			}
+			if _, err := coll.InsertOne(ctx, doc); err != nil {
+				if mongo.IsDuplicateKeyError(err) {
+					change.ID, change.Action, change.Reason = primitive.NilObjectID, ActionSkip, "bentrok dengan unique index: "+err.Error()
+					s.report.add(t.name, ActionSkip, change)
+					return nil
+				}
+				return err
+			}
+		}
+		s.refs[t.collection][rec.legacyID] = id
+		s.report.add(t.name, ActionInsert, change)
+		return nil
+	}
+
+	id, _ := existing["_id"].(primitive.ObjectID)
+	change.ID = id
+	s.refs[t.collection][rec.legacyID] = id
+	diff := diffFields(existing, rec.fields)
+
+	if _, linked := existing["legacy_id"]; !linked {
+		// Isi dokumen tidak ditimpa saat menautkan; perbedaan hanya dilaporkan
+		change.Action, change.Diff = ActionLink, diff
+		if !s.opts.DryRun {
+			_, err := coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+				"legacy_id":         rec.legacyID,
+				"legacy_updated_at": rec.updatedAt,
+				"legacy_synced_at":  now,
+			}})
+			if err != nil {
+				return err
+			}
+		}
+		s.report.add(t.name, ActionLink, change)
+		return nil
+	}
+
+	legacyUpdatedAt := timeValue(existing["legacy_updated_at"])
+	if len(diff) == 0 || !rec.updatedAt.Truncate(time.Millisecond).After(legacyUpdatedAt) {
+		// Tidak ada perubahan baru dari Postgres; perubahan lewat API dipertahankan
+		s.report.add(t.name, ActionUnchanged, nil)
+		return nil
+	}
+
+	change.Diff = diff
+	if timeValue(existing["updated_at"]).After(timeValue(existing["legacy_synced_at"])) {
+		change.Action, change.Reason = ActionConflict, "data juga diubah di sistem baru sejak sinkronisasi terakhir"
+		s.report.add(t.name, ActionConflict, change)
+		return nil
+	}
+
+	change.Action = ActionUpdate
+	if !s.opts.DryRun {
+		set := bson.M{"legacy_updated_at": rec.updatedAt, "legacy_synced_at": now, "updated_at": now}
+		unset := bson.M{}
+		for _, d := range diff {
+			if d.Legacy == nil {
+				unset[d.Field] = ""
+			} else {
+				set[d.Field] = d.Legacy
+			}
+		}
+		update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
+		if _, err := coll.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				change.Action, change.Reason = ActionSkip, "bentrok dengan unique index: "+err.Error()
+				s.report.add(t.name, ActionSkip, change)
+				return nil
+			}
+			return err
+		}
+	}
+	s.report.add(t.name, ActionUpdate, change)
+	return nil
+}
+
+// diffFields membandingkan field yang dikelola sistem lama. Nilai nil berarti
+// kolom NULL di Postgres (field tidak ada di MongoDB).
+func diffFields(existing bson.M, fields bson.M) []FieldDiff {
+	var diff []FieldDiff
+	for _, field := range sortedKeys(fields) {
+		legacy := fields[field]
+		current, ok := existing[field]
+		if !ok {
+			current = nil
+		}
+		if !sameValue(current, legacy) {
+			diff = append(diff, FieldDiff{Field: field, Mongo: current, Legacy: legacy})
+		}
+	}
+	return diff
+}
@@ -0,0 +1,122 @@
+package legacy
+
+import (
+	"alumni-crud-api/helper"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// roundTrip mengembalikan dokumen seperti hasil FindOne: angka menjadi
+// int32/int64, waktu menjadi primitive.DateTime, sub-dokumen menjadi bson.D
+func roundTrip(t *testing.T, doc bson.M) bson.M {
+	t.Helper()
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded bson.M
+	if err := bson.Unmarshal(raw, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+// Baris yang baru disisipkan lalu dibaca ulang pada sinkronisasi berikutnya
+// harus dianggap tidak berubah, bukan diperbarui terus-menerus
+func TestDiffFieldsStableAfterInsert(t *testing.T) {
+	mulai := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	fields := bson.M{
+		"alumni_id":             primitive.NewObjectID(),
+		"nama_perusahaan":       "PT Maju",
+		"angkatan":              2017,
+		"gaji_range":            nil,
+		"tanggal_mulai_kerja":   mulai,
+		"tanggal_selesai_kerja": nil,
+		"is_deleted":            false,
+		"deleted_at":            time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC),
+		"tanggal_asli":          bson.M{"selesai": "akhir 2023"},
+	}
+	doc := bson.M{}
+	for k, v := range fields {
+		if v != nil {
+			doc[k] = v
+		}
+	}
+
+	if diff := diffFields(roundTrip(t, doc), fields); len(diff) != 0 {
+		t.Fatalf("dokumen hasil insert dianggap berbeda: %+v", diff)
+	}
+}
+
+func TestDiffFieldsReportsLegacyChanges(t *testing.T) {
+	existing := roundTrip(t, bson.M{
+		"nama":       "Budi",
+		"angkatan":   2017,
+		"no_telepon": "0812",
+		"created_at": time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	fields := bson.M{
+		"nama":       "Budi",
+		"angkatan":   2018,
+		"no_telepon": nil,    // Dikosongkan di Postgres
+		"alamat":     "Solo", // Baru diisi di Postgres
+	}
+
+	diff := diffFields(existing, fields)
+	want := []FieldDiff{
+		{Field: "alamat", Mongo: nil, Legacy: "Solo"},
+		{Field: "angkatan", Mongo: int32(2017), Legacy: 2018},
+		{Field: "no_telepon", Mongo: "0812", Legacy: nil},
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("diff %+v, ingin %+v", diff, want)
+	}
+	for i := range want {
+		if diff[i] != want[i] {
+			t.Errorf("diff[%d] = %+v, ingin %+v", i, diff[i], want[i])
+		}
+	}
+}
+
+func TestSameValueSubDocument(t *testing.T) {
+	stored := bson.D{{Key: "mulai", Value: "Maret 2021"}}
+	if !sameValue(stored, bson.M{"mulai": "Maret 2021"}) {
+		t.Error("sub-dokumen bson.D dengan isi sama harus dianggap sama")
+	}
+	if sameValue(stored, bson.M{"mulai": "Maret 2021", "selesai": "2023"}) {
+		t.Error("sub-dokumen dengan field tambahan harus dianggap berbeda")
+	}
+	if sameValue(nil, bson.M{}) || sameValue("2021", 2021) {
+		t.Error("nilai berbeda jenis tidak boleh dianggap sama")
+	}
+}
+
+// Impor lama bisa menyimpan tanggal sebagai string asli, string hasil
+// normalisasi, atau BSON date; kunci alami harus cocok dengan semuanya
+func TestStoredDateForms(t *testing.T) {
+	parsed, err := helper.ParseTanggal("01/03/2021")
+	if err != nil {
+		t.Fatal(err)
+	}
+	forms := storedDateForms(parsed, "01/03/2021")
+	for _, want := range []interface{}{"01/03/2021", parsed, parsed.Format(helper.DateLayout)} {
+		found := false
+		for _, f := range forms {
+			if f == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("bentuk %v tidak ada di %v", want, forms)
+		}
+	}
+
+	// Tanggal yang tidak bisa dibaca tersimpan apa adanya atau tidak tersimpan sama sekali
+	forms = storedDateForms(nil, "akhir 2023")
+	if len(forms) != 2 || forms[0] != "akhir 2023" || forms[1] != nil {
+		t.Errorf("bentuk tanggal tak terbaca = %v", forms)
+	}
+}
@@ -0,0 +1,283 @@
+package legacy
+
+import (
+	"alumni-crud-api/helper"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// record adalah satu baris Postgres yang sudah diubah ke bentuk dokumen
+type record struct {
+	legacyID  int
+	fields    bson.M // Field yang dikelola sistem lama; nil = NULL
+	natural   bson.M // Kunci alami untuk data lama tanpa legacy_id
+	createdAt time.Time
+	updatedAt time.Time
+	skip      string // Alasan baris dilewati
+	note      string // Catatan untuk laporan jika baris tetap disinkronkan
+}
+
+// table adalah satu tabel Postgres beserta koleksi tujuannya. query tidak
+// boleh berisi WHERE/ORDER BY karena ditambahkan saat membaca per checkpoint.
+type table struct {
+	name       string
+	collection string
+	query      string
+	defaults   bson.M // Field tambahan untuk dokumen baru
+	scan       func(rows *sql.Rows, s *Syncer) (*record, error)
+}
+
+// tables diurutkan sesuai relasi: alumni merujuk users, pekerjaan merujuk
+// alumni dan users
+var tables = []table{
+	{
+		name:       "users",
+		collection: "users",
+		query:      "SELECT id, username, email, password_hash, role, created_at, updated_at FROM users",
+		defaults:   bson.M{"is_disabled": false, "totp_enabled": false},
+		scan:       scanUser,
+	},
+	{
+		name:       "alumni",
+		collection: "alumni",
+		query:      "SELECT id, user_id, nim, nama, jurusan, angkatan, tahun_lulus, email, no_telepon, alamat, created_at, updated_at FROM alumni",
+		scan:       scanAlumni,
+	},
+	{
+		name:       "pekerjaan_alumni",
+		collection: "pekerjaan_alumni",
+		query:      "SELECT id, alumni_id, nama_perusahaan, posisi_jabatan, bidang_industri, lokasi_kerja, gaji_range, tanggal_mulai_kerja, tanggal_selesai_kerja, status_pekerjaan, deskripsi_pekerjaan, is_deleted, deleted_at, deleted_by, created_at, updated_at FROM pekerjaan_alumni",
+		scan:       scanPekerjaan,
+	},
+}
+
+func scanUser(rows *sql.Rows, s *Syncer) (*record, error) {
+	var (
+		rec                         record
+		username, email, hash, role string
+	)
+	if err := rows.Scan(&rec.legacyID, &username, &email, &hash, &role, &rec.createdAt, &rec.updatedAt); err != nil {
+		return nil, err
+	}
+	rec.fields = bson.M{
+		"username":      username,
+		"email":         email,
+		"password_hash": hash,
+		"role":          role,
+	}
+	rec.natural = bson.M{"username": username}
+	return &rec, nil
+}
+
+func scanAlumni(rows *sql.Rows, s *Syncer) (*record, error) {
+	var (
+		rec                       record
+		userID                    sql.NullInt64
+		nim, nama, jurusan, email string
+		angkatan, tahunLulus      int
+		noTelepon, alamat         sql.NullString
+	)
+	if err := rows.Scan(&rec.legacyID, &userID, &nim, &nama, &jurusan, &angkatan, &tahunLulus, &email,
+		&noTelepon, &alamat, &rec.createdAt, &rec.updatedAt); err != nil {
+		return nil, err
+	}
+	rec.fields = bson.M{
+		"nim":         nim,
+		"nama":        nama,
+		"jurusan":     jurusan,
+		"angkatan":    angkatan,
+		"tahun_lulus": tahunLulus,
+		"email":       email,
+		"no_telepon":  nullString(noTelepon),
+		"alamat":      nullString(alamat),
+	}
+	// Tautan akun juga dikelola sistem baru (klaim data, SSO), jadi user_id
+	// hanya diisi jika ada di Postgres dan tidak pernah dihapus dari sini
+	if userID.Valid {
+		if id, ok := s.ref("users", int(userID.Int64)); ok {
+			rec.fields["user_id"] = id
+		}
+	}
+	rec.natural = bson.M{"nim": nim}
+	return &rec, nil
+}
+
+func scanPekerjaan(rows *sql.Rows, s *Syncer) (*record, error) {
+	var (
+		rec                                               record
+		alumniID                                          int
+		perusahaan, posisi, bidang, lokasi, mulai, status string
+		gaji, selesai, deskripsi                          sql.NullString
+		isDeleted                                         bool
+		deletedAt                                         sql.NullTime
+		deletedBy                                         sql.NullInt64
+	)
+	if err := rows.Scan(&rec.legacyID, &alumniID, &perusahaan, &posisi, &bidang, &lokasi, &gaji, &mulai, &selesai,
+		&status, &deskripsi, &isDeleted, &deletedAt, &deletedBy, &rec.createdAt, &rec.updatedAt); err != nil {
+		return nil, err
+	}
+
+	alumniRef, ok := s.ref("alumni", alumniID)
+	if !ok {
+		rec.skip = fmt.Sprintf("alumni id %d belum tersinkron", alumniID)
+		return &rec, nil
+	}
+
+	rec.fields = bson.M{
+		"alumni_id":             alumniRef,
+		"nama_perusahaan":       perusahaan,
+		"posisi_jabatan":        posisi,
+		"bidang_industri":       bidang,
+		"lokasi_kerja":          lokasi,
+		"gaji_range":            nullString(gaji),
+		"tanggal_mulai_kerja":   nil,
+		"tanggal_selesai_kerja": nil,
+		"status_pekerjaan":      status,
+		"deskripsi_pekerjaan":   nullString(deskripsi),
+		"is_deleted":            isDeleted,
+		"deleted_at":            nil,
+		"deleted_by":            nil,
+	}
+
+	// Tanggal disimpan sebagai BSON date seperti migrasi pekerjaan_dates_to_date.
+	// Tanggal yang tidak bisa dibaca tidak membuat baris dilewati: nilai aslinya
+	// disimpan di tanggal_asli dan dicatat di laporan untuk diperbaiki manual.
+	original := bson.M{}
+	var notes []string
+	dates := []struct {
+		field, backup string
+		value         sql.NullString
+	}{
+		{"tanggal_mulai_kerja", "mulai", sql.NullString{String: mulai, Valid: true}},
+		{"tanggal_selesai_kerja", "selesai", selesai},
+	}
+	for _, d := range dates {
+		if !d.value.Valid || strings.TrimSpace(d.value.String) == "" {
+			continue
+		}
+		t, err := helper.ParseTanggal(d.value.String)
+		if err != nil {
+			original[d.backup] = d.value.String
+			notes = append(notes, fmt.Sprintf("%s %q tidak dikenali, disimpan di tanggal_asli.%s", d.field, d.value.String, d.backup))
+			continue
+		}
+		rec.fields[d.field] = t
+	}
+	if len(original) > 0 {
+		rec.fields["tanggal_asli"] = original
+		rec.note = strings.Join(notes, "; ")
+	}
+	if deletedAt.Valid {
+		rec.fields["deleted_at"] = deletedAt.Time
+	}
+	if deletedBy.Valid {
+		if id, ok := s.ref("users", int(deletedBy.Int64)); ok {
+			rec.fields["deleted_by"] = id
+		}
+	}
+	rec.natural = bson.M{
+		"alumni_id":           alumniRef,
+		"nama_perusahaan":     perusahaan,
+		"posisi_jabatan":      posisi,
+		"tanggal_mulai_kerja": bson.M{"$in": storedDateForms(rec.fields["tanggal_mulai_kerja"], mulai)},
+	}
+	return &rec, nil
+}
+
+// storedDateForms mengembalikan semua bentuk tanggal yang mungkin tersimpan di
+// data lama: impor ini berjalan sebelum pekerjaan_dates_to_date, sehingga
+// tanggal bisa masih berupa string asli atau hasil normalize_pekerjaan_dates.
+func storedDateForms(parsed interface{}, raw string) bson.A {
+	forms := bson.A{raw}
+	if t, ok := parsed.(time.Time); ok {
+		forms = append(forms, t, t.Format(helper.DateLayout))
+	} else {
+		forms = append(forms, nil)
+	}
+	return forms
+}
+
+func nullString(v sql.NullString) interface{} {
+	if v.Valid {
+		return v.String
+	}
+	return nil
+}
+
+// sameValue membandingkan nilai dari Postgres dengan nilai hasil decode BSON
+// (int32/int64, primitive.DateTime) tanpa terpengaruh perbedaan tipe
+func sameValue(mongoValue, legacyValue interface{}) bool {
+	if mongoValue == nil || legacyValue == nil {
+		return mongoValue == nil && legacyValue == nil
+	}
+	switch v := legacyValue.(type) {
+	case int:
+		switch m := mongoValue.(type) {
+		case int32:
+			return int(m) == v
+		case int64:
+			return int(m) == v
+		case float64:
+			return m == float64(v)
+		}
+		return false
+	case time.Time:
+		// BSON menyimpan waktu dalam presisi milidetik
+		return timeValue(mongoValue).Equal(v.Truncate(time.Millisecond))
+	case bson.M:
+		m := documentValue(mongoValue)
+		if m == nil || len(m) != len(v) {
+			return false
+		}
+		for k, lv := range v {
+			mv, ok := m[k]
+			if !ok || !sameValue(mv, lv) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(mongoValue, legacyValue)
+}
+
+func timeValue(v interface{}) time.Time {
+	switch t := v.(type) {
+	case primitive.DateTime:
+		return t.Time()
+	case time.Time:
+		return t
+	}
+	return time.Time{}
+}
+
+// documentValue menyeragamkan sub-dokumen hasil decode (bson.M atau bson.D)
+func documentValue(v interface{}) bson.M {
+	switch d := v.(type) {
+	case bson.M:
+		return d
+	case bson.D:
+		m := make(bson.M, len(d))
+		for _, e := range d {
+			m[e.Key] = e.Value
+		}
+		return m
+	}
+	return nil
+}
+
+func sortedKeys(m bson.M) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
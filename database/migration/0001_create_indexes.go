@@ -0,0 +1,60 @@
+package migration
+
+import (
+	"alumni-crud-api/database"
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// createdIndexes adalah index yang dibuat repository (lihat EnsureIndexes
+// masing-masing), dipakai untuk rollback
+var createdIndexes = map[string][]string{
+	"users":            {"uniq_username", "uniq_email", "uniq_oidc_subject"},
+	"alumni":           {"uniq_nim", "uniq_email", "idx_user_id", "uniq_user_id"},
+	"pekerjaan_alumni": {"idx_alumni_deleted", "idx_deleted_at"},
+	"files":            {"idx_alumni", "idx_file_name"},
+	"refresh_tokens":   {"uniq_token_hash", "idx_family_id", "idx_user_id", "ttl_expires_at"},
+	"revoked_tokens":   {"uniq_jti", "ttl_expires_at"},
+	"password_resets":  {"uniq_token_hash", "idx_user_id", "ttl_expires_at"},
+	"login_attempts":   {"idx_user_created", "idx_identifier_created", "idx_ip_created"},
+	"roles":            {"uniq_name"},
+	"api_keys":         {"uniq_key_hash", "uniq_prefix"},
+	"oidc_states":      {"uniq_state_hash", "ttl_expires_at"},
+	"alumni_claims":    {"idx_alumni_user", "ttl_expires_at"},
+	"pending_changes":  {"uniq_pending_entity", "idx_status_created", "idx_submitted_by"},
+	"audit_log":        {"idx_created_at", "idx_actor", "idx_entity"},
+	"record_versions":  {"uniq_entity_version"},
+}
+
+// createIndexes membuat index semua koleksi. Berbeda dengan startup server,
+// migrasi ini gagal jika masih ada data duplikat yang menghalangi unique index.
+var createIndexes = Migration{
+	Version: 1,
+	Name:    "create_indexes",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		return database.EnsureIndexesStrict(database.IndexTargets(db))
+	},
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		for collection, names := range createdIndexes {
+			for _, name := range names {
+				_, err := db.Collection(collection).Indexes().DropOne(ctx, name)
+				if err != nil && !isNotFound(err) {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// isNotFound bernilai true jika index/koleksi memang belum ada
+// (IndexNotFound = 27, NamespaceNotFound = 26)
+func isNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == 26 || cmdErr.Code == 27
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// versionedCollections memakai field version untuk ETag/If-Match
+var versionedCollections = []string{"alumni", "pekerjaan_alumni"}
+
+// backfillVersion mengisi version = 1 pada dokumen lama yang belum punya
+// penghitung versi, agar ETag data lama sama dengan data baru.
+var backfillVersion = Migration{
+	Version: 2,
+	Name:    "backfill_version",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		for _, name := range versionedCollections {
+			_, err := db.Collection(name).UpdateMany(ctx,
+				bson.M{"version": bson.M{"$in": bson.A{0, nil}}},
+				bson.M{"$set": bson.M{"version": 1}},
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	// Tidak ada yang dikembalikan: version yang sudah diisi tetap valid dan
+	// menghapusnya akan membuat ETag yang dipegang client tidak cocok lagi
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		return nil
+	},
+}
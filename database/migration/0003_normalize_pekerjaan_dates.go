@@ -0,0 +1,115 @@
+package migration
+
+import (
+	"alumni-crud-api/helper"
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// isoDate cocok dengan tanggal yang sudah berformat YYYY-MM-DD
+var isoDate = primitive.Regex{Pattern: `^\d{4}-\d{2}-\d{2}$`}
+
+var dateFields = map[string]string{
+	"tanggal_mulai_kerja":   "mulai",
+	"tanggal_selesai_kerja": "selesai",
+}
+
+// normalizePekerjaanDates menyeragamkan tanggal pekerjaan yang ditulis bebas
+// (15/03/2021, 15 Maret 2021, ...) menjadi YYYY-MM-DD agar bisa diurutkan.
+// Nilai asli disimpan di tanggal_asli untuk rollback; nilai yang tidak bisa
+// dibaca dibiarkan dan dicatat di log untuk diperbaiki manual.
+var normalizePekerjaanDates = Migration{
+	Version: 3,
+	Name:    "normalize_pekerjaan_dates",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		coll := db.Collection("pekerjaan_alumni")
+		notISO := bson.M{"$type": "string", "$not": isoDate}
+		cursor, err := coll.Find(ctx, bson.M{"$or": bson.A{
+			bson.M{"tanggal_mulai_kerja": notISO},
+			bson.M{"tanggal_selesai_kerja": notISO},
+		}})
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		converted, failed := 0, 0
+		for cursor.Next(ctx) {
+			var doc bson.M
+			if err := cursor.Decode(&doc); err != nil {
+				return err
+			}
+
+			set := bson.M{}
+			for field, backup := range dateFields {
+				value, ok := doc[field].(string)
+				if !ok || value == "" {
+					continue
+				}
+				t, err := helper.ParseTanggal(value)
+				if err != nil {
+					log.Printf("  Peringatan: pekerjaan %v %s = %q tidak bisa dibaca, dilewati", doc["_id"], field, value)
+					failed++
+					continue
+				}
+				if normalized := t.Format(helper.DateLayout); normalized != value {
+					set[field] = normalized
+					set["tanggal_asli."+backup] = value
+				}
+			}
+			if len(set) == 0 {
+				continue
+			}
+			if _, err := coll.UpdateOne(ctx, bson.M{"_id": doc["_id"]}, bson.M{"$set": set}); err != nil {
+				return err
+			}
+			converted++
+		}
+		if err := cursor.Err(); err != nil {
+			return err
+		}
+		log.Printf("  -> %d pekerjaan diseragamkan, %d tanggal tidak dikenali", converted, failed)
+		return nil
+	},
+	// Nilai asli hanya dikembalikan jika tanggal belum diubah lagi sejak migrasi
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		coll := db.Collection("pekerjaan_alumni")
+		cursor, err := coll.Find(ctx, bson.M{"tanggal_asli": bson.M{"$exists": true}})
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		for cursor.Next(ctx) {
+			var doc bson.M
+			if err := cursor.Decode(&doc); err != nil {
+				return err
+			}
+			original, _ := doc["tanggal_asli"].(bson.M)
+
+			set := bson.M{}
+			for field, backup := range dateFields {
+				value, ok := original[backup].(string)
+				if !ok {
+					continue
+				}
+				current, _ := doc[field].(string)
+				if t, err := helper.ParseTanggal(value); err == nil && t.Format(helper.DateLayout) == current {
+					set[field] = value
+				}
+			}
+			update := bson.M{"$unset": bson.M{"tanggal_asli": ""}}
+			if len(set) > 0 {
+				update["$set"] = set
+			}
+			if _, err := coll.UpdateOne(ctx, bson.M{"_id": doc["_id"]}, update); err != nil {
+				return err
+			}
+		}
+		return cursor.Err()
+	},
+}
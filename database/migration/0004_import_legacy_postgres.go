@@ -0,0 +1,36 @@
+package migration
+
+import (
+	"alumni-crud-api/database/legacy"
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// importLegacyPostgres menjalankan sinkronisasi pertama dari PostgreSQL
+// sistem lama (lihat package legacy). Migrasi ini opsional
+// (-with import_legacy_postgres); sinkronisasi berikutnya selama masa transisi
+// dijalankan dengan perintah sync-legacy.
+//
+// Tidak bisa di-rollback karena data hasil impor mungkin sudah diubah atau
+// direferensikan data lain di sistem baru.
+func importLegacyPostgres(dsn string) Migration {
+	return Migration{
+		Version:  4,
+		Name:     "import_legacy_postgres",
+		Optional: true,
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			pg, err := legacy.Open(ctx, dsn)
+			if err != nil {
+				return err
+			}
+			defer pg.Close()
+
+			report, err := legacy.Sync(ctx, pg, db, legacy.Options{})
+			if report != nil {
+				legacy.LogSummary(report)
+			}
+			return err
+		},
+	}
+}
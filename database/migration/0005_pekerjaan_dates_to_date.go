@@ -0,0 +1,130 @@
+package migration
+
+import (
+	"alumni-crud-api/helper"
+	"context"
+	"log"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// pekerjaanDatesToDate mengubah tanggal pekerjaan dari string menjadi BSON
+// date agar urutan dan perbandingan tanggal di query benar. Semua format yang
+// diterima helper.ParseTanggal dibaca, termasuk string bebas yang masuk lewat
+// API setelah normalize_pekerjaan_dates. Nilai asli yang bukan YYYY-MM-DD
+// disimpan di tanggal_asli (jika belum ada) seperti migrasi tersebut; nilai
+// yang tidak bisa dibaca dibiarkan dan dicatat di log.
+//
+// Rollback menulis ulang tanggal sebagai string YYYY-MM-DD, yaitu bentuk
+// setelah normalize_pekerjaan_dates, sehingga rollback migrasi itu tetap bisa
+// mengembalikan nilai asli dari tanggal_asli.
+var pekerjaanDatesToDate = Migration{
+	Version: 5,
+	Name:    "pekerjaan_dates_to_date",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		coll := db.Collection("pekerjaan_alumni")
+		cursor, err := coll.Find(ctx, bson.M{"$or": bson.A{
+			bson.M{"tanggal_mulai_kerja": bson.M{"$type": "string"}},
+			bson.M{"tanggal_selesai_kerja": bson.M{"$type": "string"}},
+		}})
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		converted, failed := 0, 0
+		for cursor.Next(ctx) {
+			var doc bson.M
+			if err := cursor.Decode(&doc); err != nil {
+				return err
+			}
+			original, _ := doc["tanggal_asli"].(bson.M)
+
+			set, unset := bson.M{}, bson.M{}
+			for field, backup := range dateFields {
+				value, ok := doc[field].(string)
+				if !ok {
+					continue
+				}
+				// Tanggal selesai kosong berarti pekerjaan masih berjalan
+				if strings.TrimSpace(value) == "" && field == "tanggal_selesai_kerja" {
+					unset[field] = ""
+					continue
+				}
+				t, err := helper.ParseTanggal(value)
+				if err != nil {
+					log.Printf("  Peringatan: pekerjaan %v %s = %q tidak bisa dibaca, dilewati", doc["_id"], field, value)
+					failed++
+					continue
+				}
+				set[field] = t
+				if _, saved := original[backup]; !saved && t.Format(helper.DateLayout) != value {
+					set["tanggal_asli."+backup] = value
+				}
+			}
+			if len(set) == 0 && len(unset) == 0 {
+				continue
+			}
+			update := bson.M{}
+			if len(set) > 0 {
+				update["$set"] = set
+			}
+			if len(unset) > 0 {
+				update["$unset"] = unset
+			}
+			if _, err := coll.UpdateOne(ctx, bson.M{"_id": doc["_id"]}, update); err != nil {
+				return err
+			}
+			converted++
+		}
+		if err := cursor.Err(); err != nil {
+			return err
+		}
+		log.Printf("  -> %d pekerjaan dikonversi, %d tanggal tidak dikenali", converted, failed)
+
+		// Data lama tidak diubah, hanya dilaporkan untuk diperbaiki manual
+		reversed, err := coll.CountDocuments(ctx, bson.M{
+			"tanggal_selesai_kerja": bson.M{"$type": "date"},
+			"$expr":                 bson.M{"$lt": bson.A{"$tanggal_selesai_kerja", "$tanggal_mulai_kerja"}},
+		})
+		if err != nil {
+			return err
+		}
+		if reversed > 0 {
+			log.Printf("  Peringatan: %d pekerjaan memiliki tanggal selesai sebelum tanggal mulai", reversed)
+		}
+		return nil
+	},
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		coll := db.Collection("pekerjaan_alumni")
+		cursor, err := coll.Find(ctx, bson.M{"$or": bson.A{
+			bson.M{"tanggal_mulai_kerja": bson.M{"$type": "date"}},
+			bson.M{"tanggal_selesai_kerja": bson.M{"$type": "date"}},
+		}})
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		for cursor.Next(ctx) {
+			var doc bson.M
+			if err := cursor.Decode(&doc); err != nil {
+				return err
+			}
+
+			set := bson.M{}
+			for field := range dateFields {
+				if value, ok := doc[field].(primitive.DateTime); ok {
+					set[field] = value.Time().UTC().Format(helper.DateLayout)
+				}
+			}
+			if _, err := coll.UpdateOne(ctx, bson.M{"_id": doc["_id"]}, bson.M{"$set": set}); err != nil {
+				return err
+			}
+		}
+		return cursor.Err()
+	},
+}
@@ -0,0 +1,85 @@
+package migration
+
+import (
+	"alumni-crud-api/app/repository"
+	"alumni-crud-api/database"
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// userUniqueIndexes adalah unique index users yang dibuat ulang dengan
+// collation tidak peka huruf besar/kecil
+var userUniqueIndexes = map[string]string{
+	"uniq_username": "username",
+	"uniq_email":    "email",
+}
+
+// usersCaseInsensitiveIndexes membuat ulang unique index username dan email
+// users dengan collation strength 2 (sama dengan uniq_email alumni) dan
+// menyeragamkan email lama ke huruf kecil. Index lama dengan nama yang sama
+// tidak bisa diubah opsinya, jadi dihapus lebih dulu. Migrasi gagal sebelum
+// mengubah apa pun jika masih ada username/email yang hanya berbeda huruf
+// besar/kecil.
+//
+// Migrasi ini melengkapi pencocokan username/email tanpa membedakan huruf
+// besar/kecil pada manajemen user. Database baru sudah mendapat index ber-
+// collation dari create_indexes; database yang index users-nya dibuat sebelum
+// perubahan itu hanya mendapat peringatan "gagal membuat index users" saat
+// startup sampai migrasi ini dijalankan. Nomornya 6 karena versi migrasi hanya
+// boleh ditambah di akhir, bukan karena bergantung pada migrasi 2-5.
+var usersCaseInsensitiveIndexes = Migration{
+	Version: 6,
+	Name:    "users_case_insensitive_indexes",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		authRepo := repository.NewAuthRepository(db)
+		groups, err := authRepo.FindDuplicates()
+		if err != nil {
+			return err
+		}
+		if len(groups) > 0 {
+			database.ReportDuplicates("users", groups)
+			return fmt.Errorf("%d nilai duplikat di users harus dibereskan dulu", len(groups))
+		}
+
+		coll := db.Collection("users")
+		if err := dropUserUniqueIndexes(ctx, coll); err != nil {
+			return err
+		}
+		_, err = coll.UpdateMany(ctx, bson.M{"email": bson.M{"$type": "string"}}, mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"email": bson.M{"$toLower": "$email"}}}},
+		})
+		if err != nil {
+			return err
+		}
+		return authRepo.EnsureIndexes()
+	},
+	// Email yang sudah diseragamkan tidak dikembalikan ke bentuk aslinya
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		coll := db.Collection("users")
+		if err := dropUserUniqueIndexes(ctx, coll); err != nil {
+			return err
+		}
+		models := make([]mongo.IndexModel, 0, len(userUniqueIndexes))
+		for name, field := range userUniqueIndexes {
+			models = append(models, mongo.IndexModel{
+				Keys:    bson.D{{Key: field, Value: 1}},
+				Options: options.Index().SetUnique(true).SetName(name),
+			})
+		}
+		_, err := coll.Indexes().CreateMany(ctx, models)
+		return err
+	},
+}
+
+func dropUserUniqueIndexes(ctx context.Context, coll *mongo.Collection) error {
+	for name := range userUniqueIndexes {
+		if _, err := coll.Indexes().DropOne(ctx, name); err != nil && !isNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package migration
+
+import (
+	"alumni-crud-api/app/repository"
+	"alumni-crud-api/database"
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// alumniUniqueUserID mengganti index user_id alumni (idx_user_id) dengan
+// partial unique index uniq_user_id agar satu user hanya bisa terhubung ke
+// satu data alumni. Migrasi gagal sebelum mengubah apa pun jika masih ada
+// user yang terhubung ke lebih dari satu alumni.
+var alumniUniqueUserID = Migration{
+	Version: 7,
+	Name:    "alumni_unique_user_id",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		alumniRepo := repository.NewAlumniRepository(db)
+		groups, err := alumniRepo.FindDuplicates()
+		if err != nil {
+			return err
+		}
+		if len(groups) > 0 {
+			database.ReportDuplicates("alumni", groups)
+			return fmt.Errorf("%d nilai duplikat di alumni harus dibereskan dulu", len(groups))
+		}
+
+		coll := db.Collection("alumni")
+		if _, err := coll.Indexes().DropOne(ctx, "idx_user_id"); err != nil && !isNotFound(err) {
+			return err
+		}
+		return alumniRepo.EnsureIndexes()
+	},
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		coll := db.Collection("alumni")
+		if _, err := coll.Indexes().DropOne(ctx, "uniq_user_id"); err != nil && !isNotFound(err) {
+			return err
+		}
+		_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("idx_user_id"),
+		})
+		return err
+	},
+}
@@ -0,0 +1,182 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection menyimpan daftar migrasi yang sudah dijalankan
+const Collection = "schema_migrations"
+
+// Migration adalah satu perubahan skema/data bernomor. Up dan Down harus
+// idempotent: aman dijalankan ulang jika sebelumnya gagal di tengah jalan.
+// Down nil berarti migrasi tidak bisa di-rollback.
+type Migration struct {
+	Version  int
+	Name     string
+	Optional bool // Hanya dijalankan jika diminta eksplisit (-with)
+	Up       func(ctx context.Context, db *mongo.Database) error
+	Down     func(ctx context.Context, db *mongo.Database) error
+}
+
+// Applied adalah catatan migrasi di koleksi schema_migrations
+type Applied struct {
+	Version    int       `bson:"_id"`
+	Name       string    `bson:"name"`
+	AppliedAt  time.Time `bson:"applied_at"`
+	DurationMs int64     `bson:"duration_ms"`
+}
+
+// Status adalah kondisi satu migrasi untuk perintah status
+type Status struct {
+	Migration Migration
+	Applied   *Applied
+}
+
+// Migrator menjalankan migrasi terdaftar terhadap satu database
+type Migrator struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+	migrations []Migration
+}
+
+func NewMigrator(db *mongo.Database, migrations []Migration) (*Migrator, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, m := range sorted {
+		if m.Version <= 0 || m.Name == "" || m.Up == nil {
+			return nil, fmt.Errorf("migrasi %d (%s) tidak lengkap", m.Version, m.Name)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("versi migrasi %d terdaftar dua kali", m.Version)
+		}
+	}
+	return &Migrator{db: db, collection: db.Collection(Collection), migrations: sorted}, nil
+}
+
+// Find mencari migrasi berdasarkan nama
+func (m *Migrator) Find(name string) (Migration, bool) {
+	for _, mig := range m.migrations {
+		if mig.Name == name {
+			return mig, true
+		}
+	}
+	return Migration{}, false
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]*Applied, error) {
+	cursor, err := m.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	result := make(map[int]*Applied)
+	for cursor.Next(ctx) {
+		var a Applied
+		if err := cursor.Decode(&a); err != nil {
+			return nil, err
+		}
+		result[a.Version] = &a
+	}
+	return result, cursor.Err()
+}
+
+// Status mengembalikan semua migrasi terdaftar beserta waktu dijalankannya
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		result = append(result, Status{Migration: mig, Applied: applied[mig.Version]})
+	}
+	return result, nil
+}
+
+// Up menjalankan migrasi yang belum diterapkan secara berurutan sampai versi
+// target (0 = semua). Migrasi opsional dilewati kecuali namanya ada di include.
+func (m *Migrator) Up(ctx context.Context, target int, include map[string]bool) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, mig := range m.migrations {
+		if target > 0 && mig.Version > target {
+			break
+		}
+		if applied[mig.Version] != nil {
+			continue
+		}
+		if mig.Optional && !include[mig.Name] {
+			log.Printf("Lewati %04d_%s (opsional, aktifkan dengan -with %s)", mig.Version, mig.Name, mig.Name)
+			continue
+		}
+
+		log.Printf("Menjalankan %04d_%s...", mig.Version, mig.Name)
+		start := time.Now()
+		if err := mig.Up(ctx, m.db); err != nil {
+			return count, fmt.Errorf("migrasi %04d_%s gagal: %v", mig.Version, mig.Name, err)
+		}
+		record := Applied{
+			Version:    mig.Version,
+			Name:       mig.Name,
+			AppliedAt:  time.Now(),
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		opts := options.Replace().SetUpsert(true)
+		if _, err := m.collection.ReplaceOne(ctx, bson.M{"_id": mig.Version}, record, opts); err != nil {
+			return count, fmt.Errorf("migrasi %04d_%s selesai tetapi gagal dicatat: %v", mig.Version, mig.Name, err)
+		}
+		log.Printf("  -> selesai dalam %s", time.Since(start).Round(time.Millisecond))
+		count++
+	}
+	return count, nil
+}
+
+// Down me-rollback migrasi yang sudah diterapkan mulai dari versi terbaru,
+// sebanyak steps atau sampai tersisa migrasi dengan versi <= target.
+func (m *Migrator) Down(ctx context.Context, steps, target int) (int, error) {
+	if steps <= 0 && target < 0 {
+		return 0, errors.New("tentukan jumlah langkah atau versi target")
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if applied[mig.Version] == nil {
+			continue
+		}
+		if (steps > 0 && count >= steps) || (target >= 0 && mig.Version <= target) {
+			break
+		}
+		if mig.Down == nil {
+			return count, fmt.Errorf("migrasi %04d_%s tidak bisa di-rollback", mig.Version, mig.Name)
+		}
+
+		log.Printf("Rollback %04d_%s...", mig.Version, mig.Name)
+		if err := mig.Down(ctx, m.db); err != nil {
+			return count, fmt.Errorf("rollback %04d_%s gagal: %v", mig.Version, mig.Name, err)
+		}
+		if _, err := m.collection.DeleteOne(ctx, bson.M{"_id": mig.Version}); err != nil {
+			return count, fmt.Errorf("rollback %04d_%s selesai tetapi gagal dicatat: %v", mig.Version, mig.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
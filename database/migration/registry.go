@@ -0,0 +1,17 @@
+package migration
+
+import "alumni-crud-api/config"
+
+// All mengembalikan semua migrasi terdaftar. Migrasi baru ditambahkan di akhir
+// dengan nomor versi berikutnya; nomor yang sudah dipakai tidak boleh diubah.
+func All(cfg *config.Config) []Migration {
+	return []Migration{
+		createIndexes,
+		backfillVersion,
+		normalizePekerjaanDates,
+		importLegacyPostgres(cfg.PostgresDSN()),
+		pekerjaanDatesToDate,
+		usersCaseInsensitiveIndexes,
+		alumniUniqueUserID,
+	}
+}
@@ -0,0 +1,20 @@
+package helper
+
+import (
+	"alumni-crud-api/app/model"
+	"time"
+)
+
+// DateLayout adalah format tanggal baku yang disimpan di database
+const DateLayout = "2006-01-02"
+
+// ParseTanggal membaca tanggal dalam salah satu format yang diterima
+// (mis. 2021-03-15, 15/03/2021, 15 Maret 2021, Maret 2021) sebagai UTC.
+// Daftar format ada di model.ParseDate agar dipakai juga saat membaca BSON.
+func ParseTanggal(value string) (time.Time, error) {
+	d, err := model.ParseDate(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return d.Time, nil
+}
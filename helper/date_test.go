@@ -0,0 +1,51 @@
+package helper
+
+import "testing"
+
+func TestParseTanggal(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"iso", "2021-03-15", "2021-03-15", false},
+		{"rfc3339", "2021-03-15T23:30:00+07:00", "2021-03-15", false},
+		{"iso dengan jam", "2021-03-15 08:00:00", "2021-03-15", false},
+		{"spasi di tepi", "  2021-03-15 ", "2021-03-15", false},
+		{"hari dulu dengan garis miring", "05/03/2021", "2021-03-05", false},
+		{"hari dulu dengan strip", "05-03-2021", "2021-03-05", false},
+		{"hari dulu tanpa nol", "5/3/2021", "2021-03-05", false},
+		{"bulan dulu ditolak", "03/15/2021", "", true},
+		{"bulan indonesia", "15 Maret 2021", "2021-03-15", false},
+		{"bulan indonesia huruf kecil", "1 agustus 2020", "2020-08-01", false},
+		{"singkatan bulan indonesia", "17 Agu 2020", "2020-08-17", false},
+		{"bulan inggris", "15 March 2021", "2021-03-15", false},
+		{"hanya bulan indonesia", "Desember 2019", "2019-12-01", false},
+		{"hanya bulan angka", "07/2019", "2019-07-01", false},
+		{"hanya bulan iso", "2019-07", "2019-07-01", false},
+		{"kosong", "   ", "", true},
+		{"tidak dikenali", "awal tahun lalu", "", true},
+		{"tanggal tidak ada", "2021-02-30", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTanggal(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseTanggal(%q) = %v, want error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTanggal(%q) error: %v", tt.value, err)
+			}
+			if got.Format(DateLayout) != tt.want {
+				t.Errorf("ParseTanggal(%q) = %s, want %s", tt.value, got.Format(DateLayout), tt.want)
+			}
+			if got.Location().String() != "UTC" || got.Hour() != 0 {
+				t.Errorf("ParseTanggal(%q) = %v, want midnight UTC", tt.value, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package helper
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AnyVersion berarti perubahan tidak dibatasi versi (If-Match kosong atau "*"
+// selama REQUIRE_IF_MATCH tidak aktif)
+const AnyVersion = -1
+
+// ETag membentuk nilai header ETag dari nomor versi data
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+func SetETag(c *fiber.Ctx, version int) {
+	c.Set(fiber.HeaderETag, ETag(version))
+}
+
+// IfMatchVersion membaca versi yang diharapkan client dari header If-Match.
+// Jika required, header kosong maupun "*" ditolak karena keduanya tidak
+// menyebut versi; ETag yang bukan buatan server ini tidak akan pernah cocok
+// sehingga diperlakukan sebagai versi berbeda.
+func IfMatchVersion(c *fiber.Ctx, required bool) (int, error) {
+	value := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if value == "" || value == "*" {
+		if required {
+			return 0, errors.New("header If-Match wajib diisi")
+		}
+		return AnyVersion, nil
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(value, "W/"), `"`))
+	if err != nil || version < 0 {
+		return 0, errors.New("versi data tidak cocok")
+	}
+	return version, nil
+}
+
+// VersionMatches mengecek versi data saat ini terhadap If-Match
+func VersionMatches(current, expected int) bool {
+	return expected == AnyVersion || current == expected
+}
+
+// PreconditionErrorResponse memetakan error If-Match/versi ke 428 atau 412
+func PreconditionErrorResponse(c *fiber.Ctx, err error) error {
+	if err.Error() == "header If-Match wajib diisi" {
+		return ErrorResponse(c, fiber.StatusPreconditionRequired, "Header If-Match wajib diisi (gunakan ETag dari GET)")
+	}
+	return ErrorResponse(c, fiber.StatusPreconditionFailed, "Data telah diubah oleh pengguna lain, muat ulang lalu coba lagi")
+}
+
+// IsPreconditionError mengenali error dari IfMatchVersion dan update bersyarat versi
+func IsPreconditionError(err error) bool {
+	return err.Error() == "header If-Match wajib diisi" || err.Error() == "versi data tidak cocok"
+}
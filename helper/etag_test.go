@@ -0,0 +1,68 @@
+package helper
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	var got int
+	app := fiber.New()
+	app.Put("/", func(c *fiber.Ctx) error {
+		version, err := IfMatchVersion(c, c.Query("required") == "1")
+		if err != nil {
+			return PreconditionErrorResponse(c, err)
+		}
+		got = version
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	tests := []struct {
+		ifMatch     string
+		required    bool
+		wantStatus  int
+		wantVersion int
+	}{
+		{"", false, fiber.StatusNoContent, AnyVersion},
+		{"*", false, fiber.StatusNoContent, AnyVersion},
+		{"", true, fiber.StatusPreconditionRequired, 0},
+		// "*" tidak menyebut versi sehingga tidak memenuhi REQUIRE_IF_MATCH
+		{"*", true, fiber.StatusPreconditionRequired, 0},
+		{ETag(4), true, fiber.StatusNoContent, 4},
+		{` W/"4" `, false, fiber.StatusNoContent, 4},
+		{"7", false, fiber.StatusNoContent, 7},
+		// ETag dari server lain atau versi negatif tidak mungkin cocok
+		{`"abc"`, false, fiber.StatusPreconditionFailed, 0},
+		{`"-1"`, true, fiber.StatusPreconditionFailed, 0},
+	}
+	for _, tt := range tests {
+		got = 0
+		url := "/?required=0"
+		if tt.required {
+			url = "/?required=1"
+		}
+		req := httptest.NewRequest("PUT", url, nil)
+		if tt.ifMatch != "" {
+			req.Header.Set("If-Match", tt.ifMatch)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.wantStatus || got != tt.wantVersion {
+			t.Errorf("If-Match %q (required %v): status %d versi %d, ingin %d versi %d",
+				tt.ifMatch, tt.required, resp.StatusCode, got, tt.wantStatus, tt.wantVersion)
+		}
+	}
+}
+
+func TestVersionMatches(t *testing.T) {
+	if !VersionMatches(3, AnyVersion) {
+		t.Error("AnyVersion harus cocok dengan versi apa pun")
+	}
+	if !VersionMatches(3, 3) || VersionMatches(3, 2) {
+		t.Error("versi hanya cocok jika sama persis")
+	}
+}
@@ -2,40 +2,266 @@ package helper
 
 import (
 	"alumni-crud-api/app/model"
+	"alumni-crud-api/config"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-var jwtSecret = []byte("your-secret-key-min-32-characters-long-alumni-crud-api-2024")
+var (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// jwtKey adalah satu kunci penandatangan/verifikasi yang diidentifikasi dengan kid.
+// signKey nil berarti kunci hanya dipakai untuk verifikasi (kunci lama saat rotasi).
+type jwtKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+var (
+	jwtKeys      = map[string]*jwtKey{}
+	jwtActiveKey *jwtKey
+	jwtIssuer    string
+)
+
+// SetupJWT memuat konfigurasi penandatanganan JWT dari config.
+// Harus dipanggil sekali saat startup sebelum token diterbitkan/divalidasi.
+func SetupJWT(cfg *config.Config) error {
+	keys := map[string]*jwtKey{}
 
-func GenerateToken(user model.User) (string, error) {
-	claims := model.JWTClaims{
+	if cfg.JWTSecret != "" {
+		if len(cfg.JWTSecret) < 32 {
+			return errors.New("JWT_SECRET minimal 32 karakter")
+		}
+		keys[cfg.JWTSecretKID] = newHMACKey(cfg.JWTSecretKID, []byte(cfg.JWTSecret), true)
+	}
+	for kid, secret := range cfg.JWTPreviousSecrets {
+		if _, exists := keys[kid]; exists {
+			return fmt.Errorf("kid %q terdaftar lebih dari sekali", kid)
+		}
+		keys[kid] = newHMACKey(kid, []byte(secret), false)
+	}
+	for _, kid := range cfg.JWTKeyOrder {
+		if _, exists := keys[kid]; exists {
+			return fmt.Errorf("kid %q terdaftar lebih dari sekali", kid)
+		}
+		key, err := loadPEMKey(kid, cfg.JWTKeyFiles[kid])
+		if err != nil {
+			return err
+		}
+		keys[kid] = key
+	}
+
+	if len(keys) == 0 {
+		return errors.New("tidak ada kunci JWT: isi JWT_SECRET atau JWT_KEYS")
+	}
+
+	activeKID := cfg.JWTActiveKID
+	if activeKID == "" {
+		if len(cfg.JWTKeyOrder) > 0 {
+			activeKID = cfg.JWTKeyOrder[0]
+		} else {
+			activeKID = cfg.JWTSecretKID
+		}
+	}
+	active, ok := keys[activeKID]
+	if !ok {
+		return fmt.Errorf("JWT_ACTIVE_KID %q tidak ditemukan", activeKID)
+	}
+	if active.signKey == nil {
+		return fmt.Errorf("kunci %q hanya berisi public key, tidak bisa dipakai menandatangani", activeKID)
+	}
+
+	jwtKeys = keys
+	jwtActiveKey = active
+	jwtIssuer = cfg.JWTIssuer
+	AccessTokenTTL = cfg.JWTAccessTTL
+	RefreshTokenTTL = cfg.JWTRefreshTTL
+	return nil
+}
+
+func newHMACKey(kid string, secret []byte, canSign bool) *jwtKey {
+	key := &jwtKey{kid: kid, method: jwt.SigningMethodHS256, verifyKey: secret}
+	if canSign {
+		key.signKey = secret
+	}
+	return key
+}
+
+// loadPEMKey membaca private key (untuk signing) atau public key (verifikasi saja).
+// Algoritma ditentukan dari tipe kunci: RSA -> RS256, Ed25519 -> EdDSA.
+func loadPEMKey(kid, path string) (*jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca kunci JWT %q: %v", kid, err)
+	}
+
+	if priv, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		return &jwtKey{kid: kid, method: jwt.SigningMethodRS256, signKey: priv, verifyKey: &priv.PublicKey}, nil
+	}
+	if priv, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		edPriv := priv.(ed25519.PrivateKey)
+		return &jwtKey{kid: kid, method: jwt.SigningMethodEdDSA, signKey: edPriv, verifyKey: edPriv.Public()}, nil
+	}
+	if pub, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return &jwtKey{kid: kid, method: jwt.SigningMethodRS256, verifyKey: pub}, nil
+	}
+	if pub, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return &jwtKey{kid: kid, method: jwt.SigningMethodEdDSA, verifyKey: pub}, nil
+	}
+	return nil, fmt.Errorf("kunci JWT %q bukan RSA atau Ed25519 PEM yang valid", kid)
+}
+
+// GenerateToken membuat access token dengan jti unik dan mengembalikan claims-nya
+func GenerateToken(user model.User) (string, *model.JWTClaims, error) {
+	now := time.Now()
+	claims := &model.JWTClaims{
 		UserID:   user.ID.Hex(), // Ubah ObjectID ke string
 		Username: user.Username,
 		Role:     user.Role,
+		Scope:    user.Scope,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.New().String(),
+			Issuer:    jwtIssuer,
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	signed, err := SignClaims(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
-func ValidateToken(tokenString string) (*model.JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &model.JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+// GenerateMFAToken membuat token challenge berumur pendek untuk langkah kedua login.
+// Token ini ditolak oleh AuthRequired karena Purpose tidak kosong.
+func GenerateMFAToken(user model.User, purpose string, ttl time.Duration) (string, *model.JWTClaims, error) {
+	now := time.Now()
+	claims := &model.JWTClaims{
+		UserID:   user.ID.Hex(),
+		Username: user.Username,
+		Role:     user.Role,
+		Purpose:  purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    jwtIssuer,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
 
+	signed, err := SignClaims(claims)
 	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
+// SignClaims menandatangani claims dengan kunci aktif dan menambahkan header kid
+func SignClaims(claims jwt.Claims) (string, error) {
+	if jwtActiveKey == nil {
+		return "", errors.New("JWT belum dikonfigurasi")
+	}
+	token := jwt.NewWithClaims(jwtActiveKey.method, claims)
+	token.Header["kid"] = jwtActiveKey.kid
+	return token.SignedString(jwtActiveKey.signKey)
+}
+
+func ValidateToken(tokenString string) (*model.JWTClaims, error) {
+	claims := &model.JWTClaims{}
+	if err := ParseClaims(tokenString, claims); err != nil {
 		return nil, err
 	}
+	return claims, nil
+}
+
+// ParseClaims memverifikasi token dengan kunci sesuai header kid.
+// Algoritma token harus sama dengan algoritma kunci (mencegah alg confusion).
+func ParseClaims(tokenString string, claims jwt.Claims) error {
+	opts := []jwt.ParserOption{jwt.WithExpirationRequired()}
+	if jwtIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(jwtIssuer))
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		key := jwtActiveKey
+		if kid, ok := token.Header["kid"].(string); ok {
+			key = jwtKeys[kid]
+		}
+		if key == nil {
+			return nil, errors.New("kid tidak dikenal")
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("algoritma %s tidak sesuai dengan kunci", token.Method.Alg())
+		}
+		return key.verifyKey, nil
+	}, opts...)
+	if err != nil {
+		return err
+	}
+	if !token.Valid {
+		return jwt.ErrTokenInvalidClaims
+	}
+	return nil
+}
 
-	if claims, ok := token.Claims.(*model.JWTClaims); ok && token.Valid {
-		return claims, nil
+// JWKS mengembalikan public key yang aktif dalam format JSON Web Key Set (RFC 7517).
+// Kunci HMAC tidak pernah dipublikasikan.
+func JWKS() map[string]interface{} {
+	keys := []map[string]string{}
+	for _, key := range jwtKeys {
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"use": "sig",
+				"alg": key.method.Alg(),
+				"kid": key.kid,
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "OKP",
+				"crv": "Ed25519",
+				"use": "sig",
+				"alg": key.method.Alg(),
+				"kid": key.kid,
+				"x":   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
 	}
+	return map[string]interface{}{"keys": keys}
+}
+
+// GenerateOpaqueToken membuat token acak (base64url, 256 bit) untuk refresh token dsb.
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	return nil, jwt.ErrInvalidKey
+// HashToken mengembalikan SHA-256 (hex) dari token; hanya hash ini yang disimpan di database
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
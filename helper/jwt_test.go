@@ -0,0 +1,124 @@
+package helper
+
+import (
+	"alumni-crud-api/app/model"
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// setupTestJWT memasang kunci uji: hs-1 (HMAC aktif), hs-old (HMAC lama,
+// verifikasi saja) dan ed-1 (Ed25519)
+func setupTestJWT(t *testing.T) (activeSecret, oldSecret []byte, edPriv ed25519.PrivateKey) {
+	t.Helper()
+	prevKeys, prevActive, prevIssuer := jwtKeys, jwtActiveKey, jwtIssuer
+	t.Cleanup(func() { jwtKeys, jwtActiveKey, jwtIssuer = prevKeys, prevActive, prevIssuer })
+
+	activeSecret = []byte("secret-aktif-minimal-32-karakter!!")
+	oldSecret = []byte("secret-lama-minimal-32-karakter!!!")
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active := newHMACKey("hs-1", activeSecret, true)
+	jwtKeys = map[string]*jwtKey{
+		"hs-1":   active,
+		"hs-old": newHMACKey("hs-old", oldSecret, false),
+		"ed-1":   {kid: "ed-1", method: jwt.SigningMethodEdDSA, signKey: edPriv, verifyKey: edPub},
+	}
+	jwtActiveKey = active
+	jwtIssuer = "alumni-test"
+	return activeSecret, oldSecret, edPriv
+}
+
+func TestParseClaims(t *testing.T) {
+	activeSecret, oldSecret, edPriv := setupTestJWT(t)
+
+	claims := func(issuer string, exp time.Duration) *model.JWTClaims {
+		c := &model.JWTClaims{UserID: "u1", Username: "budi", Role: "user"}
+		c.Issuer = issuer
+		if exp != 0 {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(exp))
+		}
+		return c
+	}
+	sign := func(method jwt.SigningMethod, kid string, key interface{}, c *model.JWTClaims) string {
+		token := jwt.NewWithClaims(method, c)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	valid := claims("alumni-test", time.Minute)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string // Potongan pesan error; "-" berarti error apa pun
+	}{
+		{"kunci aktif", sign(jwt.SigningMethodHS256, "hs-1", activeSecret, valid), ""},
+		{"tanpa kid memakai kunci aktif", sign(jwt.SigningMethodHS256, "", activeSecret, valid), ""},
+		{"kunci lama saat rotasi", sign(jwt.SigningMethodHS256, "hs-old", oldSecret, valid), ""},
+		{"ed25519", sign(jwt.SigningMethodEdDSA, "ed-1", edPriv, valid), ""},
+		{"kid tidak dikenal", sign(jwt.SigningMethodHS256, "hs-2", activeSecret, valid), "kid tidak dikenal"},
+		{"kid benar dengan secret kid lain", sign(jwt.SigningMethodHS256, "hs-old", activeSecret, valid), "-"},
+		{"alg tidak sesuai kunci", sign(jwt.SigningMethodHS256, "ed-1", []byte(edPriv.Public().(ed25519.PublicKey)), valid), "tidak sesuai dengan kunci"},
+		{"alg HS384 untuk kunci HS256", sign(jwt.SigningMethodHS384, "hs-1", activeSecret, valid), "tidak sesuai dengan kunci"},
+		{"alg none", sign(jwt.SigningMethodNone, "hs-1", jwt.UnsafeAllowNoneSignatureType, valid), "-"},
+		{"kedaluwarsa", sign(jwt.SigningMethodHS256, "hs-1", activeSecret, claims("alumni-test", -time.Minute)), "-"},
+		{"tanpa exp", sign(jwt.SigningMethodHS256, "hs-1", activeSecret, claims("alumni-test", 0)), "-"},
+		{"issuer lain", sign(jwt.SigningMethodHS256, "hs-1", activeSecret, claims("sistem-lain", time.Minute)), "-"},
+		{"bukan jwt", "bukan.token.jwt", "-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &model.JWTClaims{}
+			err := ParseClaims(tt.token, got)
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("ParseClaims berhasil, want error")
+				}
+				if tt.wantErr != "-" && !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseClaims error: %v", err)
+			}
+			if got.UserID != "u1" || got.Username != "budi" {
+				t.Errorf("claims = %+v", got)
+			}
+		})
+	}
+}
+
+func TestSignClaimsUsesActiveKID(t *testing.T) {
+	setupTestJWT(t)
+	token, claims, err := GenerateToken(model.User{Username: "budi", Role: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &model.JWTClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := parsed.Header["kid"]; kid != "hs-1" {
+		t.Errorf("kid = %v, want hs-1", kid)
+	}
+	got, err := ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken error: %v", err)
+	}
+	if got.ID != claims.ID {
+		t.Errorf("jti = %s, want %s", got.ID, claims.ID)
+	}
+}
@@ -0,0 +1,98 @@
+package helper
+
+import (
+	"alumni-crud-api/config"
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mailer adalah abstraksi pengiriman email agar service tidak bergantung pada SMTP
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// NewMailer memilih implementasi Mailer berdasarkan MAIL_DRIVER
+func NewMailer(cfg *config.Config) Mailer {
+	if cfg.MailDriver == "smtp" {
+		return &SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		}
+	}
+	return &LogMailer{Path: cfg.MailLogPath, From: cfg.MailFrom}
+}
+
+// SMTPMailer mengirim email sungguhan melalui server SMTP (STARTTLS jika didukung server)
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	if m.Host == "" {
+		return fmt.Errorf("SMTP_HOST belum dikonfigurasi")
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	msg := buildMessage(m.From, to, subject, body)
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{to}, []byte(msg))
+}
+
+// LogMailer menulis email ke file (atau log jika Path kosong).
+// Dipakai untuk development lokal dan pengujian.
+type LogMailer struct {
+	Path string
+	From string
+	mu   sync.Mutex
+}
+
+func (m *LogMailer) Send(to, subject, body string) error {
+	msg := buildMessage(m.From, to, subject, body)
+
+	if m.Path == "" {
+		log.Printf("[MAIL]\n%s", msg)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(m.Path), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(m.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "----- %s -----\n%s\n\n", time.Now().Format(time.RFC3339), msg)
+	return err
+}
+
+func buildMessage(from, to, subject, body string) string {
+	// Cegah header injection lewat CR/LF
+	clean := strings.NewReplacer("\r", "", "\n", "")
+	return "From: " + clean.Replace(from) + "\r\n" +
+		"To: " + clean.Replace(to) + "\r\n" +
+		"Subject: " + clean.Replace(subject) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+}
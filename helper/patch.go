@@ -0,0 +1,316 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json" // RFC 7396
+	JSONPatchContentType  = "application/json-patch+json"  // RFC 6902
+)
+
+// AcceptPatch adalah nilai header Accept-Patch untuk endpoint PATCH
+const AcceptPatch = MergePatchContentType + ", " + JSONPatchContentType
+
+// ApplyPatch menerapkan patch ke dokumen JSON sesuai Content-Type request.
+// "application/json" diperlakukan sebagai merge patch.
+func ApplyPatch(doc, patch []byte, contentType string) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.TrimSpace(contentType)
+	}
+
+	switch strings.ToLower(mediaType) {
+	case MergePatchContentType, "application/json":
+		return ApplyMergePatch(doc, patch)
+	case JSONPatchContentType:
+		return ApplyJSONPatch(doc, patch)
+	default:
+		return nil, fmt.Errorf("content type %q tidak didukung untuk PATCH", mediaType)
+	}
+}
+
+// ApplyMergePatch menerapkan JSON Merge Patch (RFC 7396): nilai null menghapus
+// field, object digabung secara rekursif, nilai lain menggantikan isi lama.
+func ApplyMergePatch(doc, patch []byte) ([]byte, error) {
+	var target, p interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("dokumen tidak valid: %v", err)
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("patch tidak valid: %v", err)
+	}
+	return json.Marshal(mergePatch(target, p))
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = mergePatch(t[key], value)
+		}
+	}
+	return t
+}
+
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ApplyJSONPatch menerapkan JSON Patch (RFC 6902) secara berurutan. Jika satu
+// operasi gagal, seluruh patch dibatalkan. Operasi "test" yang tidak cocok
+// menghasilkan error berawalan "operasi test gagal".
+func ApplyJSONPatch(doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("dokumen tidak valid: %v", err)
+	}
+	var ops []jsonPatchOp
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("patch tidak valid: harus berupa array operasi (%v)", err)
+	}
+
+	for i, op := range ops {
+		var err error
+		if target, err = applyPatchOp(target, op); err != nil {
+			return nil, fmt.Errorf("%v (operasi ke-%d)", err, i)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func applyPatchOp(doc interface{}, op jsonPatchOp) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("patch tidak valid: operasi %s membutuhkan value", op.Op)
+		}
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("patch tidak valid: %v", err)
+		}
+		switch op.Op {
+		case "add":
+			return pointerAdd(doc, path, value)
+		case "replace":
+			if len(path) == 0 {
+				return value, nil
+			}
+			if doc, err = pointerRemove(doc, path); err != nil {
+				return nil, err
+			}
+			return pointerAdd(doc, path, value)
+		default:
+			current, err := pointerGet(doc, path)
+			if err != nil || !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("operasi test gagal: nilai %s tidak sesuai", op.Path)
+			}
+			return doc, nil
+		}
+
+	case "remove":
+		return pointerRemove(doc, path)
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+				return nil, fmt.Errorf("patch tidak valid: tidak bisa memindahkan %s ke dalam dirinya sendiri", op.From)
+			}
+			if doc, err = pointerRemove(doc, from); err != nil {
+				return nil, err
+			}
+		} else if value, err = deepCopyJSON(value); err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, value)
+	}
+	return nil, fmt.Errorf("patch tidak valid: operasi %q tidak dikenal", op.Op)
+}
+
+// parsePointer memecah JSON Pointer (RFC 6901) menjadi token
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("patch tidak valid: path %q harus diawali \"/\"", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if allowEnd && token == "-" {
+		return length, nil
+	}
+	idx, err := strconv.Atoi(token)
+	max := length - 1
+	if allowEnd {
+		max = length
+	}
+	if err != nil || idx < 0 || idx > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("patch tidak valid: index array %q di luar jangkauan", token)
+	}
+	return idx, nil
+}
+
+func pointerGet(doc interface{}, path []string) (interface{}, error) {
+	node := doc
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			value, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("patch tidak valid: path /%s tidak ditemukan", strings.Join(path, "/"))
+			}
+			node = value
+		case []interface{}:
+			idx, err := arrayIndex(token, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[idx]
+		default:
+			return nil, fmt.Errorf("patch tidak valid: path /%s tidak ditemukan", strings.Join(path, "/"))
+		}
+	}
+	return node, nil
+}
+
+// pointerUpdate menjalankan fn pada container induk dari token terakhir dan
+// menyimpan hasilnya kembali ke dokumen (array bisa berganti slice)
+func pointerUpdate(doc interface{}, path []string, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	switch n := doc.(type) {
+	case map[string]interface{}:
+		child, ok := n[path[0]]
+		if !ok {
+			return nil, errors.New("patch tidak valid: path induk tidak ditemukan")
+		}
+		updated, err := pointerUpdate(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[path[0]] = updated
+		return n, nil
+	case []interface{}:
+		idx, err := arrayIndex(path[0], len(n), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := pointerUpdate(n[idx], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[idx] = updated
+		return n, nil
+	}
+	return nil, errors.New("patch tidak valid: path induk tidak ditemukan")
+}
+
+func pointerAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return pointerUpdate(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch n := parent.(type) {
+		case map[string]interface{}:
+			n[token] = value
+			return n, nil
+		case []interface{}:
+			idx, err := arrayIndex(token, len(n), true)
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[idx+1:], n[idx:])
+			n[idx] = value
+			return n, nil
+		}
+		return nil, errors.New("patch tidak valid: path induk bukan object atau array")
+	})
+}
+
+func pointerRemove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, errors.New("patch tidak valid: dokumen utama tidak bisa dihapus")
+	}
+	return pointerUpdate(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch n := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := n[token]; !ok {
+				return nil, fmt.Errorf("patch tidak valid: path /%s tidak ditemukan", strings.Join(path, "/"))
+			}
+			delete(n, token)
+			return n, nil
+		case []interface{}:
+			idx, err := arrayIndex(token, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(n[:idx], n[idx+1:]...), nil
+		}
+		return nil, errors.New("patch tidak valid: path induk bukan object atau array")
+	})
+}
+
+func deepCopyJSON(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(raw, &out)
+	return out, err
+}
+
+// DecodeStrict mengisi out dari JSON dan menolak field yang tidak dikenal,
+// sehingga patch tidak bisa diam-diam mengubah field yang tidak boleh diubah
+func DecodeStrict(data []byte, out interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return fmt.Errorf("field %s tidak dapat diubah", field)
+		}
+		return fmt.Errorf("patch tidak valid: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package helper
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// assertJSONEqual membandingkan dua dokumen JSON tanpa memperhatikan urutan key
+func assertJSONEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("hasil bukan JSON valid: %s", got)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("want bukan JSON valid: %s", want)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("hasil = %s, want %s", got, want)
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	// Sebagian besar dari contoh RFC 7396 Appendix A
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"ganti nilai", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"tambah field", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"null menghapus field", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"hapus field yang tidak ada", `{"a":"b"}`, `{"x":null}`, `{"a":"b"}`},
+		{"array diganti utuh", `{"a":["b"]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"object digabung rekursif", `{"a":{"b":"c","d":"e"}}`, `{"a":{"b":"x","d":null}}`, `{"a":{"b":"x"}}`},
+		{"object menggantikan nilai biasa", `{"a":"b"}`, `{"a":{"c":null,"d":1}}`, `{"a":{"d":1}}`},
+		{"patch bukan object", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"patch kosong", `{"a":"b"}`, `{}`, `{"a":"b"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyMergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("ApplyMergePatch error: %v", err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+
+	if _, err := ApplyMergePatch([]byte(`{}`), []byte(`{`)); err == nil || !strings.Contains(err.Error(), "patch tidak valid") {
+		t.Errorf("patch rusak: error = %v", err)
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr string
+	}{
+		{"add field", `{"a":1}`, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`, ""},
+		{"add ke tengah array", `{"a":[1,3]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2,3]}`, ""},
+		{"add ke akhir array", `{"a":[1]}`, `[{"op":"add","path":"/a/-","value":2}]`, `{"a":[1,2]}`, ""},
+		{"add tanpa value", `{}`, `[{"op":"add","path":"/a"}]`, "", "membutuhkan value"},
+		{"add value null", `{}`, `[{"op":"add","path":"/a","value":null}]`, `{"a":null}`, ""},
+		{"remove field", `{"a":1,"b":2}`, `[{"op":"remove","path":"/a"}]`, `{"b":2}`, ""},
+		{"remove elemen array", `{"a":[1,2,3]}`, `[{"op":"remove","path":"/a/1"}]`, `{"a":[1,3]}`, ""},
+		{"remove path tidak ada", `{"a":1}`, `[{"op":"remove","path":"/b"}]`, "", "tidak ditemukan"},
+		{"remove dokumen utama", `{"a":1}`, `[{"op":"remove","path":""}]`, "", "dokumen utama"},
+		{"replace field", `{"a":1}`, `[{"op":"replace","path":"/a","value":"x"}]`, `{"a":"x"}`, ""},
+		{"replace path tidak ada", `{"a":1}`, `[{"op":"replace","path":"/b","value":2}]`, "", "tidak ditemukan"},
+		{"replace dokumen utama", `{"a":1}`, `[{"op":"replace","path":"","value":{"b":2}}]`, `{"b":2}`, ""},
+		{"move field", `{"a":{"b":1},"c":{}}`, `[{"op":"move","from":"/a/b","path":"/c/d"}]`, `{"a":{},"c":{"d":1}}`, ""},
+		{"move ke dalam dirinya sendiri", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`, "", "ke dalam dirinya sendiri"},
+		{"copy tidak berbagi nilai", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`, ""},
+		{"test cocok", `{"a":[1,{"b":"x"}]}`, `[{"op":"test","path":"/a/1/b","value":"x"}]`, `{"a":[1,{"b":"x"}]}`, ""},
+		{"test tidak cocok", `{"a":1}`, `[{"op":"test","path":"/a","value":2}]`, "", "operasi test gagal"},
+		{"test path tidak ada", `{"a":1}`, `[{"op":"test","path":"/b","value":1}]`, "", "operasi test gagal"},
+		{"escape pointer", `{"a/b":1,"c~d":2}`, `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/c~0d"}]`, `{"a/b":3}`, ""},
+		{"index array di luar jangkauan", `{"a":[1]}`, `[{"op":"replace","path":"/a/5","value":2}]`, "", "di luar jangkauan"},
+		{"index array dengan nol di depan", `{"a":[1,2]}`, `[{"op":"remove","path":"/a/01"}]`, "", "di luar jangkauan"},
+		{"path tanpa garis miring", `{"a":1}`, `[{"op":"remove","path":"a"}]`, "", "harus diawali"},
+		{"operasi tidak dikenal", `{"a":1}`, `[{"op":"merge","path":"/a"}]`, "", "tidak dikenal"},
+		{"patch bukan array", `{"a":1}`, `{"op":"remove","path":"/a"}`, "", "harus berupa array"},
+		{"gagal di tengah membatalkan semua", `{"a":1}`, `[{"op":"replace","path":"/a","value":2},{"op":"remove","path":"/b"}]`, "", "operasi ke-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyJSONPatch([]byte(tt.doc), []byte(tt.patch))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyJSONPatch error: %v", err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestApplyPatchContentType(t *testing.T) {
+	doc := []byte(`{"a":1,"b":2}`)
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+		want        string
+		wantErr     bool
+	}{
+		{"merge patch", MergePatchContentType, `{"a":null}`, `{"b":2}`, false},
+		{"merge patch dengan charset", "application/merge-patch+json; charset=utf-8", `{"a":3}`, `{"a":3,"b":2}`, false},
+		{"application/json sebagai merge patch", "application/json", `{"b":null}`, `{"a":1}`, false},
+		{"json patch", JSONPatchContentType, `[{"op":"remove","path":"/a"}]`, `{"b":2}`, false},
+		{"huruf besar", "Application/JSON-Patch+JSON", `[{"op":"remove","path":"/b"}]`, `{"a":1}`, false},
+		{"tidak didukung", "text/plain", `{}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyPatch(doc, []byte(tt.patch), tt.contentType)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ApplyPatch = %s, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyPatch error: %v", err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestDecodeStrict(t *testing.T) {
+	type target struct {
+		Nama  string `json:"nama"`
+		Email string `json:"email"`
+	}
+	tests := []struct {
+		name    string
+		data    string
+		want    target
+		wantErr string
+	}{
+		{"field dikenal", `{"nama":"Budi","email":"budi@example.com"}`, target{"Budi", "budi@example.com"}, ""},
+		{"field tidak dikenal", `{"nama":"Budi","role":"admin"}`, target{}, `field "role" tidak dapat diubah`},
+		{"tipe salah", `{"nama":1}`, target{}, "patch tidak valid"},
+		{"bukan JSON", `nama=Budi`, target{}, "patch tidak valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got target
+			err := DecodeStrict([]byte(tt.data), &got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeStrict error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("hasil = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
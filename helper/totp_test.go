@@ -0,0 +1,122 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret adalah secret SHA1 dari RFC 6238 Appendix B
+// ("12345678901234567890" dalam base32)
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTPRFC6238(t *testing.T) {
+	// Kode 8 digit RFC 6238, dipotong ke TOTPDigits (6) digit terakhir
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			code := tt.code[len(tt.code)-TOTPDigits:]
+			now := time.Unix(tt.unix, 0)
+			ok, step := ValidateTOTP(rfc6238Secret, code, now)
+			if !ok {
+				t.Fatalf("ValidateTOTP(%s) at %d = false, want true", code, tt.unix)
+			}
+			if want := tt.unix / TOTPPeriod; step != want {
+				t.Errorf("step = %d, want %d", step, want)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	// Kode step 37037036 (waktu 1111111109 pada RFC 6238)
+	const code = "081804"
+	const codeStep = int64(1111111109 / TOTPPeriod)
+	at := func(step int64) time.Time { return time.Unix(step*TOTPPeriod, 0) }
+
+	tests := []struct {
+		name string
+		now  time.Time
+		ok   bool
+	}{
+		{"step yang sama", at(codeStep), true},
+		{"jam perangkat terlambat satu step", at(codeStep + 1), true},
+		{"jam perangkat lebih cepat satu step", at(codeStep - 1), true},
+		{"terlambat dua step", at(codeStep + 2), false},
+		{"lebih cepat dua step", at(codeStep - 2), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, step := ValidateTOTP(rfc6238Secret, code, tt.now)
+			if ok != tt.ok {
+				t.Fatalf("ValidateTOTP = %v, want %v", ok, tt.ok)
+			}
+			if ok && step != codeStep {
+				t.Errorf("step = %d, want %d", step, codeStep)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPInput(t *testing.T) {
+	now := time.Unix(59, 0)
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		ok     bool
+	}{
+		{"spasi di tepi kode", rfc6238Secret, " 287082 ", true},
+		{"secret huruf kecil dengan padding", strings.ToLower(rfc6238Secret) + "====", "287082", true},
+		{"kode 8 digit", rfc6238Secret, "94287082", false},
+		{"kode salah", rfc6238Secret, "287083", false},
+		{"secret tidak valid", "bukan-base32!", "287082", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok, _ := ValidateTOTP(tt.secret, tt.code, now); ok != tt.ok {
+				t.Errorf("ValidateTOTP = %v, want %v", ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"abcde-fghij", "abcde-fghij"},
+		{"ABCDE-FGHIJ", "abcde-fghij"},
+		{"  abcde-fghij\n", "abcde-fghij"},
+		{"abcdefghij", "abcde-fghij"},
+		{"abcde fghij", "abcde-fghij"},
+		{"ABC DE FGH IJ", "abcde-fghij"},
+		{"abcd", "abcd"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeRecoveryCode(tt.input); got != tt.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	codes, err := GenerateRecoveryCodes(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range codes {
+		if got := NormalizeRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", ""))); got != code {
+			t.Errorf("kode %q dinormalisasi menjadi %q", code, got)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package helper
 
 import (
+	"alumni-crud-api/app/model"
 	"fmt"
+	"regexp"
 	"strings"
 )
 
@@ -40,7 +42,7 @@ func ValidateCreateAlumni(nim, nama, jurusan, email string, angkatan, tahunLulus
 
 func ValidateCreatePekerjaan(
 	alumniID string, // <-- Diubah dari int ke string
-	namaPerusahaan, posisiJabatan, bidangIndustri, lokasiKerja, tanggalMulaiKerja, statusPekerjaan string,
+	namaPerusahaan, posisiJabatan, bidangIndustri, lokasiKerja, tanggalMulaiKerja, statusPekerjaan, jenisPekerjaan string,
 ) error {
 	var errors []string
 
@@ -69,6 +71,9 @@ func ValidateCreatePekerjaan(
 	if statusPekerjaan != "aktif" && statusPekerjaan != "selesai" && statusPekerjaan != "resigned" {
 		errors = append(errors, "Status pekerjaan must be 'aktif', 'selesai', or 'resigned'")
 	}
+	if jenisPekerjaan != "" && !isJenisPekerjaan(jenisPekerjaan) {
+		errors = append(errors, "Jenis pekerjaan must be one of "+strings.Join(model.JenisPekerjaanList, ", "))
+	}
 
 	if len(errors) > 0 {
 		return fmt.Errorf(strings.Join(errors, ", "))
@@ -104,7 +109,7 @@ func ValidateUpdateAlumni(nama, jurusan, email string, angkatan, tahunLulus int)
 	return nil
 }
 
-func ValidateUpdatePekerjaan(namaPerusahaan, posisiJabatan, bidangIndustri, lokasiKerja, tanggalMulaiKerja, statusPekerjaan string) error {
+func ValidateUpdatePekerjaan(namaPerusahaan, posisiJabatan, bidangIndustri, lokasiKerja, tanggalMulaiKerja, statusPekerjaan, jenisPekerjaan string) error {
 	var errors []string
 
 	if namaPerusahaan == "" {
@@ -128,6 +133,185 @@ func ValidateUpdatePekerjaan(namaPerusahaan, posisiJabatan, bidangIndustri, loka
 	if statusPekerjaan != "aktif" && statusPekerjaan != "selesai" && statusPekerjaan != "resigned" {
 		errors = append(errors, "Status pekerjaan must be 'aktif', 'selesai', or 'resigned'")
 	}
+	if jenisPekerjaan != "" && !isJenisPekerjaan(jenisPekerjaan) {
+		errors = append(errors, "Jenis pekerjaan must be one of "+strings.Join(model.JenisPekerjaanList, ", "))
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf(strings.Join(errors, ", "))
+	}
+	return nil
+}
+
+func isJenisPekerjaan(jenis string) bool {
+	for _, j := range model.JenisPekerjaanList {
+		if j == jenis {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePeriodePekerjaan membaca tanggal mulai/selesai kerja (format yang
+// diterima ParseTanggal) lalu memastikan tanggal selesai tidak sebelum tanggal
+// mulai dan sesuai dengan status pekerjaan
+func ValidatePeriodePekerjaan(tanggalMulaiKerja string, tanggalSelesaiKerja *string, statusPekerjaan string) (*model.PeriodePekerjaan, error) {
+	var errors []string
+	periode := &model.PeriodePekerjaan{}
+
+	mulai, err := ParseTanggal(tanggalMulaiKerja)
+	if err != nil {
+		errors = append(errors, "Tanggal mulai kerja must be a valid date: "+err.Error())
+	}
+	periode.Mulai = model.NewDate(mulai)
+
+	if tanggalSelesaiKerja != nil && strings.TrimSpace(*tanggalSelesaiKerja) != "" {
+		selesai, err := ParseTanggal(*tanggalSelesaiKerja)
+		if err != nil {
+			errors = append(errors, "Tanggal selesai kerja must be a valid date: "+err.Error())
+		} else {
+			date := model.NewDate(selesai)
+			periode.Selesai = &date
+			if !mulai.IsZero() && selesai.Before(mulai) {
+				errors = append(errors, "Tanggal selesai kerja cannot be earlier than tanggal mulai kerja")
+			}
+		}
+	}
+
+	switch statusPekerjaan {
+	case model.StatusPekerjaanAktif:
+		if periode.Selesai != nil {
+			errors = append(errors, "Tanggal selesai kerja must be empty when status pekerjaan is 'aktif'")
+		}
+	case model.StatusPekerjaanSelesai, model.StatusPekerjaanResigned:
+		if tanggalSelesaiKerja == nil || strings.TrimSpace(*tanggalSelesaiKerja) == "" {
+			errors = append(errors, "Tanggal selesai kerja is required when status pekerjaan is '"+statusPekerjaan+"'")
+		}
+	}
+
+	if len(errors) > 0 {
+		return nil, fmt.Errorf(strings.Join(errors, ", "))
+	}
+	return periode, nil
+}
+
+func ValidateRegister(username, email, password string) error {
+	var errors []string
+
+	if username == "" {
+		errors = append(errors, "Username is required")
+	}
+	if len(username) > 0 && len(username) < 3 {
+		errors = append(errors, "Username must be at least 3 characters")
+	}
+	if strings.ContainsAny(username, " @") {
+		errors = append(errors, "Username cannot contain spaces or '@'")
+	}
+	if email == "" {
+		errors = append(errors, "Email is required")
+	} else if !strings.Contains(email, "@") {
+		errors = append(errors, "Email is not valid")
+	}
+	if err := ValidatePassword(password); err != nil {
+		errors = append(errors, err.Error())
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf(strings.Join(errors, ", "))
+	}
+	return nil
+}
+
+func ValidatePassword(password string) error {
+	if len(password) < 8 {
+		return fmt.Errorf("Password must be at least 8 characters")
+	}
+	if len(password) > 72 {
+		// bcrypt hanya memakai 72 byte pertama
+		return fmt.Errorf("Password must be at most 72 characters")
+	}
+	return nil
+}
+
+func ValidateCreateUser(username, email, password, role string) error {
+	var errors []string
+
+	if err := ValidateRegister(username, email, password); err != nil {
+		errors = append(errors, err.Error())
+	}
+	// Keberadaan role diperiksa di service (role disimpan di database)
+	if role == "" {
+		errors = append(errors, "Role is required")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf(strings.Join(errors, ", "))
+	}
+	return nil
+}
+
+func ValidateUpdateUser(username, email, role string, password *string) error {
+	var errors []string
+
+	if username == "" {
+		errors = append(errors, "Username is required")
+	}
+	if strings.ContainsAny(username, " @") {
+		errors = append(errors, "Username cannot contain spaces or '@'")
+	}
+	if email == "" {
+		errors = append(errors, "Email is required")
+	} else if !strings.Contains(email, "@") {
+		errors = append(errors, "Email is not valid")
+	}
+	// Keberadaan role diperiksa di service (role disimpan di database)
+	if role == "" {
+		errors = append(errors, "Role is required")
+	}
+	if password != nil {
+		if err := ValidatePassword(*password); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf(strings.Join(errors, ", "))
+	}
+	return nil
+}
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{2,31}$`)
+
+func ValidateCreateRole(name string, permissions []string) error {
+	var errors []string
+
+	if name == "" {
+		errors = append(errors, "Role name is required")
+	} else if !roleNamePattern.MatchString(name) {
+		errors = append(errors, "Role name must be 3-32 lowercase letters, digits, '_' or '-'")
+	}
+	if err := ValidatePermissions(permissions); err != nil {
+		errors = append(errors, err.Error())
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf(strings.Join(errors, ", "))
+	}
+	return nil
+}
+
+func ValidatePermissions(permissions []string) error {
+	var errors []string
+
+	valid := map[string]bool{}
+	for _, p := range model.AllPermissions {
+		valid[p] = true
+	}
+	for _, p := range permissions {
+		if !valid[p] {
+			errors = append(errors, fmt.Sprintf("Unknown permission '%s'", p))
+		}
+	}
 
 	if len(errors) > 0 {
 		return fmt.Errorf(strings.Join(errors, ", "))
@@ -0,0 +1,54 @@
+package helper
+
+import (
+	"alumni-crud-api/app/model"
+	"strings"
+	"testing"
+)
+
+func TestValidatePeriodePekerjaan(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name    string
+		mulai   string
+		selesai *string
+		status  string
+		want    string // Tanggal selesai hasil validasi; "" berarti nil
+		wantErr string
+	}{
+		{"aktif tanpa tanggal selesai", "2021-03-15", nil, model.StatusPekerjaanAktif, "", ""},
+		{"aktif dengan tanggal selesai kosong", "2021-03-15", str("  "), model.StatusPekerjaanAktif, "", ""},
+		{"aktif dengan tanggal selesai", "2021-03-15", str("2022-01-01"), model.StatusPekerjaanAktif, "", "must be empty when status pekerjaan is 'aktif'"},
+		{"selesai dengan tanggal selesai", "2021-03-15", str("Januari 2022"), model.StatusPekerjaanSelesai, "2022-01-01", ""},
+		{"selesai di hari yang sama", "2021-03-15", str("15/03/2021"), model.StatusPekerjaanSelesai, "2021-03-15", ""},
+		{"selesai tanpa tanggal selesai", "2021-03-15", nil, model.StatusPekerjaanSelesai, "", "is required when status pekerjaan is 'selesai'"},
+		{"resigned tanpa tanggal selesai", "2021-03-15", str(""), model.StatusPekerjaanResigned, "", "is required when status pekerjaan is 'resigned'"},
+		{"selesai sebelum mulai", "2021-03-15", str("2021-03-14"), model.StatusPekerjaanSelesai, "", "cannot be earlier than tanggal mulai kerja"},
+		{"tanggal mulai tidak valid", "kemarin", nil, model.StatusPekerjaanAktif, "", "Tanggal mulai kerja must be a valid date"},
+		{"tanggal selesai tidak valid", "2021-03-15", str("besok"), model.StatusPekerjaanSelesai, "", "Tanggal selesai kerja must be a valid date"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			periode, err := ValidatePeriodePekerjaan(tt.mulai, tt.selesai, tt.status)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if periode.Mulai.String() != "2021-03-15" {
+				t.Errorf("Mulai = %s, want 2021-03-15", periode.Mulai)
+			}
+			got := ""
+			if periode.Selesai != nil {
+				got = periode.Selesai.String()
+			}
+			if got != tt.want {
+				t.Errorf("Selesai = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
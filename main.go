@@ -10,6 +10,11 @@
 // @in header
 // @name Authorization
 // @description "Format: Bearer {token}"
+//
+// @securityDefinitions.apikey APIKeyHeader
+// @in header
+// @name X-API-Key
+// @description API key untuk integrasi antar sistem (format: ak_xxxxxxxx_...)
 
 package main
 
@@ -19,6 +24,7 @@ import (
 	"alumni-crud-api/config"
 	"alumni-crud-api/database"
 	_ "alumni-crud-api/docs"
+	"alumni-crud-api/helper"
 	"alumni-crud-api/route"
 	"fmt"
 	"log"
@@ -29,32 +35,75 @@ func main() {
 	// Load configuration
 	cfg := config.LoadConfig()
 
+	// Muat kunci penandatanganan JWT dari config
+	if err := helper.SetupJWT(cfg); err != nil {
+		log.Fatalf("Konfigurasi JWT tidak valid: %v", err)
+	}
+
 	// Connect to database (MongoDB)
 	db := database.ConnectMongo()
 
 	// Setup Fiber app
 	fiberApp := config.SetupApp()
 
-	// TAMBAHKAN INI: Sajikan folder 'uploads' secara statis
-	// Ini memungkinkan URL "http://.../uploads/foto/namafile.png" diakses
-	fiberApp.Static("/uploads", "./uploads")
-
 	// Initialize repositories
 	alumniRepo := repository.NewAlumniRepository(db)
 	pekerjaanRepo := repository.NewPekerjaanRepository(db)
 	authRepo := repository.NewAuthRepository(db)
 	fileRepo := repository.NewFileRepository(db) // BARU: Tambahkan file repo
+	tokenRepo := repository.NewTokenRepository(db)
+	resetRepo := repository.NewPasswordResetRepository(db)
+	attemptRepo := repository.NewLoginAttemptRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	oidcStateRepo := repository.NewOIDCStateRepository(db)
+	claimRepo := repository.NewAlumniClaimRepository(db)
+	changeRepo := repository.NewPendingChangeRepository(db)
+	auditRepo := repository.NewAuditLogRepository(db)
+	versionRepo := repository.NewRecordVersionRepository(db)
+
+	// Index semua koleksi; data duplikat yang menghalangi unique index dilaporkan di log
+	database.EnsureIndexes(database.IndexTargets(db))
+	// Role bawaan (admin, user) harus ada sebelum permission di-resolve
+	if err := roleRepo.SeedDefaults(); err != nil {
+		log.Fatalf("Gagal menyiapkan role bawaan: %v", err)
+	}
+
+	// Pengirim email (log/file untuk lokal, SMTP untuk produksi)
+	mailer := helper.NewMailer(cfg)
 
 	// Initialize services
-	alumniService := service.NewAlumniService(alumniRepo)
-	pekerjaanService := service.NewPekerjaanService(pekerjaanRepo, alumniRepo)
-	authService := service.NewAuthService(authRepo)
+	auditService := service.NewAuditService(auditRepo, versionRepo)
+	moderationService := service.NewModerationService(changeRepo, alumniRepo, pekerjaanRepo, authRepo, auditService, mailer)
+	alumniService := service.NewAlumniService(alumniRepo, pekerjaanRepo, fileRepo, authRepo, claimRepo, moderationService, auditService, mailer, cfg)
+	pekerjaanService := service.NewPekerjaanService(pekerjaanRepo, alumniRepo, moderationService, auditService, cfg)
+	authService := service.NewAuthService(authRepo, tokenRepo, resetRepo, attemptRepo, auditService, mailer, cfg)
 	// BARU: Tambahkan file service (membutuhkan alumniRepo untuk otorisasi)
-	fileService := service.NewFileService(fileRepo, alumniRepo)
+	fileService := service.NewFileService(fileRepo, alumniRepo, auditService)
+
+	// TAMBAHKAN INI: Sajikan folder 'uploads' secara statis
+	// Ini memungkinkan URL "http://.../uploads/foto/namafile.png" diakses.
+	// File milik alumni di trash diblokir lebih dulu oleh guard.
+	fiberApp.Use("/uploads", fileService.HiddenFileGuard)
+	fiberApp.Static("/uploads", "./uploads")
+
+	userService := service.NewUserService(authRepo, tokenRepo, attemptRepo, roleRepo, auditService, cfg)
+	roleService := service.NewRoleService(roleRepo, authRepo, auditService, cfg.Faculties)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, auditService)
+	// Login SSO kampus; provider nil jika OIDC_ISSUER_URL/OIDC_CLIENT_ID kosong
+	oidcProvider := helper.NewOIDCProvider(cfg)
+	if oidcProvider == nil {
+		log.Println("Login SSO (OIDC) tidak dikonfigurasi")
+	}
+	historyService := service.NewHistoryService(versionRepo, alumniRepo, pekerjaanRepo, auditService)
+	oidcService := service.NewOIDCService(authService, oidcProvider, oidcStateRepo, authRepo, alumniRepo, auditService, cfg)
+	// Auto-purge trash pekerjaan yang melewati masa simpan (TRASH_RETENTION)
+	trashPurgeService := service.NewTrashPurgeService(pekerjaanRepo, auditService, cfg)
+	trashPurgeService.Start()
 
 	// Setup routes
 	// BARU: Tambahkan fileService ke dalam pemanggilan SetupRoutes
-	route.SetupRoutes(fiberApp, alumniService, pekerjaanService, authService, fileService)
+	route.SetupRoutes(fiberApp, alumniService, pekerjaanService, authService, fileService, userService, roleService, apiKeyService, oidcService, moderationService, auditService, historyService, trashPurgeService)
 
 	// Start server
 	serverAddr := fmt.Sprintf(":%s", cfg.ServerPort)
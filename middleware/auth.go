@@ -1,59 +1,216 @@
 package middleware
 
 import (
-	"alumni-crud-api/helper"
+	"alumni-crud-api/app/model"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// AuthRequired middleware for authentication
-func AuthRequired() fiber.Handler {
+// TokenValidator memvalidasi access token termasuk status pencabutannya.
+// Diimplementasikan oleh service.AuthService.
+type TokenValidator interface {
+	ValidateAccessToken(tokenString string) (*model.JWTClaims, error)
+}
+
+// MFASetupValidator menambahkan validasi token challenge 2FA
+type MFASetupValidator interface {
+	TokenValidator
+	ValidateMFAToken(tokenString string, purpose string) (*model.JWTClaims, error)
+}
+
+// APIKeyValidator memvalidasi API key dari header X-API-Key.
+// Diimplementasikan oleh service.APIKeyService.
+type APIKeyValidator interface {
+	ValidateAPIKey(key string, ip string) (*model.APIKey, error)
+}
+
+// AuthRequired middleware for authentication. Menerima Bearer token user atau,
+// untuk integrasi antar sistem, API key di header X-API-Key.
+func AuthRequired(validator TokenValidator, apiKeys APIKeyValidator) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Get token from Authorization header
-		authHeader := c.Get("Authorization")
-		if authHeader == "" {
+		if key := c.Get("X-API-Key"); key != "" {
+			apiKey, err := apiKeys.ValidateAPIKey(key, c.IP())
+			if err != nil {
+				return c.Status(401).JSON(fiber.Map{
+					"success": false,
+					"message": "API key tidak valid, expired, atau sudah dicabut",
+				})
+			}
+			setAPIKeyLocals(c, apiKey)
+			return c.Next()
+		}
+
+		token, message := bearerToken(c)
+		if token == "" {
 			return c.Status(401).JSON(fiber.Map{
 				"success": false,
-				"message": "Token akses diperlukan",
+				"message": message,
 			})
 		}
 
-		// Extract token from "Bearer TOKEN"
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+		// Validate token (signature, expiry, dan daftar revoked)
+		claims, err := validator.ValidateAccessToken(token)
+		if err != nil {
+			return c.Status(401).JSON(fiber.Map{
+				"success": false,
+				"message": "Token tidak valid, expired, atau sudah dicabut",
+			})
+		}
+
+		setClaimsLocals(c, claims)
+		return c.Next()
+	}
+}
+
+// AuthOrMFASetup seperti AuthRequired, tetapi juga menerima token challenge
+// "mfa_setup" agar admin yang wajib 2FA bisa mendaftarkan authenticator.
+// Local "mfa_setup" bernilai true jika request memakai token challenge.
+func AuthOrMFASetup(validator MFASetupValidator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token, message := bearerToken(c)
+		if token == "" {
 			return c.Status(401).JSON(fiber.Map{
 				"success": false,
-				"message": "Format token tidak valid",
+				"message": message,
 			})
 		}
 
-		// Validate token
-		claims, err := helper.ValidateToken(tokenParts[1])
+		mfaSetup := false
+		claims, err := validator.ValidateAccessToken(token)
+		if err != nil {
+			claims, err = validator.ValidateMFAToken(token, model.TokenPurposeMFASetup)
+			mfaSetup = true
+		}
 		if err != nil {
 			return c.Status(401).JSON(fiber.Map{
 				"success": false,
-				"message": "Token tidak valid atau expired",
+				"message": "Token tidak valid, expired, atau sudah dicabut",
 			})
 		}
 
-		// Store user info in context
-		c.Locals("user_id", claims.UserID) // Ini sekarang string
-		c.Locals("username", claims.Username)
-		c.Locals("role", claims.Role)
+		setClaimsLocals(c, claims)
+		c.Locals("mfa_setup", mfaSetup)
+		return c.Next()
+	}
+}
+
+// bearerToken mengambil token dari header "Authorization: Bearer TOKEN".
+// Jika gagal, token kosong dan message berisi alasannya.
+func bearerToken(c *fiber.Ctx) (token string, message string) {
+	authHeader := c.Get("Authorization")
+	if authHeader == "" {
+		return "", "Token akses diperlukan"
+	}
 
+	tokenParts := strings.Split(authHeader, " ")
+	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+		return "", "Format token tidak valid"
+	}
+	return tokenParts[1], ""
+}
+
+// Store user info in context
+func setClaimsLocals(c *fiber.Ctx, claims *model.JWTClaims) {
+	c.Locals("user_id", claims.UserID) // Ini sekarang string
+	c.Locals("username", claims.Username)
+	c.Locals("role", claims.Role)
+	c.Locals("jti", claims.ID)
+	c.Locals("token_expires_at", claims.ExpiresAt.Time)
+	c.Locals("user_scope", claims.Scope)
+}
+
+// setAPIKeyLocals mengisi local yang sama dengan token user. Request dengan
+// API key dicatat atas nama pembuat key dan tidak memiliki role.
+func setAPIKeyLocals(c *fiber.Ctx, apiKey *model.APIKey) {
+	c.Locals("user_id", apiKey.CreatedBy.Hex())
+	c.Locals("username", "apikey:"+apiKey.Prefix)
+	c.Locals("role", "")
+	c.Locals("api_key", apiKey)
+}
+
+// RequireUserSession menolak request dengan API key, untuk endpoint yang
+// hanya masuk akal bagi sesi login user (profil, logout, password, 2FA)
+func RequireUserSession() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, ok := c.Locals("api_key").(*model.APIKey); ok {
+			return c.Status(403).JSON(fiber.Map{
+				"success": false,
+				"message": "Akses ditolak. Endpoint ini tidak tersedia untuk API key",
+			})
+		}
 		return c.Next()
 	}
 }
 
-// Middleware for admin-only access
-func AdminOnly() fiber.Handler {
+// AccessResolver memetakan role ke permission dan scope user ke daftar jurusan.
+// Diimplementasikan oleh service.RoleService.
+type AccessResolver interface {
+	PermissionsForRole(role string) (model.PermissionSet, error)
+	ResolveScope(scope *model.UserScope) *model.DataScope
+}
+
+// LoadActor dipasang setelah AuthRequired dan menyimpan *model.Actor di local "actor".
+// Permission di-resolve setiap request sehingga perubahan role langsung berlaku
+// tanpa menerbitkan ulang token.
+func LoadActor(resolver AccessResolver) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		// Permission API key adalah scope yang ditetapkan saat key dibuat
+		if apiKey, ok := c.Locals("api_key").(*model.APIKey); ok {
+			c.Locals("actor", &model.Actor{
+				UserID:      apiKey.CreatedBy.Hex(),
+				Username:    "apikey:" + apiKey.Prefix,
+				Permissions: model.NewPermissionSet(apiKey.Scopes),
+			})
+			return c.Next()
+		}
+
 		role := c.Locals("role").(string)
-		if role != "admin" {
+		permissions, err := resolver.PermissionsForRole(role)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"message": "Gagal memuat hak akses",
+			})
+		}
+
+		userScope, _ := c.Locals("user_scope").(*model.UserScope)
+		c.Locals("actor", &model.Actor{
+			UserID:      c.Locals("user_id").(string),
+			Username:    c.Locals("username").(string),
+			Role:        role,
+			Permissions: permissions,
+			Scope:       resolver.ResolveScope(userScope),
+		})
+		return c.Next()
+	}
+}
+
+// RequirePermission hanya meneruskan request jika user memiliki semua permission
+func RequirePermission(permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := c.Locals("actor").(*model.Actor)
+		for _, p := range permissions {
+			if !actor.Can(p) {
+				return c.Status(403).JSON(fiber.Map{
+					"success": false,
+					"message": "Akses ditolak. Membutuhkan permission " + p,
+				})
+			}
+		}
+		return c.Next()
+	}
+}
+
+// RequireUnscoped menolak user yang dibatasi scope jurusan, misalnya untuk
+// manajemen user dan role yang berlaku lintas jurusan
+func RequireUnscoped() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := c.Locals("actor").(*model.Actor)
+		if actor.Scope != nil {
 			return c.Status(403).JSON(fiber.Map{
 				"success": false,
-				"message": "Akses ditolak. Hanya admin yang diizinkan",
+				"message": "Akses ditolak. Endpoint ini tidak tersedia untuk user dengan scope jurusan",
 			})
 		}
 		return c.Next()
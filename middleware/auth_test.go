@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"alumni-crud-api/app/model"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeResolver struct {
+	faculties map[string][]string
+}
+
+func (r fakeResolver) PermissionsForRole(role string) (model.PermissionSet, error) {
+	if role == "admin" {
+		return model.NewPermissionSet([]string{"alumni:read", "users:manage"}), nil
+	}
+	return model.NewPermissionSet(nil), nil
+}
+
+func (r fakeResolver) ResolveScope(scope *model.UserScope) *model.DataScope {
+	return scope.Resolve(r.faculties)
+}
+
+// scopeTestApp memasang local seperti AuthRequired lalu LoadActor, sehingga
+// setiap request memakai scope dari header X-Test-Fakultas (jika ada).
+func scopeTestApp(t *testing.T) *fiber.App {
+	t.Helper()
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if c.Get("X-Test-API-Key") != "" {
+			c.Locals("api_key", &model.APIKey{CreatedBy: primitive.NewObjectID(), Prefix: "ak_test", Scopes: []string{"alumni:read"}})
+			return c.Next()
+		}
+		c.Locals("user_id", "u1")
+		c.Locals("username", "admin-fakultas")
+		c.Locals("role", "admin")
+		if fakultas := c.Get("X-Test-Fakultas"); fakultas != "" {
+			c.Locals("user_scope", &model.UserScope{Fakultas: []string{fakultas}})
+		}
+		return c.Next()
+	})
+	app.Use(LoadActor(fakeResolver{faculties: map[string][]string{"teknik": {"Teknik Informatika"}}}))
+	app.Get("/alumni", RequirePermission("alumni:read"), func(c *fiber.Ctx) error {
+		if !c.Locals("actor").(*model.Actor).Scope.Allows(c.Query("jurusan")) {
+			return c.SendStatus(404)
+		}
+		return c.SendStatus(200)
+	})
+	app.Get("/users", RequirePermission("users:manage"), RequireUnscoped(), func(c *fiber.Ctx) error {
+		return c.SendStatus(200)
+	})
+	return app
+}
+
+func doScopeRequest(t *testing.T, app *fiber.App, path string, headers map[string]string) int {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+func TestLoadActorResolvesFacultyScope(t *testing.T) {
+	app := scopeTestApp(t)
+	scoped := map[string]string{"X-Test-Fakultas": "Teknik"}
+
+	if got := doScopeRequest(t, app, "/alumni?jurusan=teknik+informatika", scoped); got != 200 {
+		t.Errorf("jurusan dalam fakultas: status %d, ingin 200", got)
+	}
+	if got := doScopeRequest(t, app, "/alumni?jurusan=Akuntansi", scoped); got != 404 {
+		t.Errorf("jurusan di luar fakultas: status %d, ingin 404", got)
+	}
+	if got := doScopeRequest(t, app, "/alumni?jurusan=Akuntansi", nil); got != 200 {
+		t.Errorf("admin tanpa scope: status %d, ingin 200", got)
+	}
+	// Fakultas yang tidak ada di config tidak boleh membuka akses ke semua jurusan
+	if got := doScopeRequest(t, app, "/alumni?jurusan=Teknik+Informatika", map[string]string{"X-Test-Fakultas": "Hukum"}); got != 404 {
+		t.Errorf("fakultas tidak dikenal: status %d, ingin 404", got)
+	}
+}
+
+func TestRequireUnscopedRejectsScopedAdmin(t *testing.T) {
+	app := scopeTestApp(t)
+
+	if got := doScopeRequest(t, app, "/users", map[string]string{"X-Test-Fakultas": "Teknik"}); got != 403 {
+		t.Errorf("admin fakultas ke manajemen user: status %d, ingin 403", got)
+	}
+	if got := doScopeRequest(t, app, "/users", nil); got != 200 {
+		t.Errorf("admin tanpa scope ke manajemen user: status %d, ingin 200", got)
+	}
+	if got := doScopeRequest(t, app, "/users", map[string]string{"X-Test-API-Key": "1"}); got != 403 {
+		t.Errorf("API key tanpa permission users:manage: status %d, ingin 403", got)
+	}
+}
@@ -1,6 +1,7 @@
 package route
 
 import (
+	"alumni-crud-api/app/model"
 	"alumni-crud-api/app/service"
 	_ "alumni-crud-api/docs" // Impor folder docs yang di-generate
 	"alumni-crud-api/middleware"
@@ -19,6 +20,14 @@ func SetupRoutes(
 	pekerjaanService service.PekerjaanService,
 	authService *service.AuthService,
 	fileService service.FileService,
+	userService service.UserService,
+	roleService service.RoleService,
+	apiKeyService service.APIKeyService,
+	oidcService *service.OIDCService,
+	moderationService service.ModerationService,
+	auditService service.AuditService,
+	historyService service.HistoryService,
+	trashPurgeService service.TrashPurgeService,
 ) {
 
 	// TAMBAHKAN INI: Handler untuk Swagger UI
@@ -42,37 +51,138 @@ func SetupRoutes(
 		})
 	})
 
+	// Public key untuk verifikasi token oleh service lain
+	fiberApp.Get("/.well-known/jwks.json", authService.HandleJWKS)
+
 	// Grup API utama Anda
 	api := fiberApp.Group("/alumni-crud-api")
 
 	// Rute Autentikasi (tidak berubah)
 	auth := api.Group("/auth")
 	auth.Post("/login", authService.HandleLogin)
+	auth.Post("/register", authService.HandleRegister)
+	auth.Post("/refresh", authService.HandleRefresh)
+	auth.Post("/forgot-password", authService.HandleForgotPassword)
+	auth.Post("/reset-password", authService.HandleResetPassword)
+	auth.Post("/2fa/login", authService.HandleLoginMFA)
+	auth.Get("/oidc/login", oidcService.HandleOIDCLogin)
+	auth.Get("/oidc/callback", oidcService.HandleOIDCCallback)
+
+	// Pendaftaran 2FA juga menerima token challenge "mfa_setup" (admin wajib 2FA)
+	mfaSetup := middleware.AuthOrMFASetup(authService)
+	auth.Post("/2fa/setup", mfaSetup, authService.HandleSetupTOTP)
+	auth.Post("/2fa/verify", mfaSetup, authService.HandleVerifyTOTP)
+
+	// Grup rute yang dilindungi (memerlukan token atau API key)
+	protected := api.Group("", middleware.AuthRequired(authService, apiKeyService), middleware.LoadActor(roleService))
+	userSession := middleware.RequireUserSession()
+	protected.Get("/auth/profile", userSession, authService.HandleGetProfile)
+	protected.Post("/auth/logout", userSession, authService.HandleLogout)
+	protected.Post("/auth/logout-all", userSession, authService.HandleLogoutAll)
+	protected.Put("/auth/password", userSession, authService.HandleChangePassword)
+	protected.Post("/auth/2fa/disable", userSession, authService.HandleDisableTOTP)
+	protected.Post("/auth/oidc/link", userSession, oidcService.HandleOIDCLink)
+
+	// Rute Manajemen User
+	// Admin dengan scope jurusan tidak boleh mengelola user/role (bisa menaikkan hak akses sendiri)
+	users := protected.Group("/users", middleware.RequirePermission(model.PermUsersManage), middleware.RequireUnscoped())
+	users.Get("/", userService.HandleGetAllUsers)
+	users.Get("/login-attempts", userService.HandleGetLoginAttempts)
+	users.Post("/unlock-ip", userService.HandleUnlockIP)
+	users.Get("/:id", userService.HandleGetUserByID)
+	users.Post("/", userService.HandleCreateUser)
+	users.Put("/:id", userService.HandleUpdateUser)
+	users.Patch("/:id/disable", userService.HandleDisableUser)
+	users.Patch("/:id/enable", userService.HandleEnableUser)
+	users.Post("/:id/unlock", userService.HandleUnlockUser)
+	users.Get("/:id/login-attempts", userService.HandleGetUserLoginAttempts)
+	users.Delete("/:id", userService.HandleDeleteUser)
+
+	// Rute Manajemen Role & Permission
+	roles := protected.Group("/roles", middleware.RequirePermission(model.PermRolesManage), middleware.RequireUnscoped())
+	roles.Get("/", roleService.HandleGetAllRoles)
+	roles.Get("/permissions", roleService.HandleGetPermissions)
+	roles.Get("/:name", roleService.HandleGetRole)
+	roles.Post("/", roleService.HandleCreateRole)
+	roles.Put("/:name", roleService.HandleUpdateRole)
+	roles.Delete("/:name", roleService.HandleDeleteRole)
 
-	// Grup rute yang dilindungi (memerlukan token)
-	protected := api.Group("", middleware.AuthRequired())
-	protected.Get("/auth/profile", authService.HandleGetProfile)
+	// Rute Manajemen API Key (integrasi antar sistem)
+	apiKeys := protected.Group("/api-keys", userSession, middleware.RequirePermission(model.PermAPIKeysManage), middleware.RequireUnscoped())
+	apiKeys.Get("/", apiKeyService.HandleListAPIKeys)
+	apiKeys.Post("/", apiKeyService.HandleCreateAPIKey)
+	apiKeys.Delete("/:id", apiKeyService.HandleRevokeAPIKey)
+
+	// Rute Audit Log (seluruh data, jadi tidak untuk admin ber-scope)
+	audit := protected.Group("/audit-logs", middleware.RequirePermission(model.PermAuditRead), middleware.RequireUnscoped())
+	audit.Get("/", auditService.HandleListAuditLogs)
+	audit.Get("/export", auditService.HandleExportAuditLogs)
 
 	// Rute Alumni (tidak berubah)
 	alumni := protected.Group("/alumni")
+	// Data milik alumni yang sedang login (harus sebelum "/:id")
+	alumni.Get("/me", userSession, alumniService.HandleGetMyAlumni)
+	alumni.Put("/me", userSession, alumniService.HandleUpdateMyAlumni)
+	alumni.Post("/claim", userSession, alumniService.HandleRequestClaim)
+	alumni.Post("/claim/verify", userSession, alumniService.HandleVerifyClaim)
 	alumni.Get("/", alumniService.HandleGetAllAlumni)
+	alumni.Get("/trash", middleware.RequirePermission(model.PermAlumniDelete), alumniService.HandleListTrash)
 	alumni.Get("/:id", alumniService.HandleGetAlumniByID)
-	alumni.Post("/", middleware.AdminOnly(), alumniService.HandleCreateAlumni)
-	alumni.Put("/:id", middleware.AdminOnly(), alumniService.HandleUpdateAlumni)
-	alumni.Delete("/:id", middleware.AdminOnly(), alumniService.HandleDeleteAlumni)
+	alumni.Post("/", middleware.RequirePermission(model.PermAlumniWrite), alumniService.HandleCreateAlumni)
+	alumni.Put("/:id", middleware.RequirePermission(model.PermAlumniWrite), alumniService.HandleUpdateAlumni)
+	alumni.Patch("/:id", middleware.RequirePermission(model.PermAlumniWrite), alumniService.HandlePatchAlumni)
+	// DELETE memindahkan alumni ke trash (pekerjaan ikut ke trash, file disembunyikan)
+	alumni.Delete("/:id", middleware.RequirePermission(model.PermAlumniDelete), alumniService.HandleDeleteAlumni)
+	alumni.Patch("/:id/restore", middleware.RequirePermission(model.PermAlumniDelete), alumniService.HandleRestoreAlumni)
+	alumni.Delete("/:id/hard-delete", middleware.RequirePermission(model.PermAlumniHardDelete), alumniService.HandleHardDeleteAlumni)
+	alumni.Post("/:id/link", middleware.RequirePermission(model.PermAlumniWrite), alumniService.HandleLinkUser)
+	alumni.Delete("/:id/link", middleware.RequirePermission(model.PermAlumniWrite), alumniService.HandleUnlinkUser)
+	// Riwayat versi alumni ("as-of" dan "diff" harus sebelum "/:version")
+	alumniWrite := middleware.RequirePermission(model.PermAlumniWrite)
+	alumni.Get("/:id/history", alumniWrite, historyService.HandleListHistory(model.AuditEntityAlumni))
+	alumni.Get("/:id/history/as-of", alumniWrite, historyService.HandleGetVersionAsOf(model.AuditEntityAlumni))
+	alumni.Get("/:id/history/diff", alumniWrite, historyService.HandleDiffVersions(model.AuditEntityAlumni))
+	alumni.Get("/:id/history/:version", alumniWrite, historyService.HandleGetVersion(model.AuditEntityAlumni))
+	alumni.Post("/:id/history/:version/revert", alumniWrite, historyService.HandleRevert(model.AuditEntityAlumni))
 
 	// Rute Pekerjaan (tidak berubah)
 	pekerjaan := protected.Group("/pekerjaan")
 	pekerjaan.Get("/", pekerjaanService.HandleGetAllPekerjaan)
+	pekerjaan.Get("/me", userSession, pekerjaanService.HandleGetMyPekerjaan)
+	// Trash (harus sebelum "/:id"). Operasi massal tetap memakai pengecekan
+	// pemilik per item seperti restore/hard-delete satuan.
+	pekerjaan.Get("/trash", pekerjaanService.HandleListTrash)
+	pekerjaan.Get("/trash/purge-preview", middleware.RequirePermission(model.PermPekerjaanHardDelete), trashPurgeService.HandlePreviewPurge)
+	pekerjaan.Post("/trash/restore", pekerjaanService.HandleBulkRestore)
+	pekerjaan.Post("/trash/purge", pekerjaanService.HandleBulkPurge)
+	pekerjaan.Delete("/trash", pekerjaanService.HandleEmptyTrash)
 	pekerjaan.Get("/:id", pekerjaanService.HandleGetPekerjaanByID)
-	pekerjaan.Get("/alumni/:alumni_id", middleware.AdminOnly(), pekerjaanService.HandleGetPekerjaanByAlumniID)
-	pekerjaan.Post("/", middleware.AdminOnly(), pekerjaanService.HandleCreatePekerjaan)
-	pekerjaan.Put("/:id", middleware.AdminOnly(), pekerjaanService.HandleUpdatePekerjaan)
-	pekerjaan.Delete("/:id", middleware.AdminOnly(), pekerjaanService.HandleDeletePekerjaan)
+	pekerjaan.Get("/alumni/:alumni_id", middleware.RequirePermission(model.PermPekerjaanReadAny), pekerjaanService.HandleGetPekerjaanByAlumniID)
+	// Tanpa pekerjaan:write, create/update hanya untuk pekerjaan milik sendiri (dicek di service)
+	pekerjaan.Post("/", pekerjaanService.HandleCreatePekerjaan)
+	pekerjaan.Put("/:id", pekerjaanService.HandleUpdatePekerjaan)
+	pekerjaan.Patch("/:id", pekerjaanService.HandlePatchPekerjaan)
+	pekerjaan.Delete("/:id", middleware.RequirePermission(model.PermPekerjaanHardDelete), pekerjaanService.HandleDeletePekerjaan)
 	pekerjaan.Patch("/:id/soft-delete", pekerjaanService.HandleSoftDeletePekerjaan)
-	pekerjaan.Get("/trash", pekerjaanService.HandleListTrash)
 	pekerjaan.Patch("/:id/restore", pekerjaanService.HandleRestorePekerjaan)
 	pekerjaan.Delete("/:id/hard-delete", pekerjaanService.HandleHardDeletePekerjaan)
+	pekerjaan.Put("/:id/legal-hold", middleware.RequirePermission(model.PermPekerjaanLegalHold), pekerjaanService.HandleSetLegalHold)
+	// Riwayat versi pekerjaan
+	pekerjaanWrite := middleware.RequirePermission(model.PermPekerjaanWrite)
+	pekerjaan.Get("/:id/history", pekerjaanWrite, historyService.HandleListHistory(model.AuditEntityPekerjaan))
+	pekerjaan.Get("/:id/history/as-of", pekerjaanWrite, historyService.HandleGetVersionAsOf(model.AuditEntityPekerjaan))
+	pekerjaan.Get("/:id/history/diff", pekerjaanWrite, historyService.HandleDiffVersions(model.AuditEntityPekerjaan))
+	pekerjaan.Get("/:id/history/:version", pekerjaanWrite, historyService.HandleGetVersion(model.AuditEntityPekerjaan))
+	pekerjaan.Post("/:id/history/:version/revert", pekerjaanWrite, historyService.HandleRevert(model.AuditEntityPekerjaan))
+
+	// Rute Moderasi perubahan data yang diajukan alumni
+	moderation := protected.Group("/moderation")
+	moderation.Get("/changes/mine", userSession, moderationService.HandleListMyChanges)
+	review := middleware.RequirePermission(model.PermModerationReview)
+	moderation.Get("/changes", review, moderationService.HandleListChanges)
+	moderation.Get("/changes/:id", review, moderationService.HandleGetChangeDiff)
+	moderation.Post("/changes/:id/approve", review, moderationService.HandleApproveChange)
+	moderation.Post("/changes/:id/reject", review, moderationService.HandleRejectChange)
 
 	// Rute File Upload (tidak berubah)
 	upload := protected.Group("/upload")